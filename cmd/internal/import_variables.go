@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+	"code.cloudfoundry.org/quarks-utils/pkg/cmd"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+const importVariablesFailedMessage = "import-variables command failed."
+
+// importVariablesCmd creates the secrets of explicit variables from existing credentials
+var importVariablesCmd = &cobra.Command{
+	Use:   "import-variables [flags]",
+	Short: "Imports existing credentials of a BOSH deployment",
+	Long: `Imports existing credentials of a BOSH deployment.
+
+This will read a CredHub export (credhub export -p /<director>/<deployment>)
+or a BOSH vars-store file and create a secret for each variable in the watched
+namespace, named like the secrets of the BOSHDeployment's explicit variables.
+
+Imported secrets are treated as user-provided, so their QuarksSecrets don't
+regenerate them. Existing secrets are not changed. Run this before applying
+the BOSHDeployment.
+
+`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		deploymentNameFlagViperBind(cmd.Flags())
+		viper.BindPFlag("variables-file", cmd.Flags().Lookup("variables-file"))
+	},
+
+	RunE: func(_ *cobra.Command, args []string) error {
+		log = cmd.Logger()
+		defer log.Sync()
+
+		namespace := viper.GetString("watch-namespace")
+		if len(namespace) == 0 {
+			return errors.Errorf("%s watch-namespace flag is empty.", importVariablesFailedMessage)
+		}
+
+		deploymentName, err := deploymentNameFlagValidation()
+		if err != nil {
+			return errors.Wrap(err, importVariablesFailedMessage)
+		}
+
+		path := viper.GetString("variables-file")
+		if len(path) == 0 {
+			return errors.Errorf("%s variables-file flag is empty.", importVariablesFailedMessage)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "%s Reading file specified in the variables-file flag failed.", importVariablesFailedMessage)
+		}
+
+		secrets, err := manifest.ImportVariables(data, deploymentName, namespace)
+		if err != nil {
+			return errors.Wrap(err, importVariablesFailedMessage)
+		}
+
+		restConfig, err := cmd.KubeConfig(log)
+		if err != nil {
+			return errors.Wrap(err, importVariablesFailedMessage)
+		}
+
+		scheme := runtime.NewScheme()
+		if err := clientgoscheme.AddToScheme(scheme); err != nil {
+			return errors.Wrap(err, importVariablesFailedMessage)
+		}
+
+		client, err := crc.New(restConfig, crc.Options{Scheme: scheme})
+		if err != nil {
+			return errors.Wrapf(err, "%s Creating the kubernetes client failed.", importVariablesFailedMessage)
+		}
+
+		ctx := ctxlog.NewParentContext(log)
+
+		for i := range secrets {
+			secret := &secrets[i]
+			err = client.Create(ctx, secret)
+			if apierrors.IsAlreadyExists(err) {
+				fmt.Printf("Skipped secret '%s', it already exists\n", secret.Name)
+				continue
+			}
+			if err != nil {
+				return errors.Wrapf(err, "%s Creating secret '%s' failed.", importVariablesFailedMessage, secret.Name)
+			}
+			fmt.Printf("Imported secret '%s'\n", secret.Name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	utilCmd.AddCommand(importVariablesCmd)
+
+	pf := importVariablesCmd.Flags()
+	argToEnv := map[string]string{
+		"variables-file": "VARIABLES_FILE",
+	}
+
+	pf.StringP("variables-file", "v", "", "path to a CredHub export or a BOSH vars-store file")
+	deploymentNameFlagCobraSet(pf, argToEnv)
+	cmd.AddEnvToUsage(importVariablesCmd, argToEnv)
+}
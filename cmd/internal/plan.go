@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/boshdeployment"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
+	"code.cloudfoundry.org/quarks-utils/pkg/cmd"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+const planFailedMessage = "plan command failed."
+
+// planCmd calculates the changes a BOSHDeployment would apply to the cluster
+var planCmd = &cobra.Command{
+	Use:   "plan [flags]",
+	Short: "Shows the changes a BOSHDeployment would apply",
+	Long: `Shows the changes a BOSHDeployment would apply.
+
+This will resolve the manifest of a BOSHDeployment and compare the resulting
+QuarksSecrets, QuarksStatefulSets, Services and QuarksJobs with the live
+resources in the watched namespace. The plan is printed as YAML, nothing is changed.
+
+Use --bosh-deployment-path to plan a BOSHDeployment which has not been applied yet.
+
+`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		deploymentNameFlagViperBind(cmd.Flags())
+		boshDeploymentFlagViperBind(cmd.Flags())
+	},
+
+	RunE: func(_ *cobra.Command, args []string) error {
+		log = cmd.Logger()
+		defer log.Sync()
+
+		namespace := viper.GetString("watch-namespace")
+		if len(namespace) == 0 {
+			return errors.Errorf("%s watch-namespace flag is empty.", planFailedMessage)
+		}
+
+		crossnamespace.SetAllowedNamespaces(viper.GetStringSlice("reference-namespaces"))
+
+		restConfig, err := cmd.KubeConfig(log)
+		if err != nil {
+			return errors.Wrap(err, planFailedMessage)
+		}
+
+		scheme := runtime.NewScheme()
+		if err := clientgoscheme.AddToScheme(scheme); err != nil {
+			return errors.Wrap(err, planFailedMessage)
+		}
+		if err := controllers.AddToScheme(scheme); err != nil {
+			return errors.Wrap(err, planFailedMessage)
+		}
+
+		client, err := crc.New(restConfig, crc.Options{Scheme: scheme})
+		if err != nil {
+			return errors.Wrapf(err, "%s Creating the kubernetes client failed.", planFailedMessage)
+		}
+
+		ctx := ctxlog.NewParentContext(log)
+
+		bdpl, err := planBOSHDeployment(ctx, client, namespace)
+		if err != nil {
+			return errors.Wrap(err, planFailedMessage)
+		}
+
+		p, err := boshdeployment.NewPlanner(client, namespace).Plan(ctx, bdpl)
+		if err != nil {
+			return errors.Wrap(err, planFailedMessage)
+		}
+
+		out, err := p.Marshal()
+		if err != nil {
+			return errors.Wrapf(err, "%s YAML marshalling the plan failed.", planFailedMessage)
+		}
+
+		fmt.Print(string(out))
+		return nil
+	},
+}
+
+// planBOSHDeployment reads the BOSHDeployment from the file given by the
+// bosh-deployment-path flag, or from the cluster if no file is given.
+func planBOSHDeployment(ctx context.Context, client crc.Client, namespace string) (*bdv1.BOSHDeployment, error) {
+	bdpl := &bdv1.BOSHDeployment{}
+
+	path := viper.GetString("bosh-deployment-path")
+	if len(path) > 0 {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "Reading file specified in the bosh-deployment-path flag failed.")
+		}
+
+		err = yaml.Unmarshal(data, bdpl)
+		if err != nil {
+			return nil, errors.Wrap(err, "Loading BOSHDeployment file failed.")
+		}
+		bdpl.Namespace = namespace
+		return bdpl, nil
+	}
+
+	deploymentName, err := deploymentNameFlagValidation()
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: deploymentName}, bdpl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Getting BOSHDeployment '%s/%s' failed.", namespace, deploymentName)
+	}
+	return bdpl, nil
+}
+
+func init() {
+	utilCmd.AddCommand(planCmd)
+
+	pf := planCmd.PersistentFlags()
+	argToEnv := map[string]string{}
+
+	deploymentNameFlagCobraSet(pf, argToEnv)
+	boshDeploymentFlagCobraSet(pf, argToEnv)
+	cmd.AddEnvToUsage(planCmd, argToEnv)
+}
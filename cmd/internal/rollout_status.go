@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/client/clientset/versioned"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/rolloutstatus"
+	"code.cloudfoundry.org/quarks-utils/pkg/cmd"
+)
+
+const rolloutStatusFailedMessage = "rollout-status command failed."
+
+// rolloutStatusCmd shows the rollout status of a QuarksStatefulSet
+var rolloutStatusCmd = &cobra.Command{
+	Use:   "rollout-status <quarks-statefulset-name> [flags]",
+	Short: "Shows the rollout status of a QuarksStatefulSet",
+	Long: `Shows the rollout status of a QuarksStatefulSet.
+
+This will watch the status of the QuarksStatefulSet in the watched namespace
+until the rollout of all its StatefulSets is done, printing the phase,
+partition and updated pods of each unfinished StatefulSet.
+
+The command fails, if the rollout of a StatefulSet failed or was rolled back.
+
+`,
+	Args: cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		viper.BindPFlag("rollout-watch", cmd.Flags().Lookup("watch"))
+		viper.BindPFlag("rollout-timeout", cmd.Flags().Lookup("timeout"))
+	},
+
+	RunE: func(_ *cobra.Command, args []string) error {
+		log = cmd.Logger()
+		defer log.Sync()
+
+		namespace := viper.GetString("watch-namespace")
+		if len(namespace) == 0 {
+			return errors.Errorf("%s watch-namespace flag is empty.", rolloutStatusFailedMessage)
+		}
+		name := args[0]
+
+		restConfig, err := cmd.KubeConfig(log)
+		if err != nil {
+			return errors.Wrap(err, rolloutStatusFailedMessage)
+		}
+
+		clientSet, err := versioned.NewForConfig(restConfig)
+		if err != nil {
+			return errors.Wrapf(err, "%s Creating the kubernetes client failed.", rolloutStatusFailedMessage)
+		}
+		client := clientSet.QuarksstatefulsetV1alpha1().QuarksStatefulSets(namespace)
+
+		qStatefulSet, err := client.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "%s Getting QuarksStatefulSet '%s' failed.", rolloutStatusFailedMessage, name)
+		}
+
+		message, done, err := rolloutstatus.Status(qStatefulSet)
+		if err != nil {
+			return errors.Wrap(err, rolloutStatusFailedMessage)
+		}
+		fmt.Print(message)
+		if done || !viper.GetBool("rollout-watch") {
+			return nil
+		}
+
+		watcher, err := client.Watch(metav1.ListOptions{
+			FieldSelector:   fmt.Sprintf("metadata.name=%s", name),
+			ResourceVersion: qStatefulSet.ResourceVersion,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "%s Watching QuarksStatefulSet '%s' failed.", rolloutStatusFailedMessage, name)
+		}
+		defer watcher.Stop()
+
+		var timeout <-chan time.Time
+		if seconds := viper.GetInt("rollout-timeout"); seconds > 0 {
+			timeout = time.After(time.Duration(seconds) * time.Second)
+		}
+
+		for {
+			select {
+			case <-timeout:
+				return errors.Errorf("%s Timeout while waiting for the rollout of QuarksStatefulSet '%s'.", rolloutStatusFailedMessage, name)
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					return errors.Errorf("%s Watch of QuarksStatefulSet '%s' closed.", rolloutStatusFailedMessage, name)
+				}
+				if event.Type == watch.Deleted {
+					return errors.Errorf("%s QuarksStatefulSet '%s' was deleted.", rolloutStatusFailedMessage, name)
+				}
+				qStatefulSet, ok := event.Object.(*qstsv1a1.QuarksStatefulSet)
+				if !ok {
+					continue
+				}
+
+				status, done, err := rolloutstatus.Status(qStatefulSet)
+				if err != nil {
+					return errors.Wrap(err, rolloutStatusFailedMessage)
+				}
+				if status != message {
+					fmt.Print(status)
+					message = status
+				}
+				if done {
+					return nil
+				}
+			}
+		}
+	},
+}
+
+func init() {
+	utilCmd.AddCommand(rolloutStatusCmd)
+
+	pf := rolloutStatusCmd.Flags()
+	pf.Bool("watch", true, "watch the status until the rollout is done")
+	pf.Int("timeout", 0, "timeout in seconds for the rollout to finish, zero means no timeout")
+}
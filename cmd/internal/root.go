@@ -16,9 +16,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/quarkssecret"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/operator"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/operatorimage"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/secretbackend"
 	"code.cloudfoundry.org/cf-operator/version"
 	"code.cloudfoundry.org/quarks-utils/pkg/cmd"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
@@ -72,6 +75,26 @@ var rootCmd = &cobra.Command{
 			return wrapError(errors.New("watched namespace cannot be the same as the operators namespace"), "")
 		}
 
+		referenceNamespaces := viper.GetStringSlice("reference-namespaces")
+		for _, namespace := range referenceNamespaces {
+			if namespace == cfg.Namespace || namespace == cfg.OperatorNamespace {
+				return wrapError(errors.Errorf("reference namespace '%s' must neither be the watched nor the operators namespace", namespace), "")
+			}
+		}
+		crossnamespace.SetAllowedNamespaces(referenceNamespaces)
+
+		backends := map[string]secretbackend.Backend{}
+		if address := viper.GetString("vault-address"); address != "" {
+			backends[secretbackend.VaultBackend] = secretbackend.NewVault(address, viper.GetString("vault-mount"), os.Getenv("VAULT_TOKEN"))
+		}
+		if dir := viper.GetString("secret-backend-dir"); dir != "" {
+			backends[secretbackend.FileBackend] = secretbackend.NewFile(dir)
+		}
+		secretbackend.SetBackends(backends)
+
+		quarkssecret.SetRenewalWindow(viper.GetDuration("certificate-renewal-window"))
+		quarkssecret.SetCARotationGracePeriod(viper.GetDuration("ca-rotation-grace-period"))
+
 		boshdns.SetBoshDNSDockerImage(viper.GetString("bosh-dns-docker-image"))
 		boshdns.SetClusterDomain(viper.GetString("cluster-domain"))
 
@@ -152,6 +175,8 @@ func init() {
 	cmd.ApplyCRDsFlags(pf, argToEnv)
 
 	pf.StringP("bosh-dns-docker-image", "", "coredns/coredns:1.6.3", "The docker image used for emulating bosh DNS (a CoreDNS image)")
+	pf.Duration("ca-rotation-grace-period", quarkssecret.DefaultCARotationGracePeriod, "Keep the previous CA in the trust bundles of rotated CAs for this long")
+	pf.Duration("certificate-renewal-window", quarkssecret.DefaultRenewalWindow, "Renew generated certificates this long before they expire")
 	pf.String("cluster-domain", "cluster.local", "The Kubernetes cluster domain")
 	pf.Int("max-boshdeployment-workers", 1, "Maximum number of workers concurrently running BOSHDeployment controller")
 	pf.Int("max-quarks-secret-workers", 5, "Maximum number of workers concurrently running QuarksSecret controller")
@@ -159,9 +184,15 @@ func init() {
 	pf.StringP("operator-webhook-service-host", "w", "", "Hostname/IP under which the webhook server can be reached from the cluster")
 	pf.StringP("operator-webhook-service-port", "p", "2999", "Port the webhook server listens on")
 	pf.BoolP("operator-webhook-use-service-reference", "x", false, "If true the webhook service is targeted using a service reference instead of a URL")
+	pf.StringSlice("reference-namespaces", []string{}, "Namespaces with config maps and secrets, which BOSHDeployments can reference")
+	pf.String("secret-backend-dir", "", "Directory of the file secret backend, which stores QuarksSecrets as JSON files")
+	pf.String("vault-address", "", "Address of the vault secret backend, the token is read from VAULT_TOKEN")
+	pf.String("vault-mount", secretbackend.DefaultVaultMount, "Mount path of the KV version 2 secrets engine of the vault secret backend")
 
 	for _, name := range []string{
 		"bosh-dns-docker-image",
+		"ca-rotation-grace-period",
+		"certificate-renewal-window",
 		"cluster-domain",
 		"max-boshdeployment-workers",
 		"max-quarks-secret-workers",
@@ -169,11 +200,17 @@ func init() {
 		"operator-webhook-service-host",
 		"operator-webhook-service-port",
 		"operator-webhook-use-service-reference",
+		"reference-namespaces",
+		"secret-backend-dir",
+		"vault-address",
+		"vault-mount",
 	} {
 		viper.BindPFlag(name, pf.Lookup(name))
 	}
 
 	argToEnv["bosh-dns-docker-image"] = "BOSH_DNS_DOCKER_IMAGE"
+	argToEnv["ca-rotation-grace-period"] = "CA_ROTATION_GRACE_PERIOD"
+	argToEnv["certificate-renewal-window"] = "CERTIFICATE_RENEWAL_WINDOW"
 	argToEnv["cluster-domain"] = "CLUSTER_DOMAIN"
 	argToEnv["max-boshdeployment-workers"] = "MAX_BOSHDEPLOYMENT_WORKERS"
 	argToEnv["max-quarks-secret-workers"] = "MAX_QUARKS_SECRET_WORKERS"
@@ -181,6 +218,10 @@ func init() {
 	argToEnv["operator-webhook-service-host"] = "CF_OPERATOR_WEBHOOK_SERVICE_HOST"
 	argToEnv["operator-webhook-service-port"] = "CF_OPERATOR_WEBHOOK_SERVICE_PORT"
 	argToEnv["operator-webhook-use-service-reference"] = "CF_OPERATOR_WEBHOOK_USE_SERVICE_REFERENCE"
+	argToEnv["reference-namespaces"] = "REFERENCE_NAMESPACES"
+	argToEnv["secret-backend-dir"] = "SECRET_BACKEND_DIR"
+	argToEnv["vault-address"] = "VAULT_ADDR"
+	argToEnv["vault-mount"] = "VAULT_MOUNT"
 
 	// Add env variables to help
 	cmd.AddEnvToUsage(rootCmd, argToEnv)
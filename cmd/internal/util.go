@@ -36,6 +36,15 @@ func boshManifestFlagViperBind(pf *flag.FlagSet) {
 
 }
 
+func boshDeploymentFlagCobraSet(pf *flag.FlagSet, argToEnv map[string]string) {
+	pf.StringP("bosh-deployment-path", "f", "", "path to a BOSHDeployment file, instead of reading the BOSHDeployment from the cluster")
+	argToEnv["bosh-deployment-path"] = "BOSH_DEPLOYMENT_PATH"
+}
+
+func boshDeploymentFlagViperBind(pf *flag.FlagSet) {
+	viper.BindPFlag("bosh-deployment-path", pf.Lookup("bosh-deployment-path"))
+}
+
 func baseDirFlagValidation() (string, error) {
 	baseDir := viper.GetString("base-dir")
 	if len(baseDir) == 0 {
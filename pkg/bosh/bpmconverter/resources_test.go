@@ -252,6 +252,7 @@ var _ = Describe("BPM Converter", func() {
 
 					// Test ESts spec
 					Expect(qSts.Spec.Zones).To(Equal(m.InstanceGroups[1].AZs))
+					Expect(qSts.Spec.ZoneUpdateStrategy).To(BeEmpty())
 
 					stS := qSts.Spec.Template.Spec.Template
 					Expect(stS.Name).To(Equal("diego-cell"))
@@ -288,6 +288,10 @@ func generatePersistentVolumeClaim(manifestName string, instanceGroup *bdm.Insta
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      generatePersistentVolumeClaimName(manifestName, instanceGroup.Name),
 			Namespace: namespace,
+			Labels: map[string]string{
+				bdm.LabelDeploymentName:    manifestName,
+				bdm.LabelInstanceGroupName: instanceGroup.Name,
+			},
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
@@ -140,6 +140,10 @@ var _ = Describe("VolumeFactory", func() {
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      names.Sanitize(fmt.Sprintf("%s-%s-%s", manifestName, instanceGroup.Name, "pvc")),
 					Namespace: namespace,
+					Labels: map[string]string{
+						bdm.LabelDeploymentName:    manifestName,
+						bdm.LabelInstanceGroupName: instanceGroup.Name,
+					},
 				},
 				Spec: corev1.PersistentVolumeClaimSpec{
 					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
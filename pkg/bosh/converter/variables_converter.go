@@ -77,6 +77,14 @@ func (vc *VariablesConverter) Variables(manifestName string, variables []bdm.Var
 				ServiceRef:                  v.Options.ServiceRef,
 				ActivateEKSWorkaroundForSAN: v.Options.ActivateEKSWorkaroundForSAN,
 				Usages:                      usages,
+				Duration:                    v.Options.Duration,
+				KeyAlgorithm:                v.Options.KeyAlgorithm,
+				KeyLength:                   v.Options.KeyLength,
+				Organization:                v.Options.Organization,
+				OrganizationalUnit:          v.Options.OrganizationUnit,
+				Locality:                    v.Options.Locality,
+				State:                       v.Options.State,
+				Country:                     v.Options.Country,
 			}
 			if len(certRequest.SignerType) == 0 {
 				certRequest.SignerType = qsv1a1.LocalSigner
@@ -93,6 +101,17 @@ func (vc *VariablesConverter) Variables(manifestName string, variables []bdm.Var
 			}
 			s.Spec.Request.CertificateRequest = certRequest
 		}
+		if (v.Type == qsv1a1.Key || v.Type == qsv1a1.SSHKey) && v.Options != nil {
+			s.Spec.Request.KeyRequest = qsv1a1.KeyRequest{
+				Algorithm: v.Options.KeyAlgorithm,
+				Length:    v.Options.KeyLength,
+			}
+		}
+		if v.Type == qsv1a1.User && v.Options != nil {
+			s.Spec.Request.UserRequest = qsv1a1.UserRequest{
+				Username: v.Options.Username,
+			}
+		}
 		secrets = append(secrets, s)
 	}
 
@@ -94,6 +94,57 @@ var _ = Describe("kube converter", func() {
 				Expect(var1.Spec.SecretName).To(Equal("foo-deployment.var-adminkey"))
 			})
 
+			It("converts user variables", func() {
+				m.Variables[0] = manifest.Variable{
+					Name: "adminuser",
+					Type: "user",
+					Options: &manifest.VariableOptions{
+						Username: "admin",
+					},
+				}
+				variables, err := act()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(variables).To(HaveLen(1))
+
+				var1 := variables[0]
+				Expect(var1.Name).To(Equal("foo-deployment.var-adminuser"))
+				Expect(var1.Spec.Type).To(Equal(qsv1a1.User))
+				Expect(var1.Spec.SecretName).To(Equal("foo-deployment.var-adminuser"))
+				Expect(var1.Spec.Request.UserRequest.Username).To(Equal("admin"))
+			})
+
+			It("converts key variables", func() {
+				m.Variables[0] = manifest.Variable{
+					Name: "signing-key",
+					Type: "key",
+					Options: &manifest.VariableOptions{
+						KeyAlgorithm: "ecdsa",
+						KeyLength:    384,
+					},
+				}
+				variables, err := act()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(variables).To(HaveLen(1))
+
+				var1 := variables[0]
+				Expect(var1.Spec.Type).To(Equal(qsv1a1.Key))
+				Expect(var1.Spec.Request.KeyRequest.Algorithm).To(Equal(qsv1a1.ECDSAKeyAlgorithm))
+				Expect(var1.Spec.Request.KeyRequest.Length).To(Equal(384))
+			})
+
+			It("converts the key algorithm of ssh key variables", func() {
+				m.Variables[0] = manifest.Variable{
+					Name: "adminkey",
+					Type: "ssh",
+					Options: &manifest.VariableOptions{
+						KeyAlgorithm: "ed25519",
+					},
+				}
+				variables, err := act()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(variables[0].Spec.Request.KeyRequest.Algorithm).To(Equal(qsv1a1.Ed25519KeyAlgorithm))
+			})
+
 			It("raises an error when the options are missing for a certificate variable", func() {
 				m.Variables[0] = manifest.Variable{
 					Name: "foo-cert",
@@ -131,6 +182,37 @@ var _ = Describe("kube converter", func() {
 				Expect(request.CARef.Name).To(Equal("foo-deployment.var-theca"))
 				Expect(request.CARef.Key).To(Equal("certificate"))
 			})
+
+			It("converts the validity, key length and subject of certificate variables", func() {
+				m.Variables[0] = manifest.Variable{
+					Name: "foo-cert",
+					Type: "certificate",
+					Options: &manifest.VariableOptions{
+						CommonName:       "example.com",
+						Duration:         30,
+						KeyAlgorithm:     "ecdsa",
+						KeyLength:        384,
+						Organization:     "Cloud Foundry",
+						OrganizationUnit: "Quarks",
+						Locality:         "Berlin",
+						State:            "Berlin",
+						Country:          "DE",
+					},
+				}
+				variables, err := act()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(variables).To(HaveLen(1))
+
+				request := variables[0].Spec.Request.CertificateRequest
+				Expect(request.Duration).To(Equal(30))
+				Expect(request.KeyAlgorithm).To(Equal(qsv1a1.ECDSAKeyAlgorithm))
+				Expect(request.KeyLength).To(Equal(384))
+				Expect(request.Organization).To(Equal("Cloud Foundry"))
+				Expect(request.OrganizationalUnit).To(Equal("Quarks"))
+				Expect(request.Locality).To(Equal("Berlin"))
+				Expect(request.State).To(Equal("Berlin"))
+				Expect(request.Country).To(Equal("DE"))
+			})
 		})
 
 	})
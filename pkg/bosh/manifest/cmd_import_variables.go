@@ -0,0 +1,119 @@
+package manifest
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+	goyaml "gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+// credHubExport is the format of `credhub export`
+type credHubExport struct {
+	Credentials []credHubCredential `yaml:"credentials"`
+}
+
+type credHubCredential struct {
+	Name  string      `yaml:"name"`
+	Type  string      `yaml:"type"`
+	Value interface{} `yaml:"value"`
+}
+
+// ImportVariables converts the credentials of a CredHub export or a BOSH
+// vars-store file into secrets for the explicit variables of a deployment.
+// The secrets are not labeled as generated, so the QuarksSecrets of the
+// variables don't regenerate them.
+func ImportVariables(data []byte, deploymentName string, namespace string) ([]corev1.Secret, error) {
+	values, err := importedValues(data)
+	if err != nil {
+		return nil, err
+	}
+
+	varNames := make([]string, 0, len(values))
+	for name := range values {
+		varNames = append(varNames, name)
+	}
+	sort.Strings(varNames)
+
+	secrets := make([]corev1.Secret, 0, len(values))
+	for _, name := range varNames {
+		secretData, err := variableSecretData(values[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not import variable '%s'", name)
+		}
+
+		secretName := names.DeploymentSecretName(names.DeploymentSecretTypeVariable, deploymentName, name)
+		secrets = append(secrets, corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: namespace,
+				Labels: map[string]string{
+					"variableName":      name,
+					LabelDeploymentName: deploymentName,
+				},
+			},
+			StringData: secretData,
+		})
+	}
+	return secrets, nil
+}
+
+// importedValues returns the values of the variables by name. Names of
+// CredHub credentials are reduced to their last path segment.
+func importedValues(data []byte) (map[string]interface{}, error) {
+	export := credHubExport{}
+	err := goyaml.Unmarshal(data, &export)
+	if err == nil && len(export.Credentials) > 0 {
+		values := map[string]interface{}{}
+		for _, c := range export.Credentials {
+			name := path.Base(c.Name)
+			if _, ok := values[name]; ok {
+				return nil, errors.Errorf("duplicate credential '%s', the export has to be limited to a single deployment", name)
+			}
+			if c.Type == "json" {
+				return nil, errors.Errorf("credential '%s' has the unsupported type json", c.Name)
+			}
+			values[name] = c.Value
+		}
+		return values, nil
+	}
+
+	// A vars-store file maps the variable names to their values
+	values := map[string]interface{}{}
+	err = goyaml.Unmarshal(data, &values)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse variables, expected a CredHub export or a BOSH vars-store file")
+	}
+	return values, nil
+}
+
+// variableSecretData returns the data of a variable secret. Plain values are
+// stored as password, like generated passwords, while the fields of
+// certificates, ssh and rsa keys and users become keys of the secret.
+func variableSecretData(value interface{}) (map[string]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, errors.New("value is empty")
+	case map[interface{}]interface{}:
+		data := make(map[string]string, len(value))
+		for field, v := range value {
+			switch v.(type) {
+			case nil:
+				continue
+			case map[interface{}]interface{}, []interface{}:
+				return nil, errors.Errorf("field '%v' is not a plain value", field)
+			}
+			data[fmt.Sprint(field)] = fmt.Sprint(v)
+		}
+		return data, nil
+	case []interface{}:
+		return nil, errors.New("lists are not supported")
+	default:
+		return map[string]string{"password": fmt.Sprint(value)}, nil
+	}
+}
@@ -0,0 +1,100 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+)
+
+var _ = Describe("ImportVariables", func() {
+	Context("when importing a CredHub export", func() {
+		export := []byte(`
+credentials:
+- name: /director/cf/adminpass
+  type: password
+  value: securepassword
+- name: /director/cf/router_ssl
+  type: certificate
+  value:
+    ca: ca-cert
+    certificate: router-cert
+    private_key: router-key
+- name: /director/cf/uaa_admin
+  type: user
+  value:
+    username: admin
+    password: adminpassword
+`)
+
+		It("creates a secret for each credential", func() {
+			secrets, err := ImportVariables(export, "my-deployment", "default")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secrets).To(HaveLen(3))
+
+			Expect(secrets[0].Name).To(Equal("my-deployment.var-adminpass"))
+			Expect(secrets[0].Namespace).To(Equal("default"))
+			Expect(secrets[0].StringData).To(Equal(map[string]string{"password": "securepassword"}))
+
+			Expect(secrets[1].Name).To(Equal("my-deployment.var-router-ssl"))
+			Expect(secrets[1].StringData).To(Equal(map[string]string{
+				"ca":          "ca-cert",
+				"certificate": "router-cert",
+				"private_key": "router-key",
+			}))
+
+			Expect(secrets[2].StringData).To(HaveKeyWithValue("username", "admin"))
+			Expect(secrets[2].StringData).To(HaveKeyWithValue("password", "adminpassword"))
+		})
+
+		It("does not label the secrets as generated", func() {
+			secrets, err := ImportVariables(export, "my-deployment", "default")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secrets[0].Labels).ToNot(HaveKey(qsv1a1.LabelKind))
+			Expect(secrets[0].Labels).To(HaveKeyWithValue(LabelDeploymentName, "my-deployment"))
+			Expect(secrets[0].Labels).To(HaveKeyWithValue("variableName", "adminpass"))
+		})
+
+		It("fails for exports of several deployments", func() {
+			_, err := ImportVariables([]byte(`
+credentials:
+- name: /director/cf/adminpass
+  type: password
+  value: securepassword
+- name: /director/other/adminpass
+  type: password
+  value: otherpassword
+`), "my-deployment", "default")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("duplicate credential 'adminpass'"))
+		})
+	})
+
+	Context("when importing a BOSH vars-store file", func() {
+		It("creates a secret for each variable", func() {
+			secrets, err := ImportVariables([]byte(`
+adminpass: securepassword
+ssh_key:
+  private_key: private
+  public_key: public
+  public_key_fingerprint: fingerprint
+`), "my-deployment", "default")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secrets).To(HaveLen(2))
+			Expect(secrets[0].StringData).To(Equal(map[string]string{"password": "securepassword"}))
+			Expect(secrets[1].Name).To(Equal("my-deployment.var-ssh-key"))
+			Expect(secrets[1].StringData).To(HaveKeyWithValue("public_key_fingerprint", "fingerprint"))
+		})
+
+		It("fails for nested values", func() {
+			_, err := ImportVariables([]byte(`
+nested:
+  field:
+    key: value
+`), "my-deployment", "default")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("could not import variable 'nested'"))
+		})
+	})
+})
@@ -15,7 +15,7 @@ import (
 
 // InterpolateVariables reads explicit secrets from a folder and writes an interpolated manifest to the output.json file in /mnt/quarks volume mount.
 func InterpolateVariables(log *zap.SugaredLogger, boshManifestBytes []byte, variablesDir string, outputFilePath string) error {
-	var vars []boshtpl.Variables
+	vars := map[string]interface{}{}
 
 	variables, err := ioutil.ReadDir(variablesDir)
 	if err != nil {
@@ -25,7 +25,6 @@ func InterpolateVariables(log *zap.SugaredLogger, boshManifestBytes []byte, vari
 	for _, variable := range variables {
 		// Each directory is a variable name
 		if variable.IsDir() {
-			staticVars := boshtpl.StaticVariables{}
 			// Each filename is a field name and its context is a variable value
 			err = filepath.Walk(filepath.Clean(variablesDir+"/"+variable.Name()), func(path string, info os.FileInfo, err error) error {
 				if err != nil {
@@ -43,13 +42,7 @@ func InterpolateVariables(log *zap.SugaredLogger, boshManifestBytes []byte, vari
 						return errors.Wrapf(err, "could not read variables variable %s", variable.Name())
 					}
 
-					// If variable type is password, set password value directly
-					switch varFileName {
-					case "password":
-						staticVars[variable.Name()] = string(varBytes)
-					default:
-						staticVars[variable.Name()] = mergeStaticVar(staticVars[variable.Name()], varFileName, string(varBytes))
-					}
+					vars[variable.Name()] = mergeStaticVar(vars[variable.Name()], varFileName, string(varBytes))
 				}
 				return nil
 			})
@@ -57,11 +50,16 @@ func InterpolateVariables(log *zap.SugaredLogger, boshManifestBytes []byte, vari
 				return errors.Wrapf(err, "could not read directory  %s", variable.Name())
 			}
 
-			vars = append(vars, staticVars)
+			// If variable type is password, set password value directly.
+			// Users have a password, too, but are referenced by field.
+			if fields, ok := vars[variable.Name()].(map[interface{}]interface{}); ok && len(fields) == 1 {
+				if password, ok := fields["password"]; ok {
+					vars[variable.Name()] = password
+				}
+			}
 		}
 	}
 
-	multiVars := boshtpl.NewMultiVars(vars)
 	tpl := boshtpl.NewTemplate(boshManifestBytes)
 
 	// Following options are empty for cf-operator
@@ -71,7 +69,8 @@ func InterpolateVariables(log *zap.SugaredLogger, boshManifestBytes []byte, vari
 		ExpectAllVarsUsed: false,
 	}
 
-	yamlBytes, err := tpl.Evaluate(multiVars, op, evalOpts)
+	// Whole value placeholders keep the type of the variable, like in the resolver
+	yamlBytes, err := tpl.Evaluate(boshtpl.StaticVariables(vars), op, evalOpts)
 	if err != nil {
 		return errors.Wrapf(err, "could not evaluate variables")
 	}
@@ -47,6 +47,42 @@ instance_groups:
 		Expect(string(dataBytes)).To(Equal(`{"manifest.yaml":"director_uuid: |\n  fake-password\ninstance_groups:\n- azs: null\n  env:\n    bosh:\n      agent:\n        settings: {}\n      ipv6:\n        enable: false\n  instances: 0\n  jobs: null\n  name: |\n    baz\n  properties:\n    quarks: {}\n  stemcell: \"\"\n  vm_resources: null\n- azs: null\n  env:\n    bosh:\n      agent:\n        settings: {}\n      ipv6:\n        enable: false\n  instances: 0\n  jobs: null\n  name: |\n    foo\n  properties:\n    quarks: {}\n  stemcell: \"\"\n  vm_resources: null\n- azs: null\n  env:\n    bosh:\n      agent:\n        settings: {}\n      ipv6:\n        enable: false\n  instances: 0\n  jobs: null\n  name: |\n    bar\n  properties:\n    quarks: {}\n  stemcell: \"\"\n  vm_resources: null\n"}`))
 	})
 
+	It("keeps the type of whole value placeholders", func() {
+		baseManifest = []byte(`
+---
+instance_groups:
+- name: foo
+  properties:
+    ca: ((value2))
+    url: https://((value1.key1))
+`)
+		err := InterpolateVariables(log, baseManifest, varDir, outputFilePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		dataBytes, err := ioutil.ReadFile(outputFilePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(dataBytes)).To(ContainSubstring(`ca:\n      key2: |\n        foo\n      key3: |\n        bar\n`))
+		Expect(string(dataBytes)).To(ContainSubstring(`url: |\n      https://baz\n`))
+	})
+
+	It("references the fields of users", func() {
+		baseManifest = []byte(`
+---
+instance_groups:
+- name: foo
+  properties:
+    username: ((user1.username))
+    password: ((user1.password))
+`)
+		err := InterpolateVariables(log, baseManifest, varDir, outputFilePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		dataBytes, err := ioutil.ReadFile(outputFilePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(dataBytes)).To(ContainSubstring(`username: |\n      fake-username\n`))
+		Expect(string(dataBytes)).To(ContainSubstring(`password: |\n      fake-user-password\n`))
+	})
+
 	It("raises error when variablesDir is not directory", func() {
 		varDir = assetPath + "/nonexisting"
 		err := InterpolateVariables(log, baseManifest, varDir, outputFilePath)
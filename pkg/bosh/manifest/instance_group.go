@@ -196,7 +196,7 @@ type Network struct {
 
 // Update from BOSH deployment manifest.
 type Update struct {
-	Canaries        int     `json:"canaries"`
+	Canaries        *int    `json:"canaries,omitempty"` // must be pointer, because zero skips the canaries
 	MaxInFlight     string  `json:"max_in_flight"`
 	CanaryWatchTime string  `json:"canary_watch_time"`
 	UpdateWatchTime string  `json:"update_watch_time"`
@@ -71,6 +71,15 @@ type VariableOptions struct {
 	SignerType                  string                    `json:"signer_type,omitempty"`
 	ServiceRef                  []qsv1a1.ServiceReference `json:"serviceRef,omitempty"`
 	ActivateEKSWorkaroundForSAN bool                      `json:"activateEKSWorkaroundForSAN,omitempty"`
+	Duration                    int                       `json:"duration,omitempty"`
+	KeyAlgorithm                string                    `json:"key_algorithm,omitempty"`
+	KeyLength                   int                       `json:"key_length,omitempty"`
+	Organization                string                    `json:"organization,omitempty"`
+	OrganizationUnit            string                    `json:"organization_unit,omitempty"`
+	Locality                    string                    `json:"locality,omitempty"`
+	State                       string                    `json:"state,omitempty"`
+	Country                     string                    `json:"country,omitempty"`
+	Username                    string                    `json:"username,omitempty"`
 }
 
 // Variable from BOSH deployment manifest
@@ -503,6 +512,10 @@ func (m *Manifest) ApplyAddons() error {
 
 // PropagateGlobalUpdateBlockToIGs copies the update block to all instance groups
 func (m *Manifest) PropagateGlobalUpdateBlockToIGs() {
+	if m.Update == nil {
+		return
+	}
+
 	for _, ig := range m.InstanceGroups {
 		if ig.Update == nil {
 			ig.Update = m.Update
@@ -513,6 +526,12 @@ func (m *Manifest) PropagateGlobalUpdateBlockToIGs() {
 			if ig.Update.UpdateWatchTime == "" {
 				ig.Update.UpdateWatchTime = m.Update.UpdateWatchTime
 			}
+			if ig.Update.Canaries == nil {
+				ig.Update.Canaries = m.Update.Canaries
+			}
+			if ig.Update.MaxInFlight == "" {
+				ig.Update.MaxInFlight = m.Update.MaxInFlight
+			}
 			if ig.Update.Serial == nil {
 				ig.Update.Serial = m.Update.Serial
 			}
@@ -7,6 +7,8 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/utils/pointer"
 
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -888,7 +890,7 @@ var _ = Describe("Manifest", func() {
 			Describe("Canaries", func() {
 				It("contains desired values", func() {
 					Expect(getStructTagForName("Canaries", update)).To(Equal(
-						`json:"canaries"`,
+						`json:"canaries,omitempty"`,
 					))
 				})
 			})
@@ -1449,6 +1451,19 @@ var _ = Describe("Manifest", func() {
 				Expect(manifestWithUpdate.InstanceGroups[2].Properties.Quarks.RequiredService).To(Equal(&expectedRequireService))
 			})
 
+			It("keeps zero canaries of an instance group", func() {
+				m := &Manifest{
+					Update: &Update{Canaries: pointers.Int(2)},
+					InstanceGroups: InstanceGroups{
+						{Name: "zero", Update: &Update{Canaries: pointers.Int(0)}},
+						{Name: "unset", Update: &Update{}},
+					},
+				}
+				m.ApplyUpdateBlock(dns)
+				Expect(*m.InstanceGroups[0].Update.Canaries).To(Equal(0))
+				Expect(*m.InstanceGroups[1].Update.Canaries).To(Equal(2))
+			})
+
 			It("propagates global update block correctly", func() {
 				manifest, err = env.BOSHManifestWithGlobalUpdateBlock()
 				Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,151 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	placeholderRegexp         = regexp.MustCompile(`\(\((!?[-/\.\w\pL]+)\)\)`)
+	anchoredPlaceholderRegexp = regexp.MustCompile(`\A` + placeholderRegexp.String() + `\z`)
+)
+
+// VariableLookup returns the value of a variable and whether it was found
+type VariableLookup func(name string) (interface{}, bool)
+
+// StaticVariables returns a lookup for the variables in vars. Fields of map
+// values are referenced with a '.' separator, e.g. 'ca.certificate'.
+func StaticVariables(vars map[string]interface{}) VariableLookup {
+	return func(name string) (interface{}, bool) {
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+
+		path := strings.Split(name, ".")
+		value, ok := vars[path[0]]
+		if !ok {
+			return nil, false
+		}
+		for _, field := range path[1:] {
+			switch fields := value.(type) {
+			case map[string]interface{}:
+				value, ok = fields[field]
+			case map[interface{}]interface{}:
+				value, ok = fields[field]
+			default:
+				ok = false
+			}
+			if !ok {
+				return nil, false
+			}
+		}
+		return value, true
+	}
+}
+
+// InterpolateString replaces the variable placeholders in s, following the
+// semantics of the BOSH CLI. If s consists of a single placeholder, the value
+// of the variable is returned, preserving its type. Embedded placeholders are
+// replaced by the string representation of scalar values. Placeholders of
+// unknown variables are kept.
+func InterpolateString(s string, lookup VariableLookup) (interface{}, error) {
+	if anchoredPlaceholderRegexp.MatchString(s) {
+		name := strings.TrimPrefix(anchoredPlaceholderRegexp.FindStringSubmatch(s)[1], "!")
+		if value, ok := lookup(name); ok {
+			return value, nil
+		}
+		return s, nil
+	}
+
+	var err error
+	result := placeholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := strings.TrimPrefix(placeholderRegexp.FindStringSubmatch(placeholder)[1], "!")
+		value, ok := lookup(name)
+		if !ok {
+			return placeholder
+		}
+
+		if !isScalar(value) {
+			if err == nil {
+				err = errors.Errorf("invalid type '%T' of variable '%s' embedded in string '%s', only strings, numbers and booleans can be interpolated within a string", value, name, s)
+			}
+			return placeholder
+		}
+		return fmt.Sprintf("%v", value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// InterpolateValue replaces the variable placeholders in all strings and map
+// keys of an unmarshalled YAML document, see InterpolateString
+func InterpolateValue(node interface{}, lookup VariableLookup) (interface{}, error) {
+	switch typedNode := node.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[interface{}]interface{}, len(typedNode))
+		for k, v := range typedNode {
+			key, err := InterpolateValue(k, lookup)
+			if err != nil {
+				return nil, err
+			}
+			if !isScalar(key) {
+				return nil, errors.Errorf("map key '%v' must be interpolated to a scalar, not '%T'", k, key)
+			}
+			value, err := InterpolateValue(v, lookup)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = value
+		}
+		return result, nil
+
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typedNode))
+		for k, v := range typedNode {
+			key, err := InterpolateValue(k, lookup)
+			if err != nil {
+				return nil, err
+			}
+			stringKey, ok := key.(string)
+			if !ok {
+				return nil, errors.Errorf("map key '%s' must be interpolated to a string, not '%T'", k, key)
+			}
+			value, err := InterpolateValue(v, lookup)
+			if err != nil {
+				return nil, err
+			}
+			result[stringKey] = value
+		}
+		return result, nil
+
+	case []interface{}:
+		result := make([]interface{}, len(typedNode))
+		for i, v := range typedNode {
+			value, err := InterpolateValue(v, lookup)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+
+	case string:
+		return InterpolateString(typedNode, lookup)
+	}
+
+	return node, nil
+}
+
+// isScalar returns true if the value is a string, number or boolean
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case string, bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
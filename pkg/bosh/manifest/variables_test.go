@@ -0,0 +1,111 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+)
+
+var _ = Describe("Variables", func() {
+	var lookup VariableLookup
+
+	BeforeEach(func() {
+		lookup = StaticVariables(map[string]interface{}{
+			"domain":  "example.com",
+			"port":    float64(8443),
+			"enabled": true,
+			"ca": map[string]interface{}{
+				"certificate": "the-cert",
+				"options": map[interface{}]interface{}{
+					"is_ca": true,
+				},
+			},
+			"zones":  []interface{}{"z1", "z2"},
+			"ssl/ca": "the-ssl-ca",
+		})
+	})
+
+	Describe("StaticVariables", func() {
+		It("looks up fields of map variables", func() {
+			value, ok := lookup("ca.certificate")
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal("the-cert"))
+
+			value, ok = lookup("ca.options.is_ca")
+			Expect(ok).To(BeTrue())
+			Expect(value).To(BeTrue())
+		})
+
+		It("looks up names containing a separator", func() {
+			value, ok := lookup("ssl/ca")
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal("the-ssl-ca"))
+		})
+
+		It("doesn't find missing variables and fields", func() {
+			_, ok := lookup("missing")
+			Expect(ok).To(BeFalse())
+			_, ok = lookup("ca.missing")
+			Expect(ok).To(BeFalse())
+			_, ok = lookup("domain.missing")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("InterpolateString", func() {
+		It("replaces whole value placeholders by the typed value", func() {
+			Expect(InterpolateString("((port))", lookup)).To(Equal(float64(8443)))
+			Expect(InterpolateString("((enabled))", lookup)).To(BeTrue())
+			Expect(InterpolateString("((!zones))", lookup)).To(Equal([]interface{}{"z1", "z2"}))
+			Expect(InterpolateString("((ca))", lookup)).To(HaveKeyWithValue("certificate", "the-cert"))
+		})
+
+		It("concatenates embedded placeholders", func() {
+			Expect(InterpolateString("https://((domain)):((port))/((enabled))", lookup)).To(Equal("https://example.com:8443/true"))
+		})
+
+		It("keeps placeholders of unknown variables", func() {
+			Expect(InterpolateString("((missing))", lookup)).To(Equal("((missing))"))
+			Expect(InterpolateString("((domain))/((missing))", lookup)).To(Equal("example.com/((missing))"))
+		})
+
+		It("fails to embed maps and lists", func() {
+			_, err := InterpolateString("ca: ((ca))", lookup)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid type 'map[string]interface {}' of variable 'ca'"))
+		})
+	})
+
+	Describe("InterpolateValue", func() {
+		It("replaces placeholders in nested values and keys", func() {
+			value, err := InterpolateValue(map[interface{}]interface{}{
+				"properties": map[string]interface{}{
+					"port":       "((port))",
+					"((domain))": []interface{}{"((enabled))", 1},
+				},
+			}, lookup)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(map[interface{}]interface{}{
+				"properties": map[string]interface{}{
+					"port":        float64(8443),
+					"example.com": []interface{}{true, 1},
+				},
+			}))
+		})
+
+		It("fails if a map key is not a string", func() {
+			_, err := InterpolateValue(map[string]interface{}{"((port))": "foo"}, lookup)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("fails if a map key is not a scalar", func() {
+			_, err := InterpolateValue(map[interface{}]interface{}{"((ca))": "foo"}, lookup)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("must be interpolated to a scalar"))
+
+			_, err = InterpolateValue(map[interface{}]interface{}{"((zones))": "foo"}, lookup)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -37,6 +37,20 @@ type FakeGenerator struct {
 		result2 []byte
 		result3 error
 	}
+	GenerateKeyStub        func(string, credsgen.KeyGenerationRequest) (credsgen.Key, error)
+	generateKeyMutex       sync.RWMutex
+	generateKeyArgsForCall []struct {
+		arg1 string
+		arg2 credsgen.KeyGenerationRequest
+	}
+	generateKeyReturns struct {
+		result1 credsgen.Key
+		result2 error
+	}
+	generateKeyReturnsOnCall map[int]struct {
+		result1 credsgen.Key
+		result2 error
+	}
 	GeneratePasswordStub        func(string, credsgen.PasswordGenerationRequest) string
 	generatePasswordMutex       sync.RWMutex
 	generatePasswordArgsForCall []struct {
@@ -62,10 +76,11 @@ type FakeGenerator struct {
 		result1 credsgen.RSAKey
 		result2 error
 	}
-	GenerateSSHKeyStub        func(string) (credsgen.SSHKey, error)
+	GenerateSSHKeyStub        func(string, credsgen.KeyGenerationRequest) (credsgen.SSHKey, error)
 	generateSSHKeyMutex       sync.RWMutex
 	generateSSHKeyArgsForCall []struct {
 		arg1 string
+		arg2 credsgen.KeyGenerationRequest
 	}
 	generateSSHKeyReturns struct {
 		result1 credsgen.SSHKey
@@ -75,6 +90,18 @@ type FakeGenerator struct {
 		result1 credsgen.SSHKey
 		result2 error
 	}
+	GenerateUserStub        func(string, credsgen.UserGenerationRequest) credsgen.User
+	generateUserMutex       sync.RWMutex
+	generateUserArgsForCall []struct {
+		arg1 string
+		arg2 credsgen.UserGenerationRequest
+	}
+	generateUserReturns struct {
+		result1 credsgen.User
+	}
+	generateUserReturnsOnCall map[int]struct {
+		result1 credsgen.User
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -209,6 +236,70 @@ func (fake *FakeGenerator) GenerateCertificateSigningRequestReturnsOnCall(i int,
 	}{result1, result2, result3}
 }
 
+func (fake *FakeGenerator) GenerateKey(arg1 string, arg2 credsgen.KeyGenerationRequest) (credsgen.Key, error) {
+	fake.generateKeyMutex.Lock()
+	ret, specificReturn := fake.generateKeyReturnsOnCall[len(fake.generateKeyArgsForCall)]
+	fake.generateKeyArgsForCall = append(fake.generateKeyArgsForCall, struct {
+		arg1 string
+		arg2 credsgen.KeyGenerationRequest
+	}{arg1, arg2})
+	fake.recordInvocation("GenerateKey", []interface{}{arg1, arg2})
+	fake.generateKeyMutex.Unlock()
+	if fake.GenerateKeyStub != nil {
+		return fake.GenerateKeyStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.generateKeyReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGenerator) GenerateKeyCallCount() int {
+	fake.generateKeyMutex.RLock()
+	defer fake.generateKeyMutex.RUnlock()
+	return len(fake.generateKeyArgsForCall)
+}
+
+func (fake *FakeGenerator) GenerateKeyCalls(stub func(string, credsgen.KeyGenerationRequest) (credsgen.Key, error)) {
+	fake.generateKeyMutex.Lock()
+	defer fake.generateKeyMutex.Unlock()
+	fake.GenerateKeyStub = stub
+}
+
+func (fake *FakeGenerator) GenerateKeyArgsForCall(i int) (string, credsgen.KeyGenerationRequest) {
+	fake.generateKeyMutex.RLock()
+	defer fake.generateKeyMutex.RUnlock()
+	argsForCall := fake.generateKeyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGenerator) GenerateKeyReturns(result1 credsgen.Key, result2 error) {
+	fake.generateKeyMutex.Lock()
+	defer fake.generateKeyMutex.Unlock()
+	fake.GenerateKeyStub = nil
+	fake.generateKeyReturns = struct {
+		result1 credsgen.Key
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGenerator) GenerateKeyReturnsOnCall(i int, result1 credsgen.Key, result2 error) {
+	fake.generateKeyMutex.Lock()
+	defer fake.generateKeyMutex.Unlock()
+	fake.GenerateKeyStub = nil
+	if fake.generateKeyReturnsOnCall == nil {
+		fake.generateKeyReturnsOnCall = make(map[int]struct {
+			result1 credsgen.Key
+			result2 error
+		})
+	}
+	fake.generateKeyReturnsOnCall[i] = struct {
+		result1 credsgen.Key
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeGenerator) GeneratePassword(arg1 string, arg2 credsgen.PasswordGenerationRequest) string {
 	fake.generatePasswordMutex.Lock()
 	ret, specificReturn := fake.generatePasswordReturnsOnCall[len(fake.generatePasswordArgsForCall)]
@@ -333,16 +424,17 @@ func (fake *FakeGenerator) GenerateRSAKeyReturnsOnCall(i int, result1 credsgen.R
 	}{result1, result2}
 }
 
-func (fake *FakeGenerator) GenerateSSHKey(arg1 string) (credsgen.SSHKey, error) {
+func (fake *FakeGenerator) GenerateSSHKey(arg1 string, arg2 credsgen.KeyGenerationRequest) (credsgen.SSHKey, error) {
 	fake.generateSSHKeyMutex.Lock()
 	ret, specificReturn := fake.generateSSHKeyReturnsOnCall[len(fake.generateSSHKeyArgsForCall)]
 	fake.generateSSHKeyArgsForCall = append(fake.generateSSHKeyArgsForCall, struct {
 		arg1 string
-	}{arg1})
-	fake.recordInvocation("GenerateSSHKey", []interface{}{arg1})
+		arg2 credsgen.KeyGenerationRequest
+	}{arg1, arg2})
+	fake.recordInvocation("GenerateSSHKey", []interface{}{arg1, arg2})
 	fake.generateSSHKeyMutex.Unlock()
 	if fake.GenerateSSHKeyStub != nil {
-		return fake.GenerateSSHKeyStub(arg1)
+		return fake.GenerateSSHKeyStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -357,17 +449,17 @@ func (fake *FakeGenerator) GenerateSSHKeyCallCount() int {
 	return len(fake.generateSSHKeyArgsForCall)
 }
 
-func (fake *FakeGenerator) GenerateSSHKeyCalls(stub func(string) (credsgen.SSHKey, error)) {
+func (fake *FakeGenerator) GenerateSSHKeyCalls(stub func(string, credsgen.KeyGenerationRequest) (credsgen.SSHKey, error)) {
 	fake.generateSSHKeyMutex.Lock()
 	defer fake.generateSSHKeyMutex.Unlock()
 	fake.GenerateSSHKeyStub = stub
 }
 
-func (fake *FakeGenerator) GenerateSSHKeyArgsForCall(i int) string {
+func (fake *FakeGenerator) GenerateSSHKeyArgsForCall(i int) (string, credsgen.KeyGenerationRequest) {
 	fake.generateSSHKeyMutex.RLock()
 	defer fake.generateSSHKeyMutex.RUnlock()
 	argsForCall := fake.generateSSHKeyArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeGenerator) GenerateSSHKeyReturns(result1 credsgen.SSHKey, result2 error) {
@@ -396,6 +488,67 @@ func (fake *FakeGenerator) GenerateSSHKeyReturnsOnCall(i int, result1 credsgen.S
 	}{result1, result2}
 }
 
+func (fake *FakeGenerator) GenerateUser(arg1 string, arg2 credsgen.UserGenerationRequest) credsgen.User {
+	fake.generateUserMutex.Lock()
+	ret, specificReturn := fake.generateUserReturnsOnCall[len(fake.generateUserArgsForCall)]
+	fake.generateUserArgsForCall = append(fake.generateUserArgsForCall, struct {
+		arg1 string
+		arg2 credsgen.UserGenerationRequest
+	}{arg1, arg2})
+	fake.recordInvocation("GenerateUser", []interface{}{arg1, arg2})
+	fake.generateUserMutex.Unlock()
+	if fake.GenerateUserStub != nil {
+		return fake.GenerateUserStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.generateUserReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGenerator) GenerateUserCallCount() int {
+	fake.generateUserMutex.RLock()
+	defer fake.generateUserMutex.RUnlock()
+	return len(fake.generateUserArgsForCall)
+}
+
+func (fake *FakeGenerator) GenerateUserCalls(stub func(string, credsgen.UserGenerationRequest) credsgen.User) {
+	fake.generateUserMutex.Lock()
+	defer fake.generateUserMutex.Unlock()
+	fake.GenerateUserStub = stub
+}
+
+func (fake *FakeGenerator) GenerateUserArgsForCall(i int) (string, credsgen.UserGenerationRequest) {
+	fake.generateUserMutex.RLock()
+	defer fake.generateUserMutex.RUnlock()
+	argsForCall := fake.generateUserArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGenerator) GenerateUserReturns(result1 credsgen.User) {
+	fake.generateUserMutex.Lock()
+	defer fake.generateUserMutex.Unlock()
+	fake.GenerateUserStub = nil
+	fake.generateUserReturns = struct {
+		result1 credsgen.User
+	}{result1}
+}
+
+func (fake *FakeGenerator) GenerateUserReturnsOnCall(i int, result1 credsgen.User) {
+	fake.generateUserMutex.Lock()
+	defer fake.generateUserMutex.Unlock()
+	fake.GenerateUserStub = nil
+	if fake.generateUserReturnsOnCall == nil {
+		fake.generateUserReturnsOnCall = make(map[int]struct {
+			result1 credsgen.User
+		})
+	}
+	fake.generateUserReturnsOnCall[i] = struct {
+		result1 credsgen.User
+	}{result1}
+}
+
 func (fake *FakeGenerator) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -403,12 +556,16 @@ func (fake *FakeGenerator) Invocations() map[string][][]interface{} {
 	defer fake.generateCertificateMutex.RUnlock()
 	fake.generateCertificateSigningRequestMutex.RLock()
 	defer fake.generateCertificateSigningRequestMutex.RUnlock()
+	fake.generateKeyMutex.RLock()
+	defer fake.generateKeyMutex.RUnlock()
 	fake.generatePasswordMutex.RLock()
 	defer fake.generatePasswordMutex.RUnlock()
 	fake.generateRSAKeyMutex.RLock()
 	defer fake.generateRSAKeyMutex.RUnlock()
 	fake.generateSSHKeyMutex.RLock()
 	defer fake.generateSSHKeyMutex.RUnlock()
+	fake.generateUserMutex.RLock()
+	defer fake.generateUserMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -1,9 +1,24 @@
 package credsgen
 
+import "time"
+
 const (
 	// DefaultPasswordLength represents the default length of a generated password
 	// (number of characters)
 	DefaultPasswordLength = 64
+	// DefaultUsernameLength represents the default length of a generated user name
+	// (number of characters)
+	DefaultUsernameLength = 20
+)
+
+// Valid values for key algorithms
+const (
+	// RSAAlgorithm generates RSA keys
+	RSAAlgorithm = "rsa"
+	// ECDSAAlgorithm generates ECDSA keys, the key length selects the curve
+	ECDSAAlgorithm = "ecdsa"
+	// Ed25519Algorithm generates Ed25519 keys, which have a fixed length
+	Ed25519Algorithm = "ed25519"
 )
 
 // PasswordGenerationRequest specifies the generation parameters for Passwords
@@ -11,12 +26,47 @@ type PasswordGenerationRequest struct {
 	Length int
 }
 
-// CertificateGenerationRequest specifies the generation parameters for Certificates
+// UserGenerationRequest specifies the generation parameters for Users. A
+// random user name is generated if Username is empty.
+type UserGenerationRequest struct {
+	Username       string
+	PasswordLength int
+}
+
+// KeyGenerationRequest specifies the generation parameters for keys. The
+// defaults of the generator are used if Algorithm or Length are empty.
+type KeyGenerationRequest struct {
+	Algorithm string
+	Length    int
+}
+
+// CertificateGenerationRequest specifies the generation parameters for Certificates.
+// The defaults of the generator are used if Duration, KeyAlgorithm or KeyLength are empty.
 type CertificateGenerationRequest struct {
 	CommonName       string
 	AlternativeNames []string
 	IsCA             bool
 	CA               Certificate
+	Duration         time.Duration
+	KeyAlgorithm     string
+	KeyLength        int
+	Subject          Subject
+}
+
+// Subject holds the distinguished name fields of a certificate in addition
+// to the common name
+type Subject struct {
+	Organization       string
+	OrganizationalUnit string
+	Locality           string
+	State              string
+	Country            string
+}
+
+// User holds a user name and its password
+type User struct {
+	Username string
+	Password string
 }
 
 // Certificate holds the information about a certificate
@@ -39,11 +89,20 @@ type RSAKey struct {
 	PublicKey  []byte
 }
 
-// Generator provides an interface for generating credentials like passwords, certificates or SSH and RSA keys
+// Key represents a PEM encoded private key and its public key
+type Key struct {
+	Algorithm  string
+	PrivateKey []byte
+	PublicKey  []byte
+}
+
+// Generator provides an interface for generating credentials like passwords, users, certificates, SSH keys and private keys
 type Generator interface {
 	GeneratePassword(name string, request PasswordGenerationRequest) string
+	GenerateUser(name string, request UserGenerationRequest) User
 	GenerateCertificate(name string, request CertificateGenerationRequest) (Certificate, error)
 	GenerateCertificateSigningRequest(request CertificateGenerationRequest) ([]byte, []byte, error)
-	GenerateSSHKey(name string) (SSHKey, error)
+	GenerateSSHKey(name string, request KeyGenerationRequest) (SSHKey, error)
 	GenerateRSAKey(name string) (RSAKey, error)
+	GenerateKey(name string, request KeyGenerationRequest) (Key, error)
 }
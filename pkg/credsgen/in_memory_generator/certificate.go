@@ -1,7 +1,6 @@
 package inmemorygenerator
 
 import (
-	"fmt"
 	"time"
 
 	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
@@ -45,14 +44,21 @@ func (g InMemoryGenerator) GenerateCertificateSigningRequest(request credsgen.Ce
 	var csReq, privateKey []byte
 
 	// Generate certificate request
-	certReq := &csr.CertificateRequest{KeyRequest: &csr.BasicKeyRequest{A: g.Algorithm, S: g.Bits}}
+	keyRequest, err := g.keyRequest(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	certReq := &csr.CertificateRequest{
+		KeyRequest: keyRequest,
+		Names:      subjectNames(request.Subject),
+	}
 
 	certReq.Hosts = append(certReq.Hosts, request.CommonName)
 	certReq.Hosts = append(certReq.Hosts, request.AlternativeNames...)
 	certReq.CN = certReq.Hosts[0]
 
 	sslValidator := &csr.Generator{Validator: genkey.Validator}
-	csReq, privateKey, err := sslValidator.ProcessRequest(certReq)
+	csReq, privateKey, err = sslValidator.ProcessRequest(certReq)
 	if err != nil {
 		return csReq, privateKey, err
 	}
@@ -75,10 +81,11 @@ func (g InMemoryGenerator) generateCertificate(request credsgen.CertificateGener
 		return credsgen.Certificate{}, err
 	}
 	// Sign certificate
+	expiry := g.expiry(request)
 	signingProfile := &config.SigningProfile{
 		Usage:        []string{"server auth", "client auth"},
-		Expiry:       time.Duration(g.Expiry*24) * time.Hour,
-		ExpiryString: fmt.Sprintf("%dh", g.Expiry*24),
+		Expiry:       expiry,
+		ExpiryString: expiry.String(),
 	}
 	cert.Certificate, err = g.signCertificate(signingReq, signingProfile, request)
 	if err != nil {
@@ -91,10 +98,15 @@ func (g InMemoryGenerator) generateCertificate(request credsgen.CertificateGener
 
 // generateCACertificate Generate self-signed root CA certificate and private key
 func (g InMemoryGenerator) generateCACertificate(request credsgen.CertificateGenerationRequest) (credsgen.Certificate, error) {
+	keyRequest, err := g.keyRequest(request)
+	if err != nil {
+		return credsgen.Certificate{}, err
+	}
 	req := &csr.CertificateRequest{
-		CA:         &csr.CAConfig{Expiry: fmt.Sprintf("%dh", g.Expiry*24)},
+		CA:         &csr.CAConfig{Expiry: g.expiry(request).String()},
 		CN:         request.CommonName,
-		KeyRequest: &csr.BasicKeyRequest{A: g.Algorithm, S: g.Bits},
+		KeyRequest: keyRequest,
+		Names:      subjectNames(request.Subject),
 	}
 	ca, csr, privateKey, err := initca.New(req)
 	if err != nil {
@@ -107,10 +119,15 @@ func (g InMemoryGenerator) generateCACertificate(request credsgen.CertificateGen
 		PrivateKey:  privateKey,
 	}
 	if request.CA.IsCA {
+		// Intermediate CAs are valid for five years, unless requested otherwise
+		expiry := 5 * helpers.OneYear
+		if request.Duration > 0 {
+			expiry = request.Duration
+		}
 		signingProfile := &config.SigningProfile{
 			Usage:        []string{"cert sign", "crl sign"},
-			ExpiryString: "43800h",
-			Expiry:       5 * helpers.OneYear,
+			ExpiryString: expiry.String(),
+			Expiry:       expiry,
 			CAConstraint: config.CAConstraint{
 				IsCA: true,
 			},
@@ -153,3 +170,36 @@ func (g InMemoryGenerator) signCertificate(csr []byte, signingProfile *config.Si
 
 	return certificate, nil
 }
+
+// keyRequest returns the key parameters, using the key algorithm and length
+// of the request if set
+func (g InMemoryGenerator) keyRequest(request credsgen.CertificateGenerationRequest) (*csr.BasicKeyRequest, error) {
+	algorithm, length := g.keyParameters(request.KeyAlgorithm, request.KeyLength)
+	if algorithm != credsgen.RSAAlgorithm && algorithm != credsgen.ECDSAAlgorithm {
+		return nil, errors.Errorf("unsupported key algorithm '%s' for certificates, must be one of rsa or ecdsa", algorithm)
+	}
+	return &csr.BasicKeyRequest{A: algorithm, S: length}, nil
+}
+
+// expiry returns the validity of the certificate, using the duration of the
+// request if set
+func (g InMemoryGenerator) expiry(request credsgen.CertificateGenerationRequest) time.Duration {
+	if request.Duration > 0 {
+		return request.Duration
+	}
+	return time.Duration(g.Expiry*24) * time.Hour
+}
+
+// subjectNames converts the subject into the names of a cfssl certificate request
+func subjectNames(subject credsgen.Subject) []csr.Name {
+	if subject == (credsgen.Subject{}) {
+		return nil
+	}
+	return []csr.Name{{
+		C:  subject.Country,
+		ST: subject.State,
+		L:  subject.Locality,
+		O:  subject.Organization,
+		OU: subject.OrganizationalUnit,
+	}}
+}
@@ -1,6 +1,7 @@
 package inmemorygenerator_test
 
 import (
+	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -108,6 +109,51 @@ var _ = Describe("InMemoryGenerator", func() {
 					Expect(parsedCert.NotAfter.Before(time.Now().AddDate(0, 0, 2))).To(BeTrue())
 					Expect(len(cert.PrivateKey)).To(Equal(227))
 				})
+
+				It("considers the key algorithm of the request", func() {
+					request.KeyAlgorithm = credsgen.RSAAlgorithm
+
+					cert, err := generator.GenerateCertificate("foo", request)
+					Expect(err).ToNot(HaveOccurred())
+
+					key, _ := pem.Decode(cert.PrivateKey)
+					Expect(key.Type).To(Equal("RSA PRIVATE KEY"))
+				})
+
+				It("fails for Ed25519 keys", func() {
+					request.KeyAlgorithm = credsgen.Ed25519Algorithm
+
+					_, err := generator.GenerateCertificate("foo", request)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("unsupported key algorithm 'ed25519' for certificates"))
+				})
+
+				It("considers the parameters of the request", func() {
+					request.Duration = 48 * time.Hour
+					request.KeyLength = 384
+					request.Subject = credsgen.Subject{
+						Organization:       "Cloud Foundry",
+						OrganizationalUnit: "Quarks",
+						Locality:           "Berlin",
+						State:              "Berlin",
+						Country:            "DE",
+					}
+
+					cert, err := generator.GenerateCertificate("foo", request)
+					Expect(err).ToNot(HaveOccurred())
+
+					parsedCert, err := parseCert(cert.Certificate)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(parsedCert.NotAfter.Before(time.Now().AddDate(0, 0, 3))).To(BeTrue())
+					Expect(parsedCert.NotAfter.After(time.Now().AddDate(0, 0, 1))).To(BeTrue())
+					Expect(parsedCert.PublicKey.(*ecdsa.PublicKey).Curve.Params().BitSize).To(Equal(384))
+					Expect(parsedCert.Subject.Organization).To(Equal([]string{"Cloud Foundry"}))
+					Expect(parsedCert.Subject.OrganizationalUnit).To(Equal([]string{"Quarks"}))
+					Expect(parsedCert.Subject.Locality).To(Equal([]string{"Berlin"}))
+					Expect(parsedCert.Subject.Province).To(Equal([]string{"Berlin"}))
+					Expect(parsedCert.Subject.Country).To(Equal([]string{"DE"}))
+				})
 			})
 		})
 
@@ -158,6 +204,18 @@ var _ = Describe("InMemoryGenerator", func() {
 					Expect(parsedCert.Subject.CommonName).To(Equal(request.CommonName))
 				})
 			})
+
+			It("considers the duration of the request", func() {
+				request.CommonName = "example.com"
+				request.Duration = 10 * 365 * 24 * time.Hour
+				cert, err := generator.GenerateCertificate("foo", request)
+				Expect(err).ToNot(HaveOccurred())
+
+				parsedCert, err := parseCert(cert.Certificate)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(parsedCert.NotAfter.After(time.Now().AddDate(9, 0, 0))).To(BeTrue())
+			})
 		})
 	})
 })
@@ -0,0 +1,125 @@
+package inmemorygenerator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+
+	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
+	"github.com/pkg/errors"
+)
+
+// GenerateKey generates a private key and its public key using go's standard crypto library
+func (g InMemoryGenerator) GenerateKey(name string, request credsgen.KeyGenerationRequest) (credsgen.Key, error) {
+	g.log.Debugf("Generating key %s", name)
+
+	algorithm, length := g.keyParameters(request.Algorithm, request.Length)
+	private, err := generatePrivateKey(algorithm, length)
+	if err != nil {
+		return credsgen.Key{}, errors.Wrapf(err, "Generating private key failed for secret name %s", name)
+	}
+
+	privatePEM, err := marshalPrivateKey(private)
+	if err != nil {
+		return credsgen.Key{}, errors.Wrap(err, "marshalling private key")
+	}
+
+	publicSerialized, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return credsgen.Key{}, errors.Wrap(err, "generating public key")
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicSerialized,
+	})
+
+	key := credsgen.Key{
+		Algorithm:  algorithm,
+		PrivateKey: privatePEM,
+		PublicKey:  publicPEM,
+	}
+	return key, nil
+}
+
+// keyParameters returns the algorithm and length of a key, using the defaults
+// of the generator for empty values
+func (g InMemoryGenerator) keyParameters(algorithm string, length int) (string, int) {
+	if algorithm == "" {
+		algorithm = g.Algorithm
+	}
+	if length == 0 {
+		switch {
+		case algorithm == g.Algorithm:
+			length = g.Bits
+		case algorithm == credsgen.ECDSAAlgorithm:
+			length = 256
+		default:
+			length = 2048
+		}
+	}
+	return algorithm, length
+}
+
+// generatePrivateKey generates a private key for the algorithm. The length
+// selects the curve of ECDSA keys and is ignored for Ed25519 keys.
+func generatePrivateKey(algorithm string, length int) (crypto.Signer, error) {
+	switch algorithm {
+	case credsgen.RSAAlgorithm:
+		return rsa.GenerateKey(rand.Reader, length)
+	case credsgen.ECDSAAlgorithm:
+		var curve elliptic.Curve
+		switch length {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("invalid length %d for ecdsa key, must be one of 256, 384 or 521", length)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case credsgen.Ed25519Algorithm:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	default:
+		return nil, errors.Errorf("unsupported key algorithm '%s'", algorithm)
+	}
+}
+
+// marshalPrivateKey PEM encodes a private key. RSA and ECDSA keys use their
+// traditional encoding, Ed25519 keys use PKCS #8.
+func marshalPrivateKey(private crypto.Signer) ([]byte, error) {
+	var block *pem.Block
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(private),
+		}
+	case *ecdsa.PrivateKey:
+		bytes, err := x509.MarshalECPrivateKey(private)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: bytes,
+		}
+	default:
+		bytes, err := x509.MarshalPKCS8PrivateKey(private)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: bytes,
+		}
+	}
+	return pem.EncodeToMemory(block), nil
+}
@@ -0,0 +1,84 @@
+package inmemorygenerator_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/cf-operator/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("InMemoryGenerator", func() {
+	var (
+		generator credsgen.Generator
+	)
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		generator = inmemorygenerator.NewInMemoryGenerator(log)
+	})
+
+	parsePublicKey := func(key credsgen.Key) interface{} {
+		block, _ := pem.Decode(key.PublicKey)
+		Expect(block).ToNot(BeNil())
+		Expect(block.Type).To(Equal("PUBLIC KEY"))
+
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+		return public
+	}
+
+	Describe("GenerateKey", func() {
+		It("generates an RSA key by default", func() {
+			key, err := generator.GenerateKey("foo", credsgen.KeyGenerationRequest{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(key.Algorithm).To(Equal(credsgen.RSAAlgorithm))
+			Expect(key.PrivateKey).To(ContainSubstring("BEGIN RSA PRIVATE KEY"))
+			Expect(parsePublicKey(key).(*rsa.PublicKey).Size()).To(Equal(256))
+		})
+
+		It("generates ECDSA keys", func() {
+			key, err := generator.GenerateKey("foo", credsgen.KeyGenerationRequest{Algorithm: credsgen.ECDSAAlgorithm})
+			Expect(err).ToNot(HaveOccurred())
+
+			block, _ := pem.Decode(key.PrivateKey)
+			Expect(block.Type).To(Equal("EC PRIVATE KEY"))
+			_, err = x509.ParseECPrivateKey(block.Bytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsePublicKey(key).(*ecdsa.PublicKey).Curve.Params().Name).To(Equal("P-256"))
+		})
+
+		It("considers the length of ECDSA keys", func() {
+			key, err := generator.GenerateKey("foo", credsgen.KeyGenerationRequest{Algorithm: credsgen.ECDSAAlgorithm, Length: 384})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(parsePublicKey(key).(*ecdsa.PublicKey).Curve.Params().Name).To(Equal("P-384"))
+		})
+
+		It("fails for invalid lengths of ECDSA keys", func() {
+			_, err := generator.GenerateKey("foo", credsgen.KeyGenerationRequest{Algorithm: credsgen.ECDSAAlgorithm, Length: 2048})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid length 2048 for ecdsa key"))
+		})
+
+		It("generates Ed25519 keys", func() {
+			key, err := generator.GenerateKey("foo", credsgen.KeyGenerationRequest{Algorithm: credsgen.Ed25519Algorithm})
+			Expect(err).ToNot(HaveOccurred())
+
+			block, _ := pem.Decode(key.PrivateKey)
+			Expect(block.Type).To(Equal("PRIVATE KEY"))
+			private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(private).To(BeAssignableToTypeOf(ed25519.PrivateKey{}))
+			Expect(parsePublicKey(key)).To(Equal(private.(ed25519.PrivateKey).Public()))
+		})
+	})
+})
@@ -1,9 +1,10 @@
 package inmemorygenerator
 
 import (
+	"crypto"
+	"crypto/ed25519"
 	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
+	"encoding/binary"
 	"encoding/pem"
 
 	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
@@ -12,22 +13,22 @@ import (
 )
 
 // GenerateSSHKey generates an SSH key using go's standard crypto library
-func (g InMemoryGenerator) GenerateSSHKey(name string) (credsgen.SSHKey, error) {
+func (g InMemoryGenerator) GenerateSSHKey(name string, request credsgen.KeyGenerationRequest) (credsgen.SSHKey, error) {
 	g.log.Debugf("Generating SSH key %s", name)
 
 	// generate private key
-	private, err := rsa.GenerateKey(rand.Reader, g.Bits)
+	algorithm, length := g.keyParameters(request.Algorithm, request.Length)
+	private, err := generatePrivateKey(algorithm, length)
 	if err != nil {
 		return credsgen.SSHKey{}, errors.Wrapf(err, "Generating ssh key failed for secret %s", name)
 	}
-	privateBlock := &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(private),
+	privatePEM, err := marshalSSHPrivateKey(private)
+	if err != nil {
+		return credsgen.SSHKey{}, errors.Wrapf(err, "Marshalling ssh key failed for secret %s", name)
 	}
-	privatePEM := pem.EncodeToMemory(privateBlock)
 
 	// Calculate public key
-	public, err := ssh.NewPublicKey(&private.PublicKey)
+	public, err := ssh.NewPublicKey(private.Public())
 	if err != nil {
 		return credsgen.SSHKey{}, err
 	}
@@ -39,3 +40,70 @@ func (g InMemoryGenerator) GenerateSSHKey(name string) (credsgen.SSHKey, error)
 	}
 	return key, nil
 }
+
+// marshalSSHPrivateKey PEM encodes a private key for SSH. Ed25519 keys are
+// only supported in the OpenSSH format.
+func marshalSSHPrivateKey(private crypto.Signer) ([]byte, error) {
+	if private, ok := private.(ed25519.PrivateKey); ok {
+		return marshalOpenSSHEd25519PrivateKey(private)
+	}
+	return marshalPrivateKey(private)
+}
+
+// marshalOpenSSHEd25519PrivateKey encodes an unencrypted Ed25519 key in the
+// OpenSSH private key format, see PROTOCOL.key of OpenSSH
+func marshalOpenSSHEd25519PrivateKey(private ed25519.PrivateKey) ([]byte, error) {
+	const keyType = "ssh-ed25519"
+	public := private.Public().(ed25519.PublicKey)
+
+	checkBytes := make([]byte, 4)
+	if _, err := rand.Read(checkBytes); err != nil {
+		return nil, err
+	}
+	check := binary.BigEndian.Uint32(checkBytes)
+
+	keys := struct {
+		Check1  uint32
+		Check2  uint32
+		KeyType string
+		Public  []byte
+		Private []byte
+		Comment string
+		Rest    []byte `ssh:"rest"`
+	}{
+		Check1:  check,
+		Check2:  check,
+		KeyType: keyType,
+		Public:  public,
+		Private: private,
+	}
+	// pad to the block size of the 'none' cipher
+	for i := 1; (len(ssh.Marshal(keys)))%8 != 0; i++ {
+		keys.Rest = append(keys.Rest, byte(i))
+	}
+
+	publicKey := struct {
+		KeyType string
+		Public  []byte
+	}{keyType, public}
+
+	envelope := struct {
+		CipherName  string
+		KdfName     string
+		KdfOptions  string
+		NumKeys     uint32
+		PublicKey   []byte
+		PrivateKeys []byte
+	}{
+		CipherName:  "none",
+		KdfName:     "none",
+		NumKeys:     1,
+		PublicKey:   ssh.Marshal(publicKey),
+		PrivateKeys: ssh.Marshal(keys),
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append([]byte("openssh-key-v1\x00"), ssh.Marshal(envelope)...),
+	}), nil
+}
@@ -3,6 +3,7 @@ package inmemorygenerator_test
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
 
 	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
 	inmemorygenerator "code.cloudfoundry.org/cf-operator/pkg/credsgen/in_memory_generator"
@@ -21,12 +22,42 @@ var _ = Describe("InMemoryGenerator", func() {
 
 	Describe("GenerateSSHKey", func() {
 		It("generates an SSH key", func() {
-			key, err := generator.GenerateSSHKey("foo")
+			key, err := generator.GenerateSSHKey("foo", credsgen.KeyGenerationRequest{})
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(key.PrivateKey).To(ContainSubstring("BEGIN RSA PRIVATE KEY"))
 			Expect(key.PublicKey).To(MatchRegexp("ssh-rsa\\s.+"))
 			Expect(key.Fingerprint).To(MatchRegexp("([0-9a-f]{2}:){15}[0-9a-f]{2}"))
 		})
+
+		It("generates an ECDSA SSH key", func() {
+			key, err := generator.GenerateSSHKey("foo", credsgen.KeyGenerationRequest{Algorithm: credsgen.ECDSAAlgorithm})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key.PrivateKey).To(ContainSubstring("BEGIN EC PRIVATE KEY"))
+			Expect(key.PublicKey).To(MatchRegexp("ecdsa-sha2-nistp256\\s.+"))
+
+			_, err = ssh.ParsePrivateKey(key.PrivateKey)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("generates an Ed25519 SSH key in the OpenSSH format", func() {
+			key, err := generator.GenerateSSHKey("foo", credsgen.KeyGenerationRequest{Algorithm: credsgen.Ed25519Algorithm})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key.PrivateKey).To(ContainSubstring("BEGIN OPENSSH PRIVATE KEY"))
+			Expect(key.PublicKey).To(MatchRegexp("ssh-ed25519\\s.+"))
+
+			signer, err := ssh.ParsePrivateKey(key.PrivateKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ssh.MarshalAuthorizedKey(signer.PublicKey())).To(Equal(key.PublicKey))
+		})
+
+		It("fails for unknown algorithms", func() {
+			_, err := generator.GenerateSSHKey("foo", credsgen.KeyGenerationRequest{Algorithm: "dsa"})
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unsupported key algorithm 'dsa'"))
+		})
 	})
 })
@@ -0,0 +1,22 @@
+package inmemorygenerator
+
+import (
+	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
+	"github.com/dchest/uniuri"
+)
+
+// GenerateUser generates a random password for a user. A random user name is
+// generated, if the request does not specify one.
+func (g InMemoryGenerator) GenerateUser(name string, request credsgen.UserGenerationRequest) credsgen.User {
+	g.log.Debugf("Generating user %s", name)
+
+	username := request.Username
+	if username == "" {
+		username = uniuri.NewLen(credsgen.DefaultUsernameLength)
+	}
+
+	return credsgen.User{
+		Username: username,
+		Password: g.GeneratePassword(name, credsgen.PasswordGenerationRequest{Length: request.PasswordLength}),
+	}
+}
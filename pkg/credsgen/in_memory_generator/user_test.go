@@ -0,0 +1,37 @@
+package inmemorygenerator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/cf-operator/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("InMemoryGenerator", func() {
+	var (
+		generator credsgen.Generator
+	)
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		generator = inmemorygenerator.NewInMemoryGenerator(log)
+	})
+
+	Describe("GenerateUser", func() {
+		It("generates a user name and a password", func() {
+			user := generator.GenerateUser("foo", credsgen.UserGenerationRequest{})
+
+			Expect(len(user.Username)).To(Equal(credsgen.DefaultUsernameLength))
+			Expect(len(user.Password)).To(Equal(credsgen.DefaultPasswordLength))
+		})
+
+		It("considers the user name", func() {
+			user := generator.GenerateUser("foo", credsgen.UserGenerationRequest{Username: "admin"})
+
+			Expect(user.Username).To(Equal("admin"))
+			Expect(user.Password).ToNot(BeEmpty())
+		})
+	})
+})
@@ -59,8 +59,26 @@ var (
 										{
 											Raw: []byte(`"url"`),
 										},
+										{
+											Raw: []byte(`"git"`),
+										},
 									},
 								},
+								"namespace": {
+									Type: "string",
+								},
+								"ref": {
+									Type: "string",
+								},
+								"path": {
+									Type: "string",
+								},
+								"secretName": {
+									Type: "string",
+								},
+								"sha256": {
+									Type: "string",
+								},
 							},
 							Required: []string{
 								"type",
@@ -89,8 +107,76 @@ var (
 												{
 													Raw: []byte(`"url"`),
 												},
+												{
+													Raw: []byte(`"git"`),
+												},
+											},
+										},
+										"namespace": {
+											Type: "string",
+										},
+										"ref": {
+											Type: "string",
+										},
+										"path": {
+											Type: "string",
+										},
+										"secretName": {
+											Type: "string",
+										},
+										"sha256": {
+											Type: "string",
+										},
+									},
+									Required: []string{
+										"type",
+										"name",
+									},
+								},
+							},
+						},
+						"vars": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"name": {
+											Type:      "string",
+											MinLength: pointers.Int64(1),
+										},
+										"type": {
+											Type: "string",
+											Enum: []extv1.JSON{
+												{
+													Raw: []byte(`"configmap"`),
+												},
+												{
+													Raw: []byte(`"secret"`),
+												},
+												{
+													Raw: []byte(`"url"`),
+												},
+												{
+													Raw: []byte(`"git"`),
+												},
 											},
 										},
+										"namespace": {
+											Type: "string",
+										},
+										"ref": {
+											Type: "string",
+										},
+										"path": {
+											Type: "string",
+										},
+										"secretName": {
+											Type: "string",
+										},
+										"sha256": {
+											Type: "string",
+										},
 									},
 									Required: []string{
 										"type",
@@ -99,6 +185,12 @@ var (
 								},
 							},
 						},
+						"dryRun": {
+							Type: "boolean",
+						},
+						"paused": {
+							Type: "boolean",
+						},
 					},
 					Required: []string{
 						"manifest",
@@ -110,6 +202,78 @@ var (
 						"lastReconcile": {
 							Type: "string",
 						},
+						"conditions": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"type": {
+											Type: "string",
+										},
+										"status": {
+											Type: "string",
+										},
+										"lastTransitionTime": {
+											Type: "string",
+										},
+										"reason": {
+											Type: "string",
+										},
+										"message": {
+											Type: "string",
+										},
+									},
+								},
+							},
+						},
+						"instanceGroups": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"name": {
+											Type: "string",
+										},
+										"desiredReplicas": {
+											Type: "integer",
+										},
+										"readyReplicas": {
+											Type: "integer",
+										},
+										"igResolvedSecretVersion": {
+											Type: "string",
+										},
+										"bpmSecretVersion": {
+											Type: "string",
+										},
+									},
+								},
+							},
+						},
+						"gitReferences": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"repository": {
+											Type: "string",
+										},
+										"ref": {
+											Type: "string",
+										},
+										"path": {
+											Type: "string",
+										},
+										"commit": {
+											Type: "string",
+										},
+									},
+								},
+							},
+						},
 					},
 				},
 			},
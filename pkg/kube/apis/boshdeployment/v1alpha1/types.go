@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"code.cloudfoundry.org/cf-operator/pkg/kube/apis"
@@ -23,9 +24,17 @@ const (
 	SecretReference ReferenceType = "secret"
 	// URLReference represents URL reference
 	URLReference ReferenceType = "url"
+	// GitReference represents a file in a git repository
+	GitReference ReferenceType = "git"
+
+	// PVCPolicyRetain keeps the persistent volume claims of instance groups when a BOSHDeployment is deleted
+	PVCPolicyRetain = "retain"
+	// PVCPolicyDelete deletes the persistent volume claims of instance groups when a BOSHDeployment is deleted
+	PVCPolicyDelete = "delete"
 
 	ManifestSpecName        string = "manifest"
 	OpsSpecName             string = "ops"
+	VarsSpecName            string = "vars"
 	ImplicitVariableKeyName string = "value"
 )
 
@@ -38,24 +47,189 @@ var (
 	AnnotationLinkProvidesKey = fmt.Sprintf("%s/provides", apis.GroupName)
 	// AnnotationLinkProviderService is the annotation key used on services to identify the link provider
 	AnnotationLinkProviderService = fmt.Sprintf("%s/link-provider-name", apis.GroupName)
+	// AnnotationPVCPolicy is the annotation key for the PVC policy applied when deleting a BOSHDeployment, either 'retain' or 'delete'
+	AnnotationPVCPolicy = fmt.Sprintf("%s/pvc-policy", apis.GroupName)
+	// AnnotationDryRun is the annotation key to only plan the changes of a BOSHDeployment, instead of applying them
+	AnnotationDryRun = fmt.Sprintf("%s/dry-run", apis.GroupName)
+	// AnnotationPaused is the annotation key to pause the reconciliation of a BOSHDeployment
+	AnnotationPaused = fmt.Sprintf("%s/paused", apis.GroupName)
+	// Finalizer is the finalizer used to tear down the instance groups of a BOSHDeployment in order
+	Finalizer = fmt.Sprintf("%s/finalizer", apis.GroupName)
 )
 
 // BOSHDeploymentSpec defines the desired state of BOSHDeployment
 type BOSHDeploymentSpec struct {
 	Manifest ResourceReference   `json:"manifest"`
 	Ops      []ResourceReference `json:"ops,omitempty"`
+	// Vars reference YAML maps of variables, which are interpolated into the
+	// manifest after the ops files are applied. Later references take
+	// precedence over earlier ones.
+	Vars []ResourceReference `json:"vars,omitempty"`
+	// DryRun only writes the planned changes to a config map, nothing is applied
+	DryRun bool `json:"dryRun,omitempty"`
+	// Paused stops the reconciliation of the deployment and its instance groups until it is unset
+	Paused bool `json:"paused,omitempty"`
+}
+
+// References returns the manifest, ops and vars references of the spec
+func (spec BOSHDeploymentSpec) References() []ResourceReference {
+	refs := append([]ResourceReference{spec.Manifest}, spec.Ops...)
+	return append(refs, spec.Vars...)
 }
 
 // ResourceReference defines the resource reference type and location
 type ResourceReference struct {
 	Name string        `json:"name"`
 	Type ReferenceType `json:"type"`
+	// Namespace of the referenced config map or secret, or of the credentials
+	// secret. Defaults to the namespace of the BOSHDeployment
+	Namespace string `json:"namespace,omitempty"`
+	// Ref is the branch, tag or commit of a git reference, defaults to HEAD
+	Ref string `json:"ref,omitempty"`
+	// Path of the file in the repository of a git reference
+	Path string `json:"path,omitempty"`
+	// SecretName is the name of a secret with credentials for a git or url reference
+	SecretName string `json:"secretName,omitempty"`
+	// SHA256 is the expected checksum of the content of an url reference
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// BOSHDeploymentConditionType is the type of a BOSHDeployment condition
+type BOSHDeploymentConditionType string
+
+// Valid values for BOSHDeployment condition types
+const (
+	// ManifestResolved means the manifest and ops files have been resolved into the with-ops manifest
+	ManifestResolved BOSHDeploymentConditionType = "ManifestResolved"
+	// VariablesGenerated means all explicit BOSH variables have been generated
+	VariablesGenerated BOSHDeploymentConditionType = "VariablesGenerated"
+	// DesiredManifestReady means the variable interpolation rendered the desired manifest
+	DesiredManifestReady BOSHDeploymentConditionType = "DesiredManifestReady"
+	// InstanceGroupsReady means all replicas of all instance groups are ready
+	InstanceGroupsReady BOSHDeploymentConditionType = "InstanceGroupsReady"
+	// Ready means all other conditions are true
+	Ready BOSHDeploymentConditionType = "Ready"
+	// Paused means the reconciliation of the deployment is paused, it's not considered for Ready
+	Paused BOSHDeploymentConditionType = "Paused"
+)
+
+// BOSHDeploymentCondition describes the state of a BOSHDeployment at a certain point
+type BOSHDeploymentCondition struct {
+	// Type of the condition
+	Type BOSHDeploymentConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// Last time the condition transitioned from one status to another
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+	// Human readable message indicating details about the last transition
+	Message string `json:"message,omitempty"`
+}
+
+// InstanceGroupStatus defines the observed state of an instance group
+type InstanceGroupStatus struct {
+	// Name of the instance group
+	Name string `json:"name"`
+	// Number of replicas requested by the manifest
+	DesiredReplicas int32 `json:"desiredReplicas"`
+	// Number of replicas which are ready
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// Version of the ig-resolved secret currently deployed
+	IGResolvedSecretVersion string `json:"igResolvedSecretVersion,omitempty"`
+	// Version of the BPM secret currently deployed
+	BPMSecretVersion string `json:"bpmSecretVersion,omitempty"`
+}
+
+// IsReady returns true if all desired replicas of the instance group are ready
+func (s InstanceGroupStatus) IsReady() bool {
+	return s.ReadyReplicas >= s.DesiredReplicas
+}
+
+// GitReferenceStatus is the commit a git reference was resolved to
+type GitReferenceStatus struct {
+	// URL of the repository
+	Repository string `json:"repository"`
+	// Ref which was resolved
+	Ref string `json:"ref,omitempty"`
+	// Path of the file in the repository
+	Path string `json:"path"`
+	// Commit SHA the ref pointed to
+	Commit string `json:"commit"`
 }
 
 // BOSHDeploymentStatus defines the observed state of BOSHDeployment
 type BOSHDeploymentStatus struct {
 	// Timestamp for the last reconcile
 	LastReconcile *metav1.Time `json:"lastReconcile"`
+	// Conditions of the deployment, the Ready condition is true once all others are
+	Conditions []BOSHDeploymentCondition `json:"conditions,omitempty"`
+	// Per instance group replica counts and deployed secret versions
+	InstanceGroups []InstanceGroupStatus `json:"instanceGroups,omitempty"`
+	// Commits the git references of manifest and ops files resolved to
+	GitReferences []GitReferenceStatus `json:"gitReferences,omitempty"`
+}
+
+// GetCondition returns the condition of the given type, or nil if it's not set
+func (s *BOSHDeploymentStatus) GetCondition(conditionType BOSHDeploymentConditionType) *BOSHDeploymentCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true if the condition of the given type has status True
+func (s *BOSHDeploymentStatus) IsConditionTrue(conditionType BOSHDeploymentConditionType) bool {
+	c := s.GetCondition(conditionType)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
+
+// SetCondition adds or updates the condition of the given type. The
+// transition time only changes if the status changes. The Ready condition is
+// recalculated afterwards.
+func (s *BOSHDeploymentStatus) SetCondition(conditionType BOSHDeploymentConditionType, status corev1.ConditionStatus, reason, message string) {
+	s.setCondition(conditionType, status, reason, message)
+	if conditionType != Ready {
+		s.updateReadyCondition()
+	}
+}
+
+func (s *BOSHDeploymentStatus) setCondition(conditionType BOSHDeploymentConditionType, status corev1.ConditionStatus, reason, message string) {
+	c := s.GetCondition(conditionType)
+	if c == nil {
+		s.Conditions = append(s.Conditions, BOSHDeploymentCondition{Type: conditionType})
+		c = &s.Conditions[len(s.Conditions)-1]
+	}
+
+	if c.Status != status || c.LastTransitionTime == nil {
+		now := metav1.Now()
+		c.LastTransitionTime = &now
+	}
+	c.Status = status
+	c.Reason = reason
+	c.Message = message
+}
+
+func (s *BOSHDeploymentStatus) updateReadyCondition() {
+	for _, t := range []BOSHDeploymentConditionType{ManifestResolved, VariablesGenerated, DesiredManifestReady, InstanceGroupsReady} {
+		if !s.IsConditionTrue(t) {
+			s.setCondition(Ready, corev1.ConditionFalse, "Waiting", fmt.Sprintf("waiting for condition %s", t))
+			return
+		}
+	}
+	s.setCondition(Ready, corev1.ConditionTrue, "Deployed", "all instance groups are ready")
+}
+
+// GetInstanceGroup returns the status of the named instance group, or nil if it's not set
+func (s *BOSHDeploymentStatus) GetInstanceGroup(name string) *InstanceGroupStatus {
+	for i := range s.InstanceGroups {
+		if s.InstanceGroups[i].Name == name {
+			return &s.InstanceGroups[i]
+		}
+	}
+	return nil
 }
 
 // +genclient
@@ -71,6 +245,24 @@ type BOSHDeployment struct {
 	Status BOSHDeploymentStatus `json:"status,omitempty"`
 }
 
+// IsDryRun returns true if the changes of the BOSHDeployment should only be
+// planned, either because of the spec or the dry-run annotation
+func (bdpl *BOSHDeployment) IsDryRun() bool {
+	return bdpl.Spec.DryRun || bdpl.GetAnnotations()[AnnotationDryRun] == "true"
+}
+
+// IsPaused returns true if the reconciliation of the BOSHDeployment is
+// paused, either because of the spec or the paused annotation
+func (bdpl *BOSHDeployment) IsPaused() bool {
+	return bdpl.Spec.Paused || bdpl.GetAnnotations()[AnnotationPaused] == "true"
+}
+
+// PlanConfigMapName returns the name of the config map, which contains the
+// planned changes of a dry-run
+func (bdpl *BOSHDeployment) PlanConfigMapName() string {
+	return fmt.Sprintf("%s.plan", bdpl.Name)
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // BOSHDeploymentList contains a list of BOSHDeployment
@@ -41,6 +41,26 @@ func (in *BOSHDeployment) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHDeploymentCondition) DeepCopyInto(out *BOSHDeploymentCondition) {
+	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDeploymentCondition.
+func (in *BOSHDeploymentCondition) DeepCopy() *BOSHDeploymentCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHDeploymentCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BOSHDeploymentList) DeepCopyInto(out *BOSHDeploymentList) {
 	*out = *in
@@ -83,6 +103,11 @@ func (in *BOSHDeploymentSpec) DeepCopyInto(out *BOSHDeploymentSpec) {
 		*out = make([]ResourceReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.Vars != nil {
+		in, out := &in.Vars, &out.Vars
+		*out = make([]ResourceReference, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -103,6 +128,23 @@ func (in *BOSHDeploymentStatus) DeepCopyInto(out *BOSHDeploymentStatus) {
 		in, out := &in.LastReconcile, &out.LastReconcile
 		*out = (*in).DeepCopy()
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]BOSHDeploymentCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InstanceGroups != nil {
+		in, out := &in.InstanceGroups, &out.InstanceGroups
+		*out = make([]InstanceGroupStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.GitReferences != nil {
+		in, out := &in.GitReferences, &out.GitReferences
+		*out = make([]GitReferenceStatus, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -116,6 +158,38 @@ func (in *BOSHDeploymentStatus) DeepCopy() *BOSHDeploymentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitReferenceStatus) DeepCopyInto(out *GitReferenceStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitReferenceStatus.
+func (in *GitReferenceStatus) DeepCopy() *GitReferenceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitReferenceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGroupStatus) DeepCopyInto(out *InstanceGroupStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGroupStatus.
+func (in *InstanceGroupStatus) DeepCopy() *InstanceGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceReference) DeepCopyInto(out *ResourceReference) {
 	*out = *in
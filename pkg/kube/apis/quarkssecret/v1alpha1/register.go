@@ -48,12 +48,77 @@ var (
 						"type": {
 							Type:        "string",
 							MinLength:   pointers.Int64(1),
-							Description: "What kind of secret to generate: password, certificate, ssh, rsa",
+							Description: "What kind of secret to generate: password, user, certificate, ssh, rsa, key, templated",
 						},
 						"request": {
 							Type:                   "object",
 							XPreserveUnknownFields: pointers.Bool(true),
 						},
+						"copies": {
+							Type:        "array",
+							Description: "Copies of the generated secret in other namespaces",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"name": {
+											Type: "string",
+										},
+										"namespace": {
+											Type:      "string",
+											MinLength: pointers.Int64(1),
+										},
+									},
+									Required: []string{"namespace"},
+								},
+							},
+						},
+						"backend": {
+							Type:        "object",
+							Description: "External store for the material of the secret",
+							Properties: map[string]extv1.JSONSchemaProps{
+								"name": {
+									Type:        "string",
+									MinLength:   pointers.Int64(1),
+									Description: "Name of a backend configured in the operator, e.g. vault or file",
+								},
+								"path": {
+									Type:        "string",
+									Description: "Path of the secret in the backend, relative to <namespace>/, defaults to <secretName>",
+								},
+								"mode": {
+									Type:        "string",
+									Description: "Either store the generated material in the backend or fetch it from there",
+									Enum: []extv1.JSON{
+										{
+											Raw: []byte(`"store"`),
+										},
+										{
+											Raw: []byte(`"fetch"`),
+										},
+									},
+								},
+							},
+							Required: []string{"name"},
+						},
+						"rotation": {
+							Type:        "object",
+							Description: "When to rotate the generated secret",
+							Properties: map[string]extv1.JSONSchemaProps{
+								"interval": {
+									Type:        "string",
+									Description: "Interval between two rotations, e.g. 720h",
+								},
+								"schedule": {
+									Type:        "string",
+									Description: "Cron expression for rotations, e.g. '0 3 * * 0'",
+								},
+								"maxAge": {
+									Type:        "string",
+									Description: "Maximum age of the secret before it is rotated",
+								},
+							},
+						},
 					},
 					Required: []string{
 						"secretName",
@@ -69,12 +134,100 @@ var (
 						"lastReconcile": {
 							Type: "string",
 						},
+						"notAfter": {
+							Type: "string",
+						},
+						"lastRotated": {
+							Type: "string",
+						},
+						"caRotation": {
+							Type: "string",
+						},
+						"conditions": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"type": {
+											Type: "string",
+										},
+										"status": {
+											Type: "string",
+										},
+										"lastTransitionTime": {
+											Type: "string",
+										},
+										"reason": {
+											Type: "string",
+										},
+										"message": {
+											Type: "string",
+										},
+									},
+								},
+							},
+						},
+						"observedGeneration": {
+							Type: "integer",
+						},
+						"specHash": {
+							Type: "string",
+						},
+						"copies": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"name": {
+											Type: "string",
+										},
+										"namespace": {
+											Type:      "string",
+											MinLength: pointers.Int64(1),
+										},
+									},
+									Required: []string{"namespace"},
+								},
+							},
+						},
 					},
 				},
 			},
 		},
 	}
 
+	// QuarksSecretPrinterColumns are shown by kubectl get, they explain why
+	// a secret is not generated yet
+	QuarksSecretPrinterColumns = []extv1.CustomResourceColumnDefinition{
+		{
+			Name:     "Type",
+			Type:     "string",
+			JSONPath: ".spec.type",
+		},
+		{
+			Name:     "Generated",
+			Type:     "boolean",
+			JSONPath: ".status.generated",
+		},
+		{
+			Name:     "Reason",
+			Type:     "string",
+			JSONPath: `.status.conditions[?(@.type=="Generated")].reason`,
+		},
+		{
+			Name:     "Message",
+			Type:     "string",
+			JSONPath: `.status.conditions[?(@.type=="Generated")].message`,
+		},
+		{
+			Name:     "Age",
+			Type:     "date",
+			JSONPath: ".metadata.creationTimestamp",
+		},
+	}
+
 	// QuarksSecretResourceName is the resource name of QuarksSecret
 	QuarksSecretResourceName = fmt.Sprintf("%s.%s", QuarksSecretResourcePlural, apis.GroupName)
 
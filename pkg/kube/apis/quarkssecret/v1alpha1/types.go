@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	certv1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"code.cloudfoundry.org/cf-operator/pkg/kube/apis"
@@ -22,6 +23,22 @@ const (
 	Certificate SecretType = "certificate"
 	SSHKey      SecretType = "ssh"
 	RSAKey      SecretType = "rsa"
+	User        SecretType = "user"
+	Key         SecretType = "key"
+	Templated   SecretType = "templated"
+)
+
+// KeyAlgorithm defines the algorithm of generated keys
+type KeyAlgorithm = string
+
+// Valid values for key algorithms
+const (
+	// RSAKeyAlgorithm generates RSA keys
+	RSAKeyAlgorithm KeyAlgorithm = "rsa"
+	// ECDSAKeyAlgorithm generates ECDSA keys, the key length selects the curve
+	ECDSAKeyAlgorithm KeyAlgorithm = "ecdsa"
+	// Ed25519KeyAlgorithm generates Ed25519 keys, not supported for certificates
+	Ed25519KeyAlgorithm KeyAlgorithm = "ed25519"
 )
 
 // SignerType defines the type of the certificate signer
@@ -35,6 +52,32 @@ const (
 	ClusterSigner SignerType = "cluster"
 )
 
+// BackendMode defines how a QuarksSecret uses its secret backend
+type BackendMode = string
+
+// Valid values for backend modes
+const (
+	// BackendStore stores the generated material in the backend and
+	// restores it from there, if the secret is missing in the cluster
+	BackendStore BackendMode = "store"
+	// BackendFetch fetches the material from the backend, nothing is
+	// generated
+	BackendFetch BackendMode = "fetch"
+)
+
+// CARotationPhase defines the phase of the rotation of a self-signed CA
+type CARotationPhase = string
+
+// Valid values for CA rotation phases
+const (
+	// CARotationOverlapping means the trust bundle contains the new and the
+	// previous CA, while the certificates signed by the CA are reissued
+	CARotationOverlapping CARotationPhase = "Overlapping"
+	// CARotationCompleted means the previous CA was removed from the trust
+	// bundles after the grace period
+	CARotationCompleted CARotationPhase = "Completed"
+)
+
 var (
 	// LabelKind is the label key for secret kind
 	LabelKind = fmt.Sprintf("%s/secret-kind", apis.GroupName)
@@ -42,6 +85,8 @@ var (
 	AnnotationCertSecretName = fmt.Sprintf("%s/cert-secret-name", apis.GroupName)
 	// AnnotationQSecNamespace is the annotation key for quarks secret namespace
 	AnnotationQSecNamespace = fmt.Sprintf("%s/quarks-secret-namespace", apis.GroupName)
+	// AnnotationQSecName is the annotation key for quarks secret name
+	AnnotationQSecName = fmt.Sprintf("%s/quarks-secret-name", apis.GroupName)
 	// LabelSecretRotationTrigger is set on a config map to trigger secret
 	// rotation. If set, then creating the config map will trigger secret
 	// rotation.
@@ -49,11 +94,19 @@ var (
 	// RotateQSecretListName is the name of the config map entry, which
 	// contains a JSON array of quarks secret names to rotate
 	RotateQSecretListName = "secrets"
+	// AnnotationCopyOf is the annotation key for the namespace and name of
+	// the quarks secret, which a secret is a copy of
+	AnnotationCopyOf = fmt.Sprintf("%s/copy-of", apis.GroupName)
+	// CopiesFinalizer is the finalizer used to delete the copies of a quarks
+	// secret in other namespaces
+	CopiesFinalizer = fmt.Sprintf("%s/copies-finalizer", apis.GroupName)
 )
 
 const (
 	// GeneratedSecretKind is the kind of generated secret
 	GeneratedSecretKind = "generated"
+	// CopySecretKind is the kind of copies of generated secrets
+	CopySecretKind = "copy"
 )
 
 // SecretReference specifies a reference to another secret
@@ -78,18 +131,128 @@ type CertificateRequest struct {
 	Usages                      []certv1.KeyUsage  `json:"usages"`
 	ServiceRef                  []ServiceReference `json:"serviceRef"`
 	ActivateEKSWorkaroundForSAN bool               `json:"activateEKSWorkaroundForSAN,omitempty"`
+	// Duration is the validity of the certificate in days, defaults to 365
+	Duration int `json:"duration,omitempty"`
+	// KeyAlgorithm is the algorithm of the private key, defaults to rsa
+	KeyAlgorithm KeyAlgorithm `json:"keyAlgorithm,omitempty"`
+	// KeyLength is the size of the private key in bits, defaults to 2048
+	KeyLength          int    `json:"keyLength,omitempty"`
+	Organization       string `json:"organization,omitempty"`
+	OrganizationalUnit string `json:"organizationalUnit,omitempty"`
+	Locality           string `json:"locality,omitempty"`
+	State              string `json:"state,omitempty"`
+	Country            string `json:"country,omitempty"`
+}
+
+// UserRequest specifies the details for the user generation. A random user
+// name is generated if Username is empty.
+type UserRequest struct {
+	Username string `json:"username,omitempty"`
+}
+
+// KeyRequest specifies the details for the generation of keys and SSH keys
+type KeyRequest struct {
+	// Algorithm of the key, defaults to rsa
+	Algorithm KeyAlgorithm `json:"algorithm,omitempty"`
+	// Length of the key in bits, defaults to 2048 for rsa and 256 for ecdsa
+	Length int `json:"length,omitempty"`
+}
+
+// TemplatedConfig specifies the Go templates, which render the keys of a
+// templated secret from the values of other secrets
+type TemplatedConfig struct {
+	// Templates maps the keys of the secret to templates, which access the
+	// values as {{ .Values.name }}
+	Templates map[string]string `json:"templates"`
+	// Values maps the names of the values to keys of secrets or of the
+	// secrets generated by other QuarksSecrets
+	Values map[string]TemplatedValueReference `json:"values,omitempty"`
+}
+
+// TemplatedValueReference specifies a key of a secret by its name or of the
+// secret generated by a QuarksSecret, which is used once it is generated
+type TemplatedValueReference struct {
+	Name             string `json:"name,omitempty"`
+	QuarksSecretName string `json:"quarksSecretName,omitempty"`
+	Key              string `json:"key"`
 }
 
 // Request specifies details for the secret generation
 type Request struct {
 	CertificateRequest CertificateRequest `json:"certificate"`
+	UserRequest        UserRequest        `json:"user,omitempty"`
+	KeyRequest         KeyRequest         `json:"key,omitempty"`
+	TemplatedConfig    TemplatedConfig    `json:"templated,omitempty"`
+}
+
+// RotationPolicy specifies when the generated secret is rotated. Interval
+// and Schedule are mutually exclusive, MaxAge limits the age of the secret
+// in addition to either of them.
+type RotationPolicy struct {
+	// Interval between two rotations, e.g. 720h
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Schedule is a cron expression for rotations, e.g. "0 3 * * 0"
+	Schedule string `json:"schedule,omitempty"`
+	// MaxAge is the maximum age of the secret before it is rotated
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// SecretBackend selects an external store for the material of the secret
+type SecretBackend struct {
+	// Name of a backend configured in the operator, e.g. vault or file
+	Name string `json:"name"`
+	// Path of the secret in the backend, relative to <namespace>/, defaults to <secretName>
+	Path string `json:"path,omitempty"`
+	// Mode is either store (default) or fetch
+	Mode BackendMode `json:"mode,omitempty"`
+}
+
+// SecretCopy specifies a copy of the generated secret
+type SecretCopy struct {
+	// Name of the copy, defaults to the name of the generated secret
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace"`
 }
 
 // QuarksSecretSpec defines the desired state of QuarksSecret
 type QuarksSecretSpec struct {
-	Type       SecretType `json:"type"`
-	Request    Request    `json:"request"`
-	SecretName string     `json:"secretName"`
+	Type       SecretType      `json:"type"`
+	Request    Request         `json:"request"`
+	SecretName string          `json:"secretName"`
+	Rotation   *RotationPolicy `json:"rotation,omitempty"`
+	// Copies of the generated secret, which are kept in sync
+	Copies []SecretCopy `json:"copies,omitempty"`
+	// Backend stores the material of the secret outside of the cluster
+	Backend *SecretBackend `json:"backend,omitempty"`
+}
+
+// QuarksSecretConditionType is the type of a QuarksSecret condition
+type QuarksSecretConditionType string
+
+// Valid values for QuarksSecret condition types
+const (
+	// GeneratedCondition means the secret has been generated
+	GeneratedCondition QuarksSecretConditionType = "Generated"
+	// WaitingForCACondition means the CA, which signs the certificate, does not exist yet
+	WaitingForCACondition QuarksSecretConditionType = "WaitingForCA"
+	// WaitingForCSRApprovalCondition means the certificate signing request has not been issued yet
+	WaitingForCSRApprovalCondition QuarksSecretConditionType = "WaitingForCSRApproval"
+	// FailedCondition means the last generation of the secret failed
+	FailedCondition QuarksSecretConditionType = "Failed"
+)
+
+// QuarksSecretCondition describes the state of a QuarksSecret at a certain point
+type QuarksSecretCondition struct {
+	// Type of the condition
+	Type QuarksSecretConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// Last time the condition transitioned from one status to another
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+	// Human readable message indicating details about the last transition
+	Message string `json:"message,omitempty"`
 }
 
 // QuarksSecretStatus defines the observed state of QuarksSecret
@@ -98,6 +261,54 @@ type QuarksSecretStatus struct {
 	LastReconcile *metav1.Time `json:"lastReconcile"`
 	// Indicates if the secret has already been generated
 	Generated bool `json:"generated"`
+	// Expiry of the generated certificate, it is renewed before
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+	// Timestamp of the last generation of the secret
+	LastRotated *metav1.Time `json:"lastRotated,omitempty"`
+	// Phase of the rotation of a self-signed CA
+	CARotation CARotationPhase `json:"caRotation,omitempty"`
+	// Copies of the generated secret, which were created
+	Copies []SecretCopy `json:"copies,omitempty"`
+	// Conditions of the secret, they explain why it is not generated yet
+	Conditions []QuarksSecretCondition `json:"conditions,omitempty"`
+	// Generation of the spec, which was observed by the last reconcile
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Hash of the spec, which was used for the last generation
+	SpecHash string `json:"specHash,omitempty"`
+}
+
+// GetCondition returns the condition of the given type, or nil if it's not set
+func (s *QuarksSecretStatus) GetCondition(conditionType QuarksSecretConditionType) *QuarksSecretCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true if the condition of the given type has status True
+func (s *QuarksSecretStatus) IsConditionTrue(conditionType QuarksSecretConditionType) bool {
+	c := s.GetCondition(conditionType)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
+
+// SetCondition adds or updates the condition of the given type. The
+// transition time only changes if the status changes.
+func (s *QuarksSecretStatus) SetCondition(conditionType QuarksSecretConditionType, status corev1.ConditionStatus, reason, message string) {
+	c := s.GetCondition(conditionType)
+	if c == nil {
+		s.Conditions = append(s.Conditions, QuarksSecretCondition{Type: conditionType})
+		c = &s.Conditions[len(s.Conditions)-1]
+	}
+
+	if c.Status != status || c.LastTransitionTime == nil {
+		now := metav1.Now()
+		c.LastTransitionTime = &now
+	}
+	c.Status = status
+	c.Reason = reason
+	c.Message = message
 }
 
 // +genclient
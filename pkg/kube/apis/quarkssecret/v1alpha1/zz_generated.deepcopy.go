@@ -11,6 +11,7 @@ package v1alpha1
 
 import (
 	v1beta1 "k8s.io/api/certificates/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -47,6 +48,22 @@ func (in *CertificateRequest) DeepCopy() *CertificateRequest {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyRequest) DeepCopyInto(out *KeyRequest) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRequest.
+func (in *KeyRequest) DeepCopy() *KeyRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *QuarksSecret) DeepCopyInto(out *QuarksSecret) {
 	*out = *in
@@ -75,6 +92,26 @@ func (in *QuarksSecret) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuarksSecretCondition) DeepCopyInto(out *QuarksSecretCondition) {
+	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuarksSecretCondition.
+func (in *QuarksSecretCondition) DeepCopy() *QuarksSecretCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(QuarksSecretCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *QuarksSecretList) DeepCopyInto(out *QuarksSecretList) {
 	*out = *in
@@ -112,6 +149,21 @@ func (in *QuarksSecretList) DeepCopyObject() runtime.Object {
 func (in *QuarksSecretSpec) DeepCopyInto(out *QuarksSecretSpec) {
 	*out = *in
 	in.Request.DeepCopyInto(&out.Request)
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(RotationPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Copies != nil {
+		in, out := &in.Copies, &out.Copies
+		*out = make([]SecretCopy, len(*in))
+		copy(*out, *in)
+	}
+	if in.Backend != nil {
+		in, out := &in.Backend, &out.Backend
+		*out = new(SecretBackend)
+		**out = **in
+	}
 	return
 }
 
@@ -132,6 +184,26 @@ func (in *QuarksSecretStatus) DeepCopyInto(out *QuarksSecretStatus) {
 		in, out := &in.LastReconcile, &out.LastReconcile
 		*out = (*in).DeepCopy()
 	}
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRotated != nil {
+		in, out := &in.LastRotated, &out.LastRotated
+		*out = (*in).DeepCopy()
+	}
+	if in.Copies != nil {
+		in, out := &in.Copies, &out.Copies
+		*out = make([]SecretCopy, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]QuarksSecretCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -149,6 +221,9 @@ func (in *QuarksSecretStatus) DeepCopy() *QuarksSecretStatus {
 func (in *Request) DeepCopyInto(out *Request) {
 	*out = *in
 	in.CertificateRequest.DeepCopyInto(&out.CertificateRequest)
+	out.UserRequest = in.UserRequest
+	out.KeyRequest = in.KeyRequest
+	in.TemplatedConfig.DeepCopyInto(&out.TemplatedConfig)
 	return
 }
 
@@ -162,6 +237,64 @@ func (in *Request) DeepCopy() *Request {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RotationPolicy) DeepCopyInto(out *RotationPolicy) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RotationPolicy.
+func (in *RotationPolicy) DeepCopy() *RotationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RotationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretBackend) DeepCopyInto(out *SecretBackend) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretBackend.
+func (in *SecretBackend) DeepCopy() *SecretBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretCopy) DeepCopyInto(out *SecretCopy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretCopy.
+func (in *SecretCopy) DeepCopy() *SecretCopy {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretCopy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretReference) DeepCopyInto(out *SecretReference) {
 	*out = *in
@@ -193,3 +326,65 @@ func (in *ServiceReference) DeepCopy() *ServiceReference {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplatedConfig) DeepCopyInto(out *TemplatedConfig) {
+	*out = *in
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]TemplatedValueReference, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplatedConfig.
+func (in *TemplatedConfig) DeepCopy() *TemplatedConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplatedConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplatedValueReference) DeepCopyInto(out *TemplatedValueReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplatedValueReference.
+func (in *TemplatedValueReference) DeepCopy() *TemplatedValueReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplatedValueReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserRequest) DeepCopyInto(out *UserRequest) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserRequest.
+func (in *UserRequest) DeepCopy() *UserRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(UserRequest)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -54,6 +54,14 @@ var (
 							Description:            "Defines probes to determine active/passive component instances",
 							XPreserveUnknownFields: pointers.Bool(true),
 						},
+						"rollbackOnFailure": {
+							Type:        "boolean",
+							Description: "Indicate whether to restore the previous version of a StatefulSet when its rollout fails",
+						},
+						"pauseAfterCanary": {
+							Type:        "boolean",
+							Description: "Indicate whether to pause the rollout of a StatefulSet, once its canaries are ready",
+						},
 						"zoneNodeLabel": {
 							Type:        "string",
 							Description: "Indicates the node label that a node locates",
@@ -67,6 +75,18 @@ var (
 								},
 							},
 						},
+						"zoneUpdateStrategy": {
+							Type:        "string",
+							Description: "Indicates whether the zones are updated in parallel, the default, or one at a time",
+							Enum: []extv1.JSON{
+								{
+									Raw: []byte(`"Parallel"`),
+								},
+								{
+									Raw: []byte(`"Serial"`),
+								},
+							},
+						},
 					},
 					Required: []string{
 						"template",
@@ -78,6 +98,29 @@ var (
 						"lastReconcile": {
 							Type: "string",
 						},
+						"failedRevision": {
+							Type: "string",
+						},
+						"rollouts": {
+							Type:        "array",
+							Description: "The rollout of each StatefulSet, one per zone",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type:                   "object",
+									XPreserveUnknownFields: pointers.Bool(true),
+								},
+							},
+						},
+						"rolloutHistory": {
+							Type:        "array",
+							Description: "The finished rollouts, the oldest first",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type:                   "object",
+									XPreserveUnknownFields: pointers.Bool(true),
+								},
+							},
+						},
 					},
 				},
 			},
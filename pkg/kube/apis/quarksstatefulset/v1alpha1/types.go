@@ -32,6 +32,18 @@ var (
 	LabelQStsName = fmt.Sprintf("%s/quarks-statefulset-name", apis.GroupName)
 	// LabelActivePod is the active pod on an active/passive setup
 	LabelActivePod = fmt.Sprintf("%s/pod-active", apis.GroupName)
+	// AnnotationTemplateHash is the hash of the desired StatefulSet, which doesn't change with the version
+	AnnotationTemplateHash = fmt.Sprintf("%s/template-hash", apis.GroupName)
+)
+
+// ZoneUpdateStrategy defines how the StatefulSets of the zones are updated
+type ZoneUpdateStrategy string
+
+const (
+	// ParallelZoneUpdate updates the StatefulSets of all zones at once
+	ParallelZoneUpdate ZoneUpdateStrategy = "Parallel"
+	// SerialZoneUpdate updates the StatefulSet of one zone at a time, once the rollout of the previous zones is done
+	SerialZoneUpdate ZoneUpdateStrategy = "Serial"
 )
 
 // QuarksStatefulSetSpec defines the desired state of QuarksStatefulSet
@@ -45,18 +57,92 @@ type QuarksStatefulSetSpec struct {
 	// Indicates the availability zones that the QuarksStatefulSet needs to span
 	Zones []string `json:"zones,omitempty"`
 
+	// Indicates whether the zones are updated in parallel, the default, or one at a time
+	ZoneUpdateStrategy ZoneUpdateStrategy `json:"zoneUpdateStrategy,omitempty"`
+
 	// Defines a regular StatefulSet template
 	Template appsv1.StatefulSet `json:"template"`
 
 	// Periodic probe for active/passive containers
 	// Only an active container will process request from a service
 	ActivePassiveProbes map[string]corev1.Probe `json:"activePassiveProbes,omitempty"`
+
+	// Indicates whether to restore the previous version of a StatefulSet when its rollout fails
+	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
+
+	// Indicates whether to pause the rollout of a StatefulSet, once its canaries are ready
+	PauseAfterCanary bool `json:"pauseAfterCanary,omitempty"`
 }
 
 // QuarksStatefulSetStatus defines the observed state of QuarksStatefulSet
 type QuarksStatefulSetStatus struct {
 	// Timestamp for the last reconcile
 	LastReconcile *metav1.Time `json:"lastReconcile"`
+
+	// The revision of the last failed rollout, which was rolled back
+	FailedRevision string `json:"failedRevision,omitempty"`
+
+	// The rollout of each StatefulSet, one per zone
+	Rollouts []StatefulSetRolloutStatus `json:"rollouts,omitempty"`
+
+	// The finished rollouts, the oldest first
+	RolloutHistory []RolloutHistoryEntry `json:"rolloutHistory,omitempty"`
+}
+
+// Outcomes and phases of a StatefulSet rollout
+const (
+	RolloutPhaseDone       = "Done"
+	RolloutPhaseFailed     = "Failed"
+	RolloutPhasePaused     = "Paused"
+	RolloutPhaseRolledBack = "RolledBack"
+)
+
+// MaxRolloutHistory is the number of finished rollouts kept in the status
+const MaxRolloutHistory = 10
+
+// StatefulSetRolloutStatus is the rollout state of a StatefulSet owned by the QuarksStatefulSet
+type StatefulSetRolloutStatus struct {
+	// Name of the StatefulSet
+	Name string `json:"name"`
+
+	// The availability zone of the StatefulSet
+	Zone string `json:"zone,omitempty"`
+
+	// The phase of the rollout, e.g. Canary, Rollout, Paused or Done
+	Phase string `json:"phase,omitempty"`
+
+	// Pods with an ordinal greater than or equal to the partition are updated
+	Partition int32 `json:"partition"`
+
+	// The desired number of pods
+	Replicas int32 `json:"replicas"`
+
+	// The number of pods running the update revision
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+
+	// The number of ready pods
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// The revision which is rolled out
+	UpdateRevision string `json:"updateRevision,omitempty"`
+}
+
+// RolloutHistoryEntry describes a finished rollout of a StatefulSet
+type RolloutHistoryEntry struct {
+	// Name of the StatefulSet
+	StatefulSet string `json:"statefulSet"`
+
+	// The revision which was rolled out
+	Revision string `json:"revision,omitempty"`
+
+	// Timestamp for the start of the rollout
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Timestamp for the end of the rollout
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// The outcome of the rollout, i.e. Done, Failed or RolledBack
+	Outcome string `json:"outcome"`
 }
 
 // +genclient
@@ -81,6 +167,34 @@ type QuarksStatefulSetList struct {
 	Items           []QuarksStatefulSet `json:"items"`
 }
 
+// GetRolloutStatus returns the rollout status of the named StatefulSet
+func (s *QuarksStatefulSetStatus) GetRolloutStatus(name string) *StatefulSetRolloutStatus {
+	for i := range s.Rollouts {
+		if s.Rollouts[i].Name == name {
+			return &s.Rollouts[i]
+		}
+	}
+	return nil
+}
+
+// SetRolloutStatus adds or replaces the rollout status of a StatefulSet
+func (s *QuarksStatefulSetStatus) SetRolloutStatus(rollout StatefulSetRolloutStatus) {
+	if existing := s.GetRolloutStatus(rollout.Name); existing != nil {
+		*existing = rollout
+		return
+	}
+	s.Rollouts = append(s.Rollouts, rollout)
+}
+
+// AddRolloutHistory appends a finished rollout to the history, dropping the
+// oldest entries beyond MaxRolloutHistory
+func (s *QuarksStatefulSetStatus) AddRolloutHistory(entry RolloutHistoryEntry) {
+	s.RolloutHistory = append(s.RolloutHistory, entry)
+	if len(s.RolloutHistory) > MaxRolloutHistory {
+		s.RolloutHistory = s.RolloutHistory[len(s.RolloutHistory)-MaxRolloutHistory:]
+	}
+}
+
 // GetMaxAvailableVersion gets the greatest available version owned by the QuarksStatefulSet
 func (q *QuarksStatefulSet) GetMaxAvailableVersion(versions map[int]bool) int {
 	maxAvailableVersion := 0
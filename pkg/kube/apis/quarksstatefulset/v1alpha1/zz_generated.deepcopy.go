@@ -111,6 +111,18 @@ func (in *QuarksStatefulSetStatus) DeepCopyInto(out *QuarksStatefulSetStatus) {
 		in, out := &in.LastReconcile, &out.LastReconcile
 		*out = (*in).DeepCopy()
 	}
+	if in.Rollouts != nil {
+		in, out := &in.Rollouts, &out.Rollouts
+		*out = make([]StatefulSetRolloutStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.RolloutHistory != nil {
+		in, out := &in.RolloutHistory, &out.RolloutHistory
+		*out = make([]RolloutHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -123,3 +135,43 @@ func (in *QuarksStatefulSetStatus) DeepCopy() *QuarksStatefulSetStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutHistoryEntry) DeepCopyInto(out *RolloutHistoryEntry) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutHistoryEntry.
+func (in *RolloutHistoryEntry) DeepCopy() *RolloutHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatefulSetRolloutStatus) DeepCopyInto(out *StatefulSetRolloutStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulSetRolloutStatus.
+func (in *StatefulSetRolloutStatus) DeepCopy() *StatefulSetRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulSetRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -7,12 +7,15 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"code.cloudfoundry.org/cf-operator/pkg/bosh/bpm"
@@ -21,6 +24,7 @@ import (
 	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/desiredmanifest"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/pause"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
@@ -91,9 +95,79 @@ func AddBPM(ctx context.Context, config *config.Config, mgr manager.Manager) err
 		return errors.Wrapf(err, "Watching secrets failed in BPM controller.")
 	}
 
+	// Watch for resumed BOSHDeployments, to apply the BPM secrets which
+	// were skipped while the deployment was paused.
+	bdplPredicates := predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc:  pause.IsResumed,
+	}
+	err = c.Watch(&source.Kind{Type: &bdv1.BOSHDeployment{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			bdpl := a.Object.(*bdv1.BOSHDeployment)
+
+			reconciles, err := latestBPMSecrets(ctx, mgr.GetClient(), bdpl)
+			if err != nil {
+				ctxlog.Errorf(ctx, "Failed to list BPM secrets of BOSHDeployment '%s': %v", bdpl.Name, err)
+			}
+
+			for _, reconciliation := range reconciles {
+				ctxlog.NewMappingEvent(a.Object).Debug(ctx, reconciliation, "BPMSecret", a.Meta.GetName(), "boshdeployment")
+			}
+			return reconciles
+		}),
+	}, bdplPredicates)
+	if err != nil {
+		return errors.Wrapf(err, "Watching BOSHDeployments failed in BPM controller.")
+	}
+
 	return nil
 }
 
+// latestBPMSecrets returns reconcile requests for the latest version of each
+// BPM secret of the BOSHDeployment
+func latestBPMSecrets(ctx context.Context, client crc.Client, bdpl *bdv1.BOSHDeployment) ([]reconcile.Request, error) {
+	secrets := &corev1.SecretList{}
+	err := client.List(ctx, secrets,
+		crc.InNamespace(bdpl.Namespace),
+		crc.MatchingLabels{
+			bdv1.LabelDeploymentName:       bdpl.Name,
+			bdv1.LabelDeploymentSecretType: names.DeploymentSecretBpmInformation.String(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]corev1.Secret{}
+	for _, secret := range secrets.Items {
+		if !isBPMInfoSecret(&secret) {
+			continue
+		}
+		version, err := vss.Version(secret)
+		if err != nil {
+			continue
+		}
+
+		prefix := vss.NamePrefix(secret.Name)
+		if existing, ok := latest[prefix]; ok {
+			if existingVersion, _ := vss.Version(existing); existingVersion >= version {
+				continue
+			}
+		}
+		latest[prefix] = secret
+	}
+
+	reconciles := []reconcile.Request{}
+	for _, secret := range latest {
+		reconciles = append(reconciles, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		})
+	}
+	return reconciles, nil
+}
+
 func isBPMInfoSecret(secret *corev1.Secret) bool {
 	ok := vss.IsVersionedSecret(*secret)
 	if !ok {
@@ -109,17 +109,6 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 		return reconcile.Result{},
 			log.WithEvent(bpmSecret, "GetBOSHDeploymentLabel").Errorf(ctx, "There's no label for a BOSH Deployment name on the Instance Group BPM versioned bpmSecret '%s'", request.NamespacedName)
 	}
-	manifest, err := r.resolver.DesiredManifest(ctx, deploymentName, request.Namespace)
-	if err != nil {
-		return reconcile.Result{},
-			log.WithEvent(bpmSecret, "DesiredManifestReadError").Errorf(ctx, "Failed to read desired manifest '%s': %v", request.NamespacedName, err)
-	}
-
-	dns, err := r.newDNSFunc(deploymentName, *manifest)
-	if err != nil {
-		return reconcile.Result{},
-			log.WithEvent(bpmSecret, "DesiredManifestReadError").Errorf(ctx, "Failed to load BOSH DNS for manifest '%s': %v", request.NamespacedName, err)
-	}
 
 	// Apply BPM information
 	instanceGroupName, ok := bpmSecret.Labels[qjv1a1.LabelRemoteID]
@@ -129,17 +118,38 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 	}
 
 	// Start the instance groups referenced by this BPM secret
-	instanceName, ok := bpmSecret.Labels[bdv1.LabelDeploymentName]
-	if !ok {
+	bdpl := &bdv1.BOSHDeployment{}
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: deploymentName}, bdpl)
+	if err != nil {
 		return reconcile.Result{},
-			log.WithEvent(bpmSecret, "LabelMissingError").Errorf(ctx, "Missing deployment mame label for bpm information bpmSecret '%s'", request.NamespacedName)
+			log.WithEvent(bpmSecret, "GetBOSHDeployment").Errorf(ctx, "Failed to get BoshDeployment instance '%s': %v", deploymentName, err)
 	}
 
-	bdpl := &bdv1.BOSHDeployment{}
-	err = r.client.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: instanceName}, bdpl)
+	if !bdpl.GetDeletionTimestamp().IsZero() {
+		log.Debugf(ctx, "Skip reconcile: BoshDeployment '%s' is being deleted", deploymentName)
+		return reconcile.Result{}, nil
+	}
+
+	if bdpl.IsPaused() {
+		log.WithEvent(bpmSecret, "Paused").Infof(ctx, "Skip reconcile: BoshDeployment '%s' is paused", deploymentName)
+		return reconcile.Result{}, nil
+	}
+
+	manifest, err := r.resolver.DesiredManifest(ctx, deploymentName, request.Namespace)
+	if err != nil {
+		// The error is returned and requeued, even if the status can't be written
+		updateStatus(ctx, r.client, bdpl, func(bdpl *bdv1.BOSHDeployment) error {
+			bdpl.Status.SetCondition(bdv1.DesiredManifestReady, corev1.ConditionFalse, "ReadError", err.Error())
+			return nil
+		})
+		return reconcile.Result{},
+			log.WithEvent(bpmSecret, "DesiredManifestReadError").Errorf(ctx, "Failed to read desired manifest '%s': %v", request.NamespacedName, err)
+	}
+
+	dns, err := r.newDNSFunc(deploymentName, *manifest)
 	if err != nil {
 		return reconcile.Result{},
-			log.WithEvent(bpmSecret, "GetBOSHDeployment").Errorf(ctx, "Failed to get BoshDeployment instance '%s': %v", instanceName, err)
+			log.WithEvent(bpmSecret, "DesiredManifestReadError").Errorf(ctx, "Failed to load BOSH DNS for manifest '%s': %v", request.NamespacedName, err)
 	}
 
 	err = dns.Reconcile(ctx, request.Namespace, r.client, func(object metav1.Object) error {
@@ -151,7 +161,7 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 			log.WithEvent(bpmSecret, "DnsReconcileError").Errorf(ctx, "Failed to reconcile dns: %v", err)
 	}
 
-	resources, err := r.applyBPMResources(bdpl.Name, bpmSecret, manifest, dns)
+	resources, igResolvedSecretVersion, err := r.applyBPMResources(bdpl.Name, bpmSecret, manifest, dns)
 	if err != nil {
 		return reconcile.Result{},
 			log.WithEvent(bpmSecret, "BPMApplyingError").Errorf(ctx, "Failed to apply BPM information: %v", err)
@@ -169,6 +179,11 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 			log.WithEvent(bpmSecret, "InstanceGroupStartError").Errorf(ctx, "Failed to start: %v", err)
 	}
 
+	err = r.updateDeploymentStatus(ctx, bdpl, manifest, instanceGroupName, igResolvedSecretVersion, bpmSecret)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
 	meltdown.SetLastReconcile(&bpmSecret.ObjectMeta, time.Now())
 	err = r.client.Update(ctx, bpmSecret)
 	if err != nil {
@@ -179,26 +194,27 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcileBPM) applyBPMResources(bdplName string, bpmSecret *corev1.Secret, manifest *bdm.Manifest, dns boshdns.DomainNameService) (*bpmconverter.Resources, error) {
+// applyBPMResources returns the resources of the instance group and the version of the ig-resolved secret they are based on
+func (r *ReconcileBPM) applyBPMResources(bdplName string, bpmSecret *corev1.Secret, manifest *bdm.Manifest, dns boshdns.DomainNameService) (*bpmconverter.Resources, string, error) {
 
 	instanceGroupName, ok := bpmSecret.Labels[qjv1a1.LabelRemoteID]
 	if !ok {
-		return nil, errors.Errorf("Missing container label for bpm information secret '%s'", bpmSecret.Name)
+		return nil, "", errors.Errorf("Missing container label for bpm information secret '%s'", bpmSecret.Name)
 	}
 
 	var bpmInfo bdm.BPMInfo
 	if val, ok := bpmSecret.Data["bpm.yaml"]; ok {
 		err := yaml.Unmarshal(val, &bpmInfo)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	} else {
-		return nil, errors.New("Couldn't find bpm.yaml key in manifest secret")
+		return nil, "", errors.New("Couldn't find bpm.yaml key in manifest secret")
 	}
 
 	instanceGroup, found := manifest.InstanceGroups.InstanceGroupByName(instanceGroupName)
 	if !found {
-		return nil, errors.Errorf("instance group '%s' not found", instanceGroupName)
+		return nil, "", errors.Errorf("instance group '%s' not found", instanceGroupName)
 	}
 
 	// Fetch qSts version
@@ -207,30 +223,49 @@ func (r *ReconcileBPM) applyBPMResources(bdplName string, bpmSecret *corev1.Secr
 	err := r.client.Get(r.ctx, types.NamespacedName{Namespace: r.config.Namespace, Name: quarksStatefulSetName}, quarksStatefulSet)
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
-			return nil, errors.Errorf("Failed to get QuarksStatefulSet instance '%s': %v", quarksStatefulSetName, err)
+			return nil, "", errors.Errorf("Failed to get QuarksStatefulSet instance '%s': %v", quarksStatefulSetName, err)
 		}
 	}
 	_, qStsVersion, err := qstscontroller.GetMaxStatefulSetVersion(r.ctx, r.client, quarksStatefulSet)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	qStsVersion = qStsVersion + 1
 	qStsVersionString := strconv.Itoa(qStsVersion)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	igResolvedSecretVersion, err := r.fetchIGresolvedVersion(bdplName, instanceGroupName)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	resources, err := r.converter.Resources(bdplName, dns, qStsVersionString, instanceGroup, manifest, bpmInfo.Configs, igResolvedSecretVersion)
 	if err != nil {
-		return resources, err
+		return resources, igResolvedSecretVersion, err
 	}
 
-	return resources, nil
+	return resources, igResolvedSecretVersion, nil
+}
+
+// updateDeploymentStatus records that the desired manifest has been rendered
+// and the secret versions deployed for the instance group.
+func (r *ReconcileBPM) updateDeploymentStatus(ctx context.Context, bdpl *bdv1.BOSHDeployment, manifest *bdm.Manifest, instanceGroupName string, igResolvedSecretVersion string, bpmSecret *corev1.Secret) error {
+	return updateStatus(ctx, r.client, bdpl, func(bdpl *bdv1.BOSHDeployment) error {
+		bdpl.Status.SetCondition(bdv1.DesiredManifestReady, corev1.ConditionTrue, "Rendered", "desired manifest has been rendered")
+
+		err := updateInstanceGroupsStatus(ctx, r.client, bdpl, manifest)
+		if err != nil {
+			return errors.Wrapf(err, "getting instance group status for '%s'", bdpl.Name)
+		}
+
+		if igStatus := bdpl.Status.GetInstanceGroup(instanceGroupName); igStatus != nil {
+			igStatus.IGResolvedSecretVersion = igResolvedSecretVersion
+			igStatus.BPMSecretVersion = bpmSecret.GetLabels()[versionedsecretstore.LabelVersion]
+		}
+		return nil
+	})
 }
 
 func (r *ReconcileBPM) fetchIGresolvedVersion(manifestName, instanceGroupName string) (string, error) {
@@ -50,6 +50,7 @@ var _ = Describe("ReconcileBPM", func() {
 		log                       *zap.SugaredLogger
 		config                    *cfcfg.Config
 		client                    *fakes.FakeClient
+		statusWriter              *fakes.FakeStatusWriter
 		manifestWithVars          *corev1.Secret
 		bpmInformation            *corev1.Secret
 		bpmInformationNoProcesses *corev1.Secret
@@ -226,6 +227,9 @@ variables: []
 			return nil
 		})
 
+		statusWriter = &fakes.FakeStatusWriter{}
+		client.StatusCalls(func() crc.StatusWriter { return statusWriter })
+
 		manager.GetClientReturns(client)
 
 		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo.bpm.fakepod", Namespace: "default"}}
@@ -334,6 +338,97 @@ variables: []
 				err = client.Get(context.Background(), types.NamespacedName{Name: "foo", Namespace: "default"}, newInstance)
 				Expect(err).ToNot(HaveOccurred())
 			})
+
+			It("skips deploying instance groups while the BOSHDeployment is paused", func() {
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.Secret:
+						if nn.Name == bpmInformation.Name {
+							bpmInformation.DeepCopyInto(object)
+						}
+					case *bdv1.BOSHDeployment:
+						object.Name = "foo"
+						object.Namespace = "default"
+						object.Spec.Paused = true
+					}
+					return nil
+				})
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(kubeConverter.ResourcesCallCount()).To(Equal(0))
+				Expect(client.CreateCallCount()).To(Equal(0))
+				Expect(client.UpdateCallCount()).To(Equal(0))
+				Expect(logs.FilterMessageSnippet("Skip reconcile: BoshDeployment 'foo' is paused").Len()).To(Equal(1))
+			})
+
+			It("sets the DesiredManifestReady condition to false if the desired manifest can't be read", func() {
+				resolver.DesiredManifestReturns(nil, errors.New("fake-error"))
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to read desired manifest"))
+
+				Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+				_, object, _ := statusWriter.UpdateArgsForCall(0)
+				condition := object.(*bdv1.BOSHDeployment).Status.GetCondition(bdv1.DesiredManifestReady)
+				Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+				Expect(condition.Message).To(Equal("fake-error"))
+			})
+
+			It("records the deployed versions in the BOSHDeployment status", func() {
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.Secret:
+						if nn.Name == bpmInformation.Name {
+							bpmInformation.DeepCopyInto(object)
+						}
+						if nn.Name == "foo.ig-resolved.fakepod-v2" {
+							object.Labels = map[string]string{versionedsecretstore.LabelVersion: "2"}
+						}
+					case *bdv1.BOSHDeployment:
+						object.Name = "foo"
+						object.Namespace = "default"
+					}
+					return nil
+				})
+				client.ListCalls(func(context context.Context, object runtime.Object, _ ...crc.ListOption) error {
+					switch object := object.(type) {
+					case *corev1.SecretList:
+						secretList := corev1.SecretList{}
+						secretList.Items = []corev1.Secret{
+							{
+								ObjectMeta: metav1.ObjectMeta{
+									Name:   "foo.ig-resolved.fakepod-v2",
+									Labels: map[string]string{versionedsecretstore.LabelVersion: "2"},
+								},
+							},
+						}
+						secretList.DeepCopyInto(object)
+					}
+					return nil
+				})
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+				_, object, _ := statusWriter.UpdateArgsForCall(0)
+				status := object.(*bdv1.BOSHDeployment).Status
+				Expect(status.GetCondition(bdv1.VariablesGenerated)).To(BeNil())
+				Expect(status.IsConditionTrue(bdv1.DesiredManifestReady)).To(BeTrue())
+				Expect(status.GetCondition(bdv1.InstanceGroupsReady).Status).To(Equal(corev1.ConditionFalse))
+				Expect(status.InstanceGroups).To(Equal([]bdv1.InstanceGroupStatus{
+					{
+						Name:                    "fakepod",
+						DesiredReplicas:         1,
+						ReadyReplicas:           0,
+						IGResolvedSecretVersion: "2",
+						BPMSecretVersion:        "1",
+					},
+				}))
+			})
 		})
 	})
 })
@@ -8,7 +8,10 @@ import (
 	"github.com/pkg/errors"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -18,33 +21,77 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"code.cloudfoundry.org/cf-operator/pkg/bosh/bpm"
+	"code.cloudfoundry.org/cf-operator/pkg/bosh/bpmconverter"
 	"code.cloudfoundry.org/cf-operator/pkg/bosh/converter"
 	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
 	"code.cloudfoundry.org/cf-operator/pkg/bosh/qjobs"
 	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/gitrepo"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/plan"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/reference"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/withops"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 )
 
+// NewPlanner returns a planner, which calculates the changes a BOSHDeployment
+// would apply to the resources in the namespace.
+func NewPlanner(client crc.Client, namespace string) *plan.Planner {
+	return plan.NewPlanner(
+		client,
+		withops.NewResolver(
+			client,
+			func() withops.Interpolator { return withops.NewInterpolator() },
+			func(deploymentName string, m bdm.Manifest) (withops.DomainNameService, error) {
+				return boshdns.NewDNS(deploymentName, m)
+			},
+		),
+		converter.NewVariablesConverter(namespace),
+		bpmconverter.NewConverter(
+			namespace,
+			bpmconverter.NewVolumeFactory(),
+			func(deploymentName string, instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider bdm.ReleaseImageProvider, bpmConfigs bpm.Configs) bpmconverter.ContainerFactory {
+				return bpmconverter.NewContainerFactory(deploymentName, instanceGroupName, version, disableLogSidecar, releaseImageProvider, bpmConfigs)
+			}),
+		func(deploymentName string, m bdm.Manifest) (boshdns.DomainNameService, error) {
+			return boshdns.NewDNS(deploymentName, m)
+		},
+	)
+}
+
 // AddDeployment creates a new BOSHDeployment controller to watch for
 // BOSHDeployment manifest custom resources and start the rendering, which will
 // finally produce the "desired manifest", the instance group manifests and the BPM configs.
 func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manager) error {
 	ctx = ctxlog.NewContextWithRecorder(ctx, "boshdeployment-reconciler", mgr.GetEventRecorderFor("boshdeployment-recorder"))
+
+	// References to config maps and secrets in shared namespaces are read
+	// from a separate cache
+	sharedCache, err := crossnamespace.NewCache(mgr)
+	if err != nil {
+		return errors.Wrap(err, "Adding Bosh deployment controller to manager failed.")
+	}
+	client := mgr.GetClient()
+	if sharedCache != nil {
+		client = crossnamespace.NewClient(client, sharedCache)
+	}
+
+	resolver := withops.NewResolver(
+		client,
+		func() withops.Interpolator { return withops.NewInterpolator() },
+		func(deploymentName string, m bdm.Manifest) (withops.DomainNameService, error) {
+			return boshdns.NewDNS(deploymentName, m)
+		},
+	)
 	r := NewDeploymentReconciler(
 		ctx, config, mgr,
-		withops.NewResolver(
-			mgr.GetClient(),
-			func() withops.Interpolator { return withops.NewInterpolator() },
-			func(deploymentName string, m bdm.Manifest) (withops.DomainNameService, error) {
-				return boshdns.NewDNS(deploymentName, m)
-			},
-		),
+		resolver,
 		qjobs.NewJobFactory(config.Namespace),
 		converter.NewVariablesConverter(config.Namespace),
+		NewPlanner(client, config.Namespace),
 		controllerutil.SetControllerReference,
 	)
 
@@ -71,7 +118,10 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			o := e.ObjectOld.(*bdv1.BOSHDeployment)
 			n := e.ObjectNew.(*bdv1.BOSHDeployment)
-			if !reflect.DeepEqual(o.Spec, n.Spec) {
+			if !reflect.DeepEqual(o.Spec, n.Spec) ||
+				o.IsDryRun() != n.IsDryRun() ||
+				o.IsPaused() != n.IsPaused() ||
+				(o.DeletionTimestamp == nil && n.DeletionTimestamp != nil) {
 				ctxlog.NewPredicateEvent(e.ObjectNew).Debug(
 					ctx, e.MetaNew, "bdv1.BOSHDeployment",
 					fmt.Sprintf("Update predicate passed for '%s'", e.MetaNew.GetName()),
@@ -86,7 +136,7 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 		return errors.Wrapf(err, "Watching bosh deployment failed in bosh deployment controller.")
 	}
 
-	// Watch ConfigMaps referenced by the BOSHDeployment
+	// Watch ConfigMaps referenced by the BOSHDeployment, also in shared namespaces
 	configMapPredicates := predicate.Funcs{
 		CreateFunc:  func(e event.CreateEvent) bool { return true },
 		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
@@ -98,15 +148,15 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 			return !reflect.DeepEqual(oldConfigMap.Data, newConfigMap.Data)
 		},
 	}
-	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+	configMapHandler := &handler.EnqueueRequestsFromMapFunc{
 		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
 			config := a.Object.(*corev1.ConfigMap)
 
-			if reference.SkipReconciles(ctx, mgr.GetClient(), config) {
+			if reference.SkipReconciles(ctx, client, config) {
 				return []reconcile.Request{}
 			}
 
-			reconciles, err := reference.GetReconciles(ctx, mgr.GetClient(), reference.ReconcileForBOSHDeployment, config, false)
+			reconciles, err := reference.GetReconciles(ctx, client, reference.ReconcileForBOSHDeployment, config, false)
 			if err != nil {
 				ctxlog.Errorf(ctx, "Failed to calculate reconciles for config '%s': %v", config.Name, err)
 			}
@@ -117,16 +167,17 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 
 			return reconciles
 		}),
-	}, configMapPredicates)
+	}
+	err = watch(c, sharedCache, &corev1.ConfigMap{}, configMapHandler, configMapPredicates)
 	if err != nil {
 		return errors.Wrapf(err, "Watching configmaps failed in bosh deployment controller.")
 	}
 
-	// Watch Secrets referenced by the BOSHDeployment
+	// Watch Secrets referenced by the BOSHDeployment, also in shared namespaces
 	secretPredicates := predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
 			secret := e.Object.(*corev1.Secret)
-			reconciles, err := reference.GetReconciles(ctx, mgr.GetClient(), reference.ReconcileForBOSHDeployment, secret, false)
+			reconciles, err := reference.GetReconciles(ctx, client, reference.ReconcileForBOSHDeployment, secret, false)
 			if err != nil {
 				ctxlog.Errorf(ctx, "Failed to calculate reconciles for secret '%s': %v", secret.Name, err)
 			}
@@ -143,15 +194,15 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 			return !reflect.DeepEqual(oldSecret.Data, newSecret.Data)
 		},
 	}
-	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+	secretHandler := &handler.EnqueueRequestsFromMapFunc{
 		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
 			secret := a.Object.(*corev1.Secret)
 
-			if reference.SkipReconciles(ctx, mgr.GetClient(), secret) {
+			if reference.SkipReconciles(ctx, client, secret) {
 				return []reconcile.Request{}
 			}
 
-			reconciles, err := reference.GetReconciles(ctx, mgr.GetClient(), reference.ReconcileForBOSHDeployment, secret, false)
+			reconciles, err := reference.GetReconciles(ctx, client, reference.ReconcileForBOSHDeployment, secret, false)
 			if err != nil {
 				ctxlog.Errorf(ctx, "Failed to calculate reconciles for secret '%s': %v", secret.Name, err)
 			}
@@ -162,7 +213,8 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 
 			return reconciles
 		}),
-	}, secretPredicates)
+	}
+	err = watch(c, sharedCache, &corev1.Secret{}, secretHandler, secretPredicates)
 	if err != nil {
 		return errors.Wrapf(err, "Watching secrets failed in bosh deployment controller.")
 
@@ -207,5 +259,32 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 
 	}
 
+	// Poll git references and reconcile, when they move to a new commit
+	poller := NewGitPoller(ctx, client, config.Namespace, gitrepo.Default, GitPollInterval)
+	err = mgr.Add(poller)
+	if err != nil {
+		return errors.Wrapf(err, "adding git poller failed in bosh deployment controller.")
+	}
+	err = c.Watch(&source.Channel{Source: poller.Events()}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return errors.Wrapf(err, "watching git references failed in bosh deployment controller.")
+	}
+
 	return nil
 }
+
+// watch adds a watch for the type to the controller. If a cache for shared
+// namespaces is given, the type is watched in those namespaces, too.
+func watch(c controller.Controller, sharedCache cache.Cache, obj runtime.Object, h handler.EventHandler, p predicate.Predicate) error {
+	err := c.Watch(&source.Kind{Type: obj}, h, p)
+	if err != nil || sharedCache == nil {
+		return err
+	}
+
+	shared := &source.Kind{Type: obj.DeepCopyObject()}
+	err = shared.InjectCache(sharedCache)
+	if err != nil {
+		return err
+	}
+	return c.Watch(shared, h, p)
+}
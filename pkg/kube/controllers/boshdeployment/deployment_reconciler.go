@@ -24,6 +24,7 @@ import (
 	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/mutate"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/plan"
 	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
@@ -45,6 +46,12 @@ type VariablesConverter interface {
 // WithOps interpolates BOSH manifests and operations files to create the WithOps manifest
 type WithOps interface {
 	Manifest(instance *bdv1.BOSHDeployment, namespace string) (*bdm.Manifest, []string, error)
+	ManifestWithGitReferences(instance *bdv1.BOSHDeployment, namespace string) (*bdm.Manifest, []bdv1.GitReferenceStatus, error)
+}
+
+// Planner calculates the changes of a BOSHDeployment without applying them
+type Planner interface {
+	Plan(ctx context.Context, bdpl *bdv1.BOSHDeployment) (*plan.Plan, error)
 }
 
 // Check that ReconcileBOSHDeployment implements the reconcile.Reconciler interface
@@ -53,7 +60,7 @@ var _ reconcile.Reconciler = &ReconcileBOSHDeployment{}
 type setReferenceFunc func(owner, object metav1.Object, scheme *runtime.Scheme) error
 
 // NewDeploymentReconciler returns a new reconcile.Reconciler
-func NewDeploymentReconciler(ctx context.Context, config *config.Config, mgr manager.Manager, withops WithOps, jobFactory JobFactory, converter VariablesConverter, srf setReferenceFunc) reconcile.Reconciler {
+func NewDeploymentReconciler(ctx context.Context, config *config.Config, mgr manager.Manager, withops WithOps, jobFactory JobFactory, converter VariablesConverter, planner Planner, srf setReferenceFunc) reconcile.Reconciler {
 
 	return &ReconcileBOSHDeployment{
 		ctx:          ctx,
@@ -64,6 +71,7 @@ func NewDeploymentReconciler(ctx context.Context, config *config.Config, mgr man
 		setReference: srf,
 		jobFactory:   jobFactory,
 		converter:    converter,
+		planner:      planner,
 	}
 }
 
@@ -77,6 +85,7 @@ type ReconcileBOSHDeployment struct {
 	setReference setReferenceFunc
 	jobFactory   JobFactory
 	converter    VariablesConverter
+	planner      Planner
 }
 
 // Reconcile starts the deployment process for a BOSHDeployment and deploys QuarksJobs to generate required properties for instance groups and rendered BPM
@@ -103,14 +112,38 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 			log.WithEvent(instance, "GetBOSHDeploymentError").Errorf(ctx, "failed to get BOSHDeployment '%s': %v", request.NamespacedName, err)
 	}
 
+	if !instance.GetDeletionTimestamp().IsZero() {
+		return r.teardown(ctx, instance)
+	}
+
+	err = r.addFinalizer(ctx, instance)
+	if err != nil {
+		return reconcile.Result{},
+			log.WithEvent(instance, "AddFinalizerError").Errorf(ctx, "failed to add finalizer to BOSHDeployment '%s': %v", request.NamespacedName, err)
+	}
+
+	if instance.IsPaused() {
+		return reconcile.Result{}, r.pause(ctx, instance)
+	}
+	r.resume(ctx, instance)
+
 	if meltdown.NewWindow(r.config.MeltdownDuration, instance.Status.LastReconcile).Contains(time.Now()) {
 		log.WithEvent(instance, "Meltdown").Debugf(ctx, "Resource '%s' is in meltdown, requeue reconcile after %s", instance.Name, r.config.MeltdownRequeueAfter)
 		return reconcile.Result{RequeueAfter: r.config.MeltdownRequeueAfter}, nil
 	}
 
+	if instance.IsDryRun() {
+		return reconcile.Result{}, r.dryRun(ctx, instance)
+	}
+
 	// Resolve the manifest with ops
-	manifest, err := r.resolveManifest(ctx, instance)
+	manifest, gitReferences, err := r.resolveManifest(ctx, instance)
 	if err != nil {
+		// The error is returned and requeued, even if the status can't be written
+		updateStatus(ctx, r.client, instance, func(bdpl *bdv1.BOSHDeployment) error {
+			bdpl.Status.SetCondition(bdv1.ManifestResolved, corev1.ConditionFalse, "ResolveError", err.Error())
+			return nil
+		})
 		return reconcile.Result{},
 			log.WithEvent(instance, "WithOpsManifestError").Errorf(ctx, "failed to get with-ops manifest for BOSHDeployment '%s': %v", request.NamespacedName, err)
 	}
@@ -176,28 +209,119 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 			log.WithEvent(instance, "InstanceGroupManifestError").Errorf(ctx, "failed to create instance group manifest qJob for BOSHDeployment '%s': %v", request.NamespacedName, err)
 	}
 
-	// Update status of bdpl with the timestamp of the last reconcile
+	// Update status of bdpl with the results and the timestamp of the last
+	// reconcile, the status changes are applied again after a conflict
 	now := metav1.Now()
-	instance.Status.LastReconcile = &now
+	err = updateStatus(ctx, r.client, instance, func(bdpl *bdv1.BOSHDeployment) error {
+		resumeStatus(bdpl)
+		bdpl.Status.SetCondition(bdv1.ManifestResolved, corev1.ConditionTrue, "Resolved", "manifest and ops files resolved")
+		bdpl.Status.GitReferences = gitReferences
+
+		err := setVariablesCondition(ctx, r.client, bdpl, secrets)
+		if err != nil {
+			return errors.Wrapf(err, "checking variables for BOSH manifest '%s'", bdpl.Name)
+		}
+
+		// The BPM reconciler sets the condition once the desired manifest has been rendered
+		if bdpl.Status.GetCondition(bdv1.DesiredManifestReady) == nil {
+			bdpl.Status.SetCondition(bdv1.DesiredManifestReady, corev1.ConditionFalse, "Pending", "waiting for the variable interpolation job")
+		}
+
+		err = updateInstanceGroupsStatus(ctx, r.client, bdpl, manifest)
+		if err != nil {
+			return errors.Wrapf(err, "getting instance group status for BOSHDeployment '%s'", bdpl.Name)
+		}
 
-	err = r.client.Status().Update(ctx, instance)
+		bdpl.Status.LastReconcile = &now
+		return nil
+	})
+	return reconcile.Result{}, err
+}
+
+// resolveManifest resolves manifest with ops manifest and returns the
+// commits of its git references
+func (r *ReconcileBOSHDeployment) resolveManifest(ctx context.Context, instance *bdv1.BOSHDeployment) (*bdm.Manifest, []bdv1.GitReferenceStatus, error) {
+	log.Debug(ctx, "Resolving manifest")
+	manifest, gitReferences, err := r.withops.ManifestWithGitReferences(instance, instance.GetNamespace())
 	if err != nil {
-		log.WithEvent(instance, "UpdateError").Errorf(ctx, "failed to update reconcile timestamp on bdpl '%s' (%v): %s", instance.Name, instance.ResourceVersion, err)
-		return reconcile.Result{Requeue: false}, nil
+		return nil, nil, log.WithEvent(instance, "WithOpsManifestError").Errorf(ctx, "Error resolving the manifest %s: %s", instance.GetName(), err)
 	}
 
-	return reconcile.Result{}, nil
+	return manifest, gitReferences, nil
 }
 
-// resolveManifest resolves manifest with ops manifest
-func (r *ReconcileBOSHDeployment) resolveManifest(ctx context.Context, instance *bdv1.BOSHDeployment) (*bdm.Manifest, error) {
-	log.Debug(ctx, "Resolving manifest")
-	manifest, _, err := r.withops.Manifest(instance, instance.GetNamespace())
+// pause marks the BOSHDeployment as paused. Changes are not applied until
+// the deployment is resumed.
+func (r *ReconcileBOSHDeployment) pause(ctx context.Context, instance *bdv1.BOSHDeployment) error {
+	if instance.Status.IsConditionTrue(bdv1.Paused) {
+		log.Debugf(ctx, "Skip reconcile: BOSHDeployment '%s/%s' is paused", instance.Namespace, instance.Name)
+		return nil
+	}
+
+	log.WithEvent(instance, "Paused").Infof(ctx, "Reconciliation of BOSHDeployment '%s/%s' is paused", instance.Namespace, instance.Name)
+	return updateStatus(ctx, r.client, instance, func(bdpl *bdv1.BOSHDeployment) error {
+		bdpl.Status.SetCondition(bdv1.Paused, corev1.ConditionTrue, "Paused", "reconciliation is paused")
+		return nil
+	})
+}
+
+// resume logs that a BOSHDeployment, which was paused before, is resumed.
+// The paused condition is cleared by resumeStatus, when the status is
+// written at the end of the reconcile.
+func (r *ReconcileBOSHDeployment) resume(ctx context.Context, instance *bdv1.BOSHDeployment) {
+	if !instance.Status.IsConditionTrue(bdv1.Paused) {
+		return
+	}
+
+	log.WithEvent(instance, "Resumed").Infof(ctx, "Reconciliation of BOSHDeployment '%s/%s' is resumed", instance.Namespace, instance.Name)
+}
+
+// resumeStatus clears the paused condition of a BOSHDeployment, which was
+// paused before
+func resumeStatus(instance *bdv1.BOSHDeployment) {
+	if !instance.Status.IsConditionTrue(bdv1.Paused) {
+		return
+	}
+	instance.Status.SetCondition(bdv1.Paused, corev1.ConditionFalse, "Resumed", "reconciliation has been resumed")
+}
+
+// dryRun writes the planned changes to a config map, instead of applying them
+func (r *ReconcileBOSHDeployment) dryRun(ctx context.Context, instance *bdv1.BOSHDeployment) error {
+	log.Debug(ctx, "Planning changes for dry-run")
+	p, err := r.planner.Plan(ctx, instance)
+	if err != nil {
+		return log.WithEvent(instance, "PlanError").Errorf(ctx, "failed to plan changes for BOSHDeployment '%s/%s': %v", instance.Namespace, instance.Name, err)
+	}
+
+	planBytes, err := p.Marshal()
 	if err != nil {
-		return nil, log.WithEvent(instance, "WithOpsManifestError").Errorf(ctx, "Error resolving the manifest %s: %s", instance.GetName(), err)
+		return log.WithEvent(instance, "PlanError").Errorf(ctx, "failed to marshal plan for BOSHDeployment '%s/%s': %v", instance.Namespace, instance.Name, err)
 	}
 
-	return manifest, nil
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.PlanConfigMapName(),
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				bdv1.LabelDeploymentName: instance.Name,
+			},
+		},
+		Data: map[string]string{
+			"plan.yaml": string(planBytes),
+		},
+	}
+
+	if err := r.setReference(instance, configMap, r.scheme); err != nil {
+		return log.WithEvent(instance, "PlanError").Errorf(ctx, "failed to set ownerReference for ConfigMap '%s': %v", configMap.Name, err)
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.client, configMap, mutate.ConfigMapMutateFn(configMap))
+	if err != nil {
+		return log.WithEvent(instance, "PlanError").Errorf(ctx, "failed to apply ConfigMap '%s': %v", configMap.Name, err)
+	}
+
+	log.WithEvent(instance, "DryRun").Infof(ctx, "Planned changes for BOSHDeployment '%s/%s' (%s) are in config map '%s'", instance.Namespace, instance.Name, p.Summary(), configMap.Name)
+	return nil
 }
 
 // createManifestWithOps creates a secret containing the deployment manifest with ops files applied
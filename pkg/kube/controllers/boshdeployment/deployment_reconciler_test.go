@@ -11,6 +11,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,16 +24,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"code.cloudfoundry.org/cf-operator/pkg/bosh/bpmconverter"
 	"code.cloudfoundry.org/cf-operator/pkg/bosh/converter"
 	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers"
 	cfd "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/boshdeployment"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/fakes"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/plan"
 	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
 	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
 	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
 )
 
@@ -46,6 +52,8 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 		withops        fakes.FakeWithOps
 		jobFactory     fakes.FakeJobFactory
 		kubeConverter  fakes.FakeVariablesConverter
+		bpmConverter   fakes.FakeBPMConverter
+		planner        cfd.Planner
 		manifest       *bdm.Manifest
 		log            *zap.SugaredLogger
 		config         *cfcfg.Config
@@ -66,6 +74,8 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 		jobFactory = fakes.FakeJobFactory{}
 		kubeConverter = fakes.FakeVariablesConverter{}
 		kubeConverter.VariablesReturns([]qsv1a1.QuarksSecret{}, nil)
+		bpmConverter = fakes.FakeBPMConverter{}
+		bpmConverter.ResourcesReturns(&bpmconverter.Resources{}, nil)
 
 		deploymentName = "foo"
 
@@ -175,9 +185,15 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 
 	JustBeforeEach(func() {
 		withops.ManifestReturns(manifest, []string{}, nil)
+		withops.ManifestWithGitReferencesReturns(manifest, nil, nil)
+		planner = plan.NewPlanner(client, &withops, &kubeConverter, &bpmConverter,
+			func(name string, m bdm.Manifest) (boshdns.DomainNameService, error) {
+				return boshdns.NewSimpleDomainNameService(name), nil
+			},
+		)
 		reconciler = cfd.NewDeploymentReconciler(
 			ctx, config, manager,
-			&withops, &jobFactory, &kubeConverter,
+			&withops, &jobFactory, &kubeConverter, planner,
 			controllerutil.SetControllerReference,
 		)
 	})
@@ -205,7 +221,7 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 			})
 
 			It("handles an error when resolving the BOSHDeployment", func() {
-				withops.ManifestReturns(nil, []string{}, fmt.Errorf("resolver error"))
+				withops.ManifestWithGitReferencesReturns(nil, nil, fmt.Errorf("resolver error"))
 
 				_, err := reconciler.Reconcile(request)
 				Expect(err).To(HaveOccurred())
@@ -214,12 +230,28 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 				// check for events
 				Expect(<-recorder.Events).To(ContainSubstring("WithOpsManifestError"))
 			})
+
+			It("sets the ManifestResolved condition to false", func() {
+				statusWriter := &fakes.FakeStatusWriter{}
+				client.StatusCalls(func() crc.StatusWriter { return statusWriter })
+				withops.ManifestWithGitReferencesReturns(nil, nil, fmt.Errorf("resolver error"))
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).To(HaveOccurred())
+
+				Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+				_, object, _ := statusWriter.UpdateArgsForCall(0)
+				status := object.(*bdv1.BOSHDeployment).Status
+				Expect(status.GetCondition(bdv1.ManifestResolved).Status).To(Equal(corev1.ConditionFalse))
+				Expect(status.GetCondition(bdv1.ManifestResolved).Message).To(ContainSubstring("resolver error"))
+				Expect(status.IsConditionTrue(bdv1.Ready)).To(BeFalse())
+			})
 		})
 
 		Context("when the manifest can be resolved", func() {
 			It("handles an error when resolving manifest", func() {
 				manifest = &bdm.Manifest{}
-				withops.ManifestReturns(manifest, []string{}, errors.New("fake-error"))
+				withops.ManifestWithGitReferencesReturns(manifest, nil, errors.New("fake-error"))
 
 				_, err := reconciler.Reconcile(request)
 				Expect(err).To(HaveOccurred())
@@ -227,7 +259,7 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 			})
 
 			It("handles an error when setting the owner reference on the object", func() {
-				reconciler = cfd.NewDeploymentReconciler(ctx, config, manager, &withops, &jobFactory, &kubeConverter,
+				reconciler = cfd.NewDeploymentReconciler(ctx, config, manager, &withops, &jobFactory, &kubeConverter, planner,
 					func(owner, object metav1.Object, scheme *runtime.Scheme) error {
 						return fmt.Errorf("some error")
 					},
@@ -370,6 +402,114 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 				Expect(err.Error()).To(ContainSubstring("failed to create instance group manifest qJob for BOSHDeployment 'default/foo': creating or updating QuarksJob 'ig-foo': fake-error"))
 			})
 
+			Context("when updating the status", func() {
+				var statusWriter *fakes.FakeStatusWriter
+
+				BeforeEach(func() {
+					statusWriter = &fakes.FakeStatusWriter{}
+					client.StatusCalls(func() crc.StatusWriter { return statusWriter })
+
+					manifest.InstanceGroups[0].Instances = 2
+					client.ListCalls(func(context context.Context, object runtime.Object, _ ...crc.ListOption) error {
+						switch object := object.(type) {
+						case *appsv1.StatefulSetList:
+							list := appsv1.StatefulSetList{
+								Items: []appsv1.StatefulSet{
+									{
+										Spec:   appsv1.StatefulSetSpec{Replicas: pointers.Int32(2)},
+										Status: appsv1.StatefulSetStatus{ReadyReplicas: 1},
+									},
+								},
+							}
+							list.DeepCopyInto(object)
+						}
+						return nil
+					})
+				})
+
+				It("sets the conditions and instance group replicas", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+					_, object, _ := statusWriter.UpdateArgsForCall(0)
+					status := object.(*bdv1.BOSHDeployment).Status
+					Expect(status.IsConditionTrue(bdv1.ManifestResolved)).To(BeTrue())
+					Expect(status.IsConditionTrue(bdv1.VariablesGenerated)).To(BeTrue())
+					Expect(status.GetCondition(bdv1.DesiredManifestReady).Status).To(Equal(corev1.ConditionFalse))
+					Expect(status.GetCondition(bdv1.InstanceGroupsReady).Status).To(Equal(corev1.ConditionFalse))
+					Expect(status.GetCondition(bdv1.InstanceGroupsReady).Message).To(ContainSubstring("fakepod (1/2)"))
+					Expect(status.IsConditionTrue(bdv1.Ready)).To(BeFalse())
+					Expect(status.InstanceGroups).To(Equal([]bdv1.InstanceGroupStatus{
+						{Name: "fakepod", DesiredReplicas: 2, ReadyReplicas: 1},
+					}))
+				})
+
+				It("returns an error to requeue the reconcile, if the status can't be written", func() {
+					statusWriter.UpdateReturns(errors.New("fake-error"))
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("failed to update status on bdpl 'foo'"))
+				})
+
+				It("writes the status again on the latest version after a conflict", func() {
+					statusWriter.UpdateReturnsOnCall(0, apierrors.NewConflict(schema.GroupResource{}, "foo", errors.New("object was modified")))
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(statusWriter.UpdateCallCount()).To(Equal(2))
+					_, object, _ := statusWriter.UpdateArgsForCall(1)
+					status := object.(*bdv1.BOSHDeployment).Status
+					Expect(status.IsConditionTrue(bdv1.ManifestResolved)).To(BeTrue())
+					Expect(status.LastReconcile).ToNot(BeNil())
+				})
+
+				It("records the commits of the git references", func() {
+					gitReferences := []bdv1.GitReferenceStatus{
+						{Repository: "https://example.com/repo.git", Ref: "main", Path: "manifest.yml", Commit: "abc123"},
+					}
+					withops.ManifestWithGitReferencesReturns(manifest, gitReferences, nil)
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, object, _ := statusWriter.UpdateArgsForCall(0)
+					Expect(object.(*bdv1.BOSHDeployment).Status.GitReferences).To(Equal(gitReferences))
+				})
+
+				It("sets the VariablesGenerated condition to false while secrets are missing", func() {
+					kubeConverter.VariablesReturns([]qsv1a1.QuarksSecret{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "fake-variable", Namespace: "default"},
+							Spec:       qsv1a1.QuarksSecretSpec{SecretName: "fake-variable"},
+						},
+					}, nil)
+					client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+						switch object := object.(type) {
+						case *bdv1.BOSHDeployment:
+							instance.DeepCopyInto(object)
+						case *qjv1a1.QuarksJob:
+							return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+						case *corev1.Secret:
+							if nn.Name == "fake-variable" {
+								return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+							}
+						}
+						return nil
+					})
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, object, _ := statusWriter.UpdateArgsForCall(0)
+					condition := object.(*bdv1.BOSHDeployment).Status.GetCondition(bdv1.VariablesGenerated)
+					Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+					Expect(condition.Message).To(Equal("waiting for secrets: fake-variable"))
+				})
+			})
+
 			Context("when the manifest contains variables", func() {
 				BeforeEach(func() {
 					kubeConverter.VariablesReturns([]qsv1a1.QuarksSecret{
@@ -534,5 +674,279 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 				})
 			})
 		})
+
+		Context("when the BOSHDeployment is created", func() {
+			It("adds the finalizer", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(client.UpdateCallCount()).To(BeNumerically(">", 0))
+				_, object, _ := client.UpdateArgsForCall(0)
+				Expect(object.(*bdv1.BOSHDeployment).GetFinalizers()).To(ContainElement(bdv1.Finalizer))
+			})
+		})
+
+		Context("when the BOSHDeployment is a dry-run", func() {
+			var configMap *corev1.ConfigMap
+
+			BeforeEach(func() {
+				instance.Spec.DryRun = true
+				configMap = nil
+
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *bdv1.BOSHDeployment:
+						instance.DeepCopyInto(object)
+						return nil
+					}
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+				client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+					switch object := object.(type) {
+					case *corev1.ConfigMap:
+						configMap = object
+					}
+					return nil
+				})
+				bpmConverter.ResourcesReturns(&bpmconverter.Resources{
+					InstanceGroups: []qstsv1a1.QuarksStatefulSet{
+						{ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod"}},
+					},
+				}, nil)
+			})
+
+			It("writes the plan to a config map instead of applying the manifest", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(configMap).ToNot(BeNil())
+				Expect(configMap.Name).To(Equal("foo.plan"))
+				Expect(configMap.Data["plan.yaml"]).To(ContainSubstring("name: foo-fakepod"))
+				Expect(configMap.Data["plan.yaml"]).To(ContainSubstring("action: create"))
+
+				Expect(jobFactory.VariableInterpolationJobCallCount()).To(Equal(0))
+				Expect(jobFactory.InstanceGroupManifestJobCallCount()).To(Equal(0))
+				Expect(<-recorder.Events).To(ContainSubstring("1 to create, 0 to update, 0 to delete"))
+			})
+
+			It("is enabled by the annotation", func() {
+				instance.Spec.DryRun = false
+				instance.Annotations = map[string]string{bdv1.AnnotationDryRun: "true"}
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(configMap).ToNot(BeNil())
+				Expect(jobFactory.VariableInterpolationJobCallCount()).To(Equal(0))
+			})
+
+			It("handles an error when planning", func() {
+				withops.ManifestReturns(nil, []string{}, errors.New("fake-error"))
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to plan changes for BOSHDeployment 'default/foo'"))
+				Expect(configMap).To(BeNil())
+			})
+		})
+
+		Context("when the BOSHDeployment is paused", func() {
+			var statusWriter *fakes.FakeStatusWriter
+
+			BeforeEach(func() {
+				instance.Spec.Paused = true
+				statusWriter = &fakes.FakeStatusWriter{}
+				client.StatusCalls(func() crc.StatusWriter { return statusWriter })
+			})
+
+			It("doesn't apply the manifest and sets the Paused condition", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(withops.ManifestWithGitReferencesCallCount()).To(Equal(0))
+				Expect(jobFactory.VariableInterpolationJobCallCount()).To(Equal(0))
+				Expect(jobFactory.InstanceGroupManifestJobCallCount()).To(Equal(0))
+
+				Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+				_, object, _ := statusWriter.UpdateArgsForCall(0)
+				status := object.(*bdv1.BOSHDeployment).Status
+				Expect(status.IsConditionTrue(bdv1.Paused)).To(BeTrue())
+				Expect(<-recorder.Events).To(ContainSubstring("Paused"))
+			})
+
+			It("is enabled by the annotation", func() {
+				instance.Spec.Paused = false
+				instance.Annotations = map[string]string{bdv1.AnnotationPaused: "true"}
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(withops.ManifestWithGitReferencesCallCount()).To(Equal(0))
+			})
+
+			It("doesn't update the status again while paused", func() {
+				instance.Status.SetCondition(bdv1.Paused, corev1.ConditionTrue, "Paused", "reconciliation is paused")
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(statusWriter.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("applies the manifest once it's resumed", func() {
+				instance.Spec.Paused = false
+				instance.Status.SetCondition(bdv1.Paused, corev1.ConditionTrue, "Paused", "reconciliation is paused")
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobFactory.VariableInterpolationJobCallCount()).To(Equal(1))
+				Expect(jobFactory.InstanceGroupManifestJobCallCount()).To(Equal(1))
+
+				Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+				_, object, _ := statusWriter.UpdateArgsForCall(0)
+				status := object.(*bdv1.BOSHDeployment).Status
+				Expect(status.GetCondition(bdv1.Paused).Status).To(Equal(corev1.ConditionFalse))
+				Expect(status.GetCondition(bdv1.Paused).Reason).To(Equal("Resumed"))
+				Expect(<-recorder.Events).To(ContainSubstring("Resumed"))
+			})
+
+			It("still tears down the instance groups on deletion", func() {
+				now := metav1.Now()
+				instance.DeletionTimestamp = &now
+				instance.Finalizers = []string{bdv1.Finalizer}
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(client.UpdateCallCount()).To(Equal(1))
+				_, object, _ := client.UpdateArgsForCall(0)
+				Expect(object.(*bdv1.BOSHDeployment).Finalizers).To(BeEmpty())
+			})
+		})
+
+		Context("when the BOSHDeployment is deleted", func() {
+			var (
+				podsByIG    map[string]int
+				deletedPVCs []string
+				deleted     []string
+			)
+
+			BeforeEach(func() {
+				now := metav1.Now()
+				instance.DeletionTimestamp = &now
+				instance.Finalizers = []string{bdv1.Finalizer}
+
+				withOpsManifest := `---
+name: foo
+instance_groups:
+- name: db
+  instances: 1
+- name: api
+  instances: 1
+- name: worker
+  instances: 1
+  update:
+    serial: false
+- name: smoke-tests
+  instances: 1
+  lifecycle: errand
+`
+				podsByIG = map[string]int{}
+				deleted = []string{}
+				deletedPVCs = []string{}
+
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *bdv1.BOSHDeployment:
+						instance.DeepCopyInto(object)
+					case *corev1.Secret:
+						if nn.Name == "foo.with-ops" {
+							object.Data = map[string][]byte{"manifest.yaml": []byte(withOpsManifest)}
+						}
+					}
+					return nil
+				})
+				client.ListCalls(func(context context.Context, object runtime.Object, opts ...crc.ListOption) error {
+					var ig string
+					for _, opt := range opts {
+						if labels, ok := opt.(crc.MatchingLabels); ok {
+							ig = labels[bdm.LabelInstanceGroupName]
+						}
+					}
+
+					switch object := object.(type) {
+					case *corev1.PodList:
+						for i := 0; i < podsByIG[ig]; i++ {
+							object.Items = append(object.Items, corev1.Pod{})
+						}
+					case *corev1.PersistentVolumeClaimList:
+						object.Items = []corev1.PersistentVolumeClaim{
+							{ObjectMeta: metav1.ObjectMeta{Name: ig + "-pvc", Namespace: "default"}},
+						}
+					}
+					return nil
+				})
+				client.DeleteCalls(func(context context.Context, object runtime.Object, _ ...crc.DeleteOption) error {
+					switch object := object.(type) {
+					case *qstsv1a1.QuarksStatefulSet:
+						deleted = append(deleted, object.Name)
+					case *corev1.PersistentVolumeClaim:
+						deletedPVCs = append(deletedPVCs, object.Name)
+					}
+					return nil
+				})
+			})
+
+			It("waits for the pods of the last instance groups to terminate", func() {
+				podsByIG["worker"] = 1
+
+				result, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+
+				Expect(deleted).To(Equal([]string{"foo-api", "foo-worker"}))
+				Expect(deletedPVCs).To(BeEmpty())
+				Expect(client.UpdateCallCount()).To(Equal(0))
+				Expect(withops.ManifestWithGitReferencesCallCount()).To(Equal(0))
+			})
+
+			It("tears down the instance groups in reverse order and removes the finalizer", func() {
+				result, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{}))
+
+				Expect(deleted).To(Equal([]string{"foo-api", "foo-worker", "foo-db"}))
+				Expect(deletedPVCs).To(BeEmpty())
+
+				Expect(client.UpdateCallCount()).To(Equal(1))
+				_, object, _ := client.UpdateArgsForCall(0)
+				Expect(object.(*bdv1.BOSHDeployment).GetFinalizers()).ToNot(ContainElement(bdv1.Finalizer))
+			})
+
+			It("deletes the PVCs if requested by the annotation", func() {
+				instance.Annotations = map[string]string{bdv1.AnnotationPVCPolicy: bdv1.PVCPolicyDelete}
+				podsByIG["db"] = 1
+
+				result, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+				Expect(deletedPVCs).To(Equal([]string{"api-pvc", "worker-pvc"}))
+			})
+
+			It("removes the finalizer if the with-ops manifest is gone", func() {
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *bdv1.BOSHDeployment:
+						instance.DeepCopyInto(object)
+					case *corev1.Secret:
+						return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+					}
+					return nil
+				})
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deleted).To(BeEmpty())
+				Expect(client.UpdateCallCount()).To(Equal(1))
+			})
+		})
 	})
 })
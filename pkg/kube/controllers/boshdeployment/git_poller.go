@@ -0,0 +1,134 @@
+package boshdeployment
+
+import (
+	"context"
+	"time"
+
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/gitrepo"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/withops"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// GitPollInterval is the interval in which the git references of
+// BOSHDeployments are checked for new commits
+const GitPollInterval = time.Minute
+
+// GitPoller periodically checks the git references of all BOSHDeployments
+// and emits an event for each deployment, whose refs moved to a new commit
+type GitPoller struct {
+	ctx       context.Context
+	client    crc.Client
+	namespace string
+	git       *gitrepo.Repositories
+	interval  time.Duration
+	events    chan event.GenericEvent
+}
+
+// NewGitPoller returns a new git poller for the BOSHDeployments in namespace
+func NewGitPoller(ctx context.Context, client crc.Client, namespace string, git *gitrepo.Repositories, interval time.Duration) *GitPoller {
+	return &GitPoller{
+		ctx:       ctx,
+		client:    client,
+		namespace: namespace,
+		git:       git,
+		interval:  interval,
+		events:    make(chan event.GenericEvent),
+	}
+}
+
+// Events returns the channel, which receives the BOSHDeployments to reconcile
+func (p *GitPoller) Events() <-chan event.GenericEvent {
+	return p.events
+}
+
+// Start polls until the stop channel is closed, it implements manager.Runnable
+func (p *GitPoller) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			for _, bdpl := range p.Poll() {
+				select {
+				case p.events <- event.GenericEvent{Meta: bdpl, Object: bdpl}:
+				case <-stop:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// Poll returns the BOSHDeployments, whose git references point to a
+// different commit than the one recorded in their status
+func (p *GitPoller) Poll() []*bdv1.BOSHDeployment {
+	bdpls := &bdv1.BOSHDeploymentList{}
+	err := p.client.List(p.ctx, bdpls, crc.InNamespace(p.namespace))
+	if err != nil {
+		log.Errorf(p.ctx, "Failed to list BOSHDeployments for git polling: %v", err)
+		return nil
+	}
+
+	moved := []*bdv1.BOSHDeployment{}
+	for i := range bdpls.Items {
+		bdpl := &bdpls.Items[i]
+		if bdpl.DeletionTimestamp != nil || bdpl.IsPaused() {
+			continue
+		}
+		if p.moved(bdpl) {
+			moved = append(moved, bdpl)
+		}
+	}
+	return moved
+}
+
+// moved checks the remote commit of each git reference, which was resolved
+// before and does not point to a fixed commit
+func (p *GitPoller) moved(bdpl *bdv1.BOSHDeployment) bool {
+	for _, ref := range bdpl.Spec.References() {
+		if ref.Type != bdv1.GitReference || gitrepo.IsCommit(ref.Ref) {
+			continue
+		}
+
+		status := gitReferenceStatus(bdpl, ref)
+		if status == nil {
+			continue
+		}
+
+		creds, err := withops.GitCredentials(p.ctx, p.client, bdpl.Namespace, ref)
+		if err != nil {
+			log.Errorf(p.ctx, "Failed to poll git repository '%s' of BOSHDeployment '%s/%s': %v", ref.Name, bdpl.Namespace, bdpl.Name, err)
+			continue
+		}
+
+		commit, err := p.git.RemoteCommit(p.ctx, ref.Name, ref.Ref, creds)
+		if err != nil {
+			log.Errorf(p.ctx, "Failed to poll git repository '%s' of BOSHDeployment '%s/%s': %v", ref.Name, bdpl.Namespace, bdpl.Name, err)
+			continue
+		}
+
+		if commit != status.Commit {
+			log.Infof(p.ctx, "Git reference '%s' of BOSHDeployment '%s/%s' moved from %s to %s", ref.Name, bdpl.Namespace, bdpl.Name, status.Commit, commit)
+			return true
+		}
+	}
+	return false
+}
+
+// gitReferenceStatus returns the recorded status of a git reference, or nil
+// if it has not been resolved yet
+func gitReferenceStatus(bdpl *bdv1.BOSHDeployment, ref bdv1.ResourceReference) *bdv1.GitReferenceStatus {
+	for i, status := range bdpl.Status.GitReferences {
+		if status.Repository == ref.Name && status.Ref == ref.Ref && status.Path == ref.Path {
+			return &bdpl.Status.GitReferences[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package boshdeployment_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/boshdeployment"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/gitrepo"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("GitPoller", func() {
+	var (
+		ctx        context.Context
+		tmpDir     string
+		repository string
+		work       string
+		resolved   string
+		bdpl       *bdv1.BOSHDeployment
+		poller     *boshdeployment.GitPoller
+	)
+
+	git := func(dir string, args ...string) string {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		Expect(err).ToNot(HaveOccurred(), string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	commit := func(content string) string {
+		Expect(ioutil.WriteFile(filepath.Join(work, "manifest.yml"), []byte(content), 0644)).To(Succeed())
+		git(work, "add", "-A")
+		git(work, "commit", "--quiet", "-m", "update manifest")
+		git(work, "push", "--quiet", "origin", "HEAD:main")
+		return git(work, "rev-parse", "HEAD")
+	}
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		ctx = ctxlog.NewParentContext(log)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "git-poller")
+		Expect(err).ToNot(HaveOccurred())
+
+		repository = filepath.Join(tmpDir, "remote.git")
+		work = filepath.Join(tmpDir, "work")
+		git(tmpDir, "init", "--quiet", "--bare", "--initial-branch=main", repository)
+		git(tmpDir, "clone", "--quiet", repository, work)
+		resolved = commit("name: foo\n")
+
+		bdpl = &bdv1.BOSHDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+			Spec: bdv1.BOSHDeploymentSpec{
+				Manifest: bdv1.ResourceReference{
+					Type: bdv1.GitReference,
+					Name: repository,
+					Ref:  "main",
+					Path: "manifest.yml",
+				},
+			},
+			Status: bdv1.BOSHDeploymentStatus{
+				GitReferences: []bdv1.GitReferenceStatus{
+					{Repository: repository, Ref: "main", Path: "manifest.yml", Commit: resolved},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(bdv1.AddToScheme(scheme)).To(Succeed())
+		client := fake.NewFakeClientWithScheme(scheme, bdpl)
+		poller = boshdeployment.NewGitPoller(ctx, client, "default", gitrepo.NewRepositories(filepath.Join(tmpDir, "cache")), 10*time.Millisecond)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("ignores deployments whose refs did not move", func() {
+		Expect(poller.Poll()).To(BeEmpty())
+	})
+
+	Context("when the ref moved", func() {
+		BeforeEach(func() {
+			commit("name: bar\n")
+		})
+
+		It("returns the deployment", func() {
+			moved := poller.Poll()
+			Expect(moved).To(HaveLen(1))
+			Expect(moved[0].Name).To(Equal("foo"))
+		})
+
+		It("sends an event for the deployment", func() {
+			stop := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				Expect(poller.Start(stop)).To(Succeed())
+			}()
+
+			Eventually(poller.Events()).Should(Receive())
+			close(stop)
+			Eventually(done).Should(BeClosed())
+		})
+
+		Context("when the deployment is paused", func() {
+			BeforeEach(func() {
+				bdpl.Spec.Paused = true
+			})
+
+			It("ignores the deployment", func() {
+				Expect(poller.Poll()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when the reference points to a commit", func() {
+		BeforeEach(func() {
+			commit("name: bar\n")
+			bdpl.Spec.Manifest.Ref = resolved
+			bdpl.Status.GitReferences[0].Ref = resolved
+		})
+
+		It("does not poll the repository", func() {
+			Expect(poller.Poll()).To(BeEmpty())
+		})
+	})
+
+	Context("when the reference has not been resolved yet", func() {
+		BeforeEach(func() {
+			bdpl.Status.GitReferences = nil
+		})
+
+		It("ignores the deployment", func() {
+			Expect(poller.Poll()).To(BeEmpty())
+		})
+	})
+})
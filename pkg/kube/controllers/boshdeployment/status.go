@@ -0,0 +1,183 @@
+package boshdeployment
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+
+	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// setVariablesCondition sets the VariablesGenerated condition, depending on
+// whether the secrets for all explicit variables exist
+func setVariablesCondition(ctx context.Context, client crc.Client, instance *bdv1.BOSHDeployment, variables []qsv1a1.QuarksSecret) error {
+	missing := []string{}
+	for _, v := range variables {
+		secret := &corev1.Secret{}
+		err := client.Get(ctx, types.NamespacedName{Namespace: v.Namespace, Name: v.Spec.SecretName}, secret)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				missing = append(missing, v.Spec.SecretName)
+				continue
+			}
+			return errors.Wrapf(err, "getting secret '%s' for explicit variable", v.Spec.SecretName)
+		}
+	}
+
+	if len(missing) > 0 {
+		instance.Status.SetCondition(bdv1.VariablesGenerated, corev1.ConditionFalse, "Pending",
+			fmt.Sprintf("waiting for secrets: %s", strings.Join(missing, ", ")))
+		return nil
+	}
+
+	instance.Status.SetCondition(bdv1.VariablesGenerated, corev1.ConditionTrue, "Generated",
+		fmt.Sprintf("%d explicit variables generated", len(variables)))
+	return nil
+}
+
+// updateInstanceGroupsStatus refreshes the replica counts of all instance
+// groups in the manifest and sets the InstanceGroupsReady condition.
+// Secret versions recorded previously are kept.
+func updateInstanceGroupsStatus(ctx context.Context, client crc.Client, instance *bdv1.BOSHDeployment, manifest *bdm.Manifest) error {
+	igStatus := []bdv1.InstanceGroupStatus{}
+
+	for _, ig := range manifest.InstanceGroups {
+		if ig.LifeCycle == bdm.IGTypeErrand || ig.LifeCycle == bdm.IGTypeAutoErrand {
+			continue
+		}
+
+		status := bdv1.InstanceGroupStatus{Name: ig.Name}
+		if existing := instance.Status.GetInstanceGroup(ig.Name); existing != nil {
+			status = *existing
+		}
+
+		desired, ready, found, err := instanceGroupReplicas(ctx, client, instance, ig.Name)
+		if err != nil {
+			return err
+		}
+		if !found {
+			// The StatefulSets, one for each zone, don't exist yet
+			zones := len(ig.AZs)
+			if zones == 0 {
+				zones = 1
+			}
+			desired = int32(ig.Instances * zones)
+		}
+		status.DesiredReplicas = desired
+		status.ReadyReplicas = ready
+		igStatus = append(igStatus, status)
+	}
+	instance.Status.InstanceGroups = igStatus
+
+	setInstanceGroupsCondition(instance)
+	return nil
+}
+
+// refreshInstanceGroupsStatus refreshes the replica counts of the instance
+// groups recorded in the status from their StatefulSets, without resolving
+// the manifest again
+func refreshInstanceGroupsStatus(ctx context.Context, client crc.Client, instance *bdv1.BOSHDeployment) error {
+	for i := range instance.Status.InstanceGroups {
+		status := &instance.Status.InstanceGroups[i]
+
+		desired, ready, found, err := instanceGroupReplicas(ctx, client, instance, status.Name)
+		if err != nil {
+			return err
+		}
+		if found {
+			status.DesiredReplicas = desired
+		}
+		status.ReadyReplicas = ready
+	}
+
+	setInstanceGroupsCondition(instance)
+	return nil
+}
+
+// setInstanceGroupsCondition sets the InstanceGroupsReady condition from the
+// replica counts of the instance groups
+func setInstanceGroupsCondition(instance *bdv1.BOSHDeployment) {
+	notReady := []string{}
+	for _, status := range instance.Status.InstanceGroups {
+		if !status.IsReady() {
+			notReady = append(notReady, fmt.Sprintf("%s (%d/%d)", status.Name, status.ReadyReplicas, status.DesiredReplicas))
+		}
+	}
+
+	if len(notReady) > 0 {
+		instance.Status.SetCondition(bdv1.InstanceGroupsReady, corev1.ConditionFalse, "NotReady",
+			fmt.Sprintf("instance groups not ready: %s", strings.Join(notReady, ", ")))
+		return
+	}
+
+	instance.Status.SetCondition(bdv1.InstanceGroupsReady, corev1.ConditionTrue, "Ready",
+		fmt.Sprintf("%d instance groups ready", len(instance.Status.InstanceGroups)))
+}
+
+// instanceGroupReplicas returns the desired and ready replicas of an instance
+// group, summed up over its StatefulSets, one for each zone. It returns false
+// if there are no StatefulSets for the instance group.
+func instanceGroupReplicas(ctx context.Context, client crc.Client, instance *bdv1.BOSHDeployment, igName string) (int32, int32, bool, error) {
+	statefulSets := &appsv1.StatefulSetList{}
+	err := client.List(ctx, statefulSets, crc.InNamespace(instance.Namespace), crc.MatchingLabels{
+		bdm.LabelDeploymentName:    instance.Name,
+		bdm.LabelInstanceGroupName: igName,
+	})
+	if err != nil {
+		return 0, 0, false, errors.Wrapf(err, "listing statefulsets of instance group '%s'", igName)
+	}
+
+	var desired, ready int32
+	for _, sts := range statefulSets.Items {
+		if sts.Spec.Replicas != nil {
+			desired += *sts.Spec.Replicas
+		}
+		ready += sts.Status.ReadyReplicas
+	}
+
+	return desired, ready, len(statefulSets.Items) > 0, nil
+}
+
+// updateStatus applies the changes to the status of the BOSHDeployment and
+// writes it, unless it's unchanged. The deployment, BPM and status
+// reconcilers all write the status, so on a conflict the BOSHDeployment is
+// fetched again and the changes are applied to the latest version.
+func updateStatus(ctx context.Context, client crc.Client, instance *bdv1.BOSHDeployment, apply func(*bdv1.BOSHDeployment) error) error {
+	refetch := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if refetch {
+			err := client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, instance)
+			if err != nil {
+				return errors.Wrap(err, "getting latest version")
+			}
+		}
+		refetch = true
+
+		old := instance.Status.DeepCopy()
+		err := apply(instance)
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(old, &instance.Status) {
+			return nil
+		}
+		return client.Status().Update(ctx, instance)
+	})
+	if err != nil {
+		return log.WithEvent(instance, "UpdateError").Errorf(ctx, "failed to update status on bdpl '%s' (%v): %s", instance.Name, instance.ResourceVersion, err)
+	}
+	return nil
+}
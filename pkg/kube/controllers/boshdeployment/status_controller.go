@@ -0,0 +1,75 @@
+package boshdeployment
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// AddDeploymentStatus creates a new controller, which keeps the readiness of
+// the instance groups in the BOSHDeployment status up to date. It doesn't
+// run the deployment pipeline, so rollouts don't trigger a full reconcile.
+func AddDeploymentStatus(ctx context.Context, config *config.Config, mgr manager.Manager) error {
+	ctx = ctxlog.NewContextWithRecorder(ctx, "boshdeployment-status-reconciler", mgr.GetEventRecorderFor("boshdeployment-recorder"))
+	r := NewStatusReconciler(ctx, config, mgr)
+
+	// Create a new controller
+	c, err := controller.New("boshdeployment-status-controller", mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: config.MaxBoshDeploymentWorkers,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Adding BOSHDeployment status controller to manager failed.")
+	}
+
+	// Watch StatefulSets of instance groups, when their readiness changes
+	statefulSetPredicates := predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldStatefulSet := e.ObjectOld.(*appsv1.StatefulSet)
+			newStatefulSet := e.ObjectNew.(*appsv1.StatefulSet)
+
+			if _, ok := newStatefulSet.GetLabels()[bdm.LabelDeploymentName]; !ok {
+				return false
+			}
+
+			return oldStatefulSet.Status.ReadyReplicas != newStatefulSet.Status.ReadyReplicas ||
+				!reflect.DeepEqual(oldStatefulSet.Spec.Replicas, newStatefulSet.Spec.Replicas)
+		},
+	}
+	err = c.Watch(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			request := reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: a.Meta.GetNamespace(),
+					Name:      a.Meta.GetLabels()[bdm.LabelDeploymentName],
+				},
+			}
+			ctxlog.NewMappingEvent(a.Object).Debug(ctx, request, "BOSHDeployment", a.Meta.GetName(), "StatefulSetOfInstanceGroup")
+
+			return []reconcile.Request{request}
+		}),
+	}, statefulSetPredicates)
+	if err != nil {
+		return errors.Wrapf(err, "watching statefulsets failed in bosh deployment status controller.")
+	}
+
+	return nil
+}
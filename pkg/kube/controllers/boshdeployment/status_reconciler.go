@@ -0,0 +1,62 @@
+package boshdeployment
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// NewStatusReconciler returns a new reconcile.Reconciler for the instance group status of BOSHDeployments
+func NewStatusReconciler(ctx context.Context, config *config.Config, mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileStatus{
+		ctx:    ctx,
+		config: config,
+		client: mgr.GetClient(),
+	}
+}
+
+// ReconcileStatus reconciles the instance group status of a BOSHDeployment
+type ReconcileStatus struct {
+	ctx    context.Context
+	config *config.Config
+	client client.Client
+}
+
+// Reconcile refreshes the replica counts of the instance groups in the
+// status of the BOSHDeployment from their StatefulSets
+func (r *ReconcileStatus) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	instance := &bdv1.BOSHDeployment{}
+
+	// Set the ctx to be Background, as the top-level context for incoming requests.
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.CtxTimeOut)
+	defer cancel()
+
+	log.Debugf(ctx, "Reconciling status of BOSHDeployment %s", request.NamespacedName)
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Debug(ctx, "Skip reconcile: BOSHDeployment not found")
+			return reconcile.Result{}, nil
+		}
+
+		return reconcile.Result{},
+			log.WithEvent(instance, "GetBOSHDeploymentError").Errorf(ctx, "failed to get BOSHDeployment '%s': %v", request.NamespacedName, err)
+	}
+
+	if !instance.GetDeletionTimestamp().IsZero() {
+		log.Debugf(ctx, "Skip reconcile: BOSHDeployment '%s' is being deleted", request.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
+	err = updateStatus(ctx, r.client, instance, func(bdpl *bdv1.BOSHDeployment) error {
+		return refreshInstanceGroupsStatus(ctx, r.client, bdpl)
+	})
+	return reconcile.Result{}, err
+}
@@ -0,0 +1,177 @@
+package boshdeployment_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers"
+	cfd "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/boshdeployment"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/fakes"
+	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("ReconcileStatus", func() {
+	var (
+		manager    *fakes.FakeManager
+		reconciler reconcile.Reconciler
+		request    reconcile.Request
+		client     crc.Client
+		instance   *bdv1.BOSHDeployment
+	)
+
+	statefulSet := func(name string, replicas, ready int32) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					bdm.LabelDeploymentName:    "foo",
+					bdm.LabelInstanceGroupName: "nats",
+				},
+			},
+			Spec:   appsv1.StatefulSetSpec{Replicas: pointers.Int32(replicas)},
+			Status: appsv1.StatefulSetStatus{ReadyReplicas: ready},
+		}
+	}
+
+	BeforeEach(func() {
+		controllers.AddToScheme(scheme.Scheme)
+		manager = &fakes.FakeManager{}
+		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo", Namespace: "default"}}
+
+		instance = &bdv1.BOSHDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+			Status: bdv1.BOSHDeploymentStatus{
+				InstanceGroups: []bdv1.InstanceGroupStatus{
+					{Name: "nats", DesiredReplicas: 4, ReadyReplicas: 1, BPMSecretVersion: "2"},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		manager.GetClientReturns(client)
+		_, log := helper.NewTestLogger()
+		ctx := ctxlog.NewParentContext(log)
+		reconciler = cfd.NewStatusReconciler(ctx, &cfcfg.Config{CtxTimeOut: 10 * time.Second}, manager)
+	})
+
+	Context("when the StatefulSets of an instance group become ready", func() {
+		BeforeEach(func() {
+			client = fake.NewFakeClient(instance, statefulSet("foo-nats-z0", 2, 2), statefulSet("foo-nats-z1", 2, 2))
+		})
+
+		It("refreshes the instance group status", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			bdpl := &bdv1.BOSHDeployment{}
+			Expect(client.Get(context.Background(), request.NamespacedName, bdpl)).To(Succeed())
+			Expect(bdpl.Status.InstanceGroups).To(Equal([]bdv1.InstanceGroupStatus{
+				{Name: "nats", DesiredReplicas: 4, ReadyReplicas: 4, BPMSecretVersion: "2"},
+			}))
+			Expect(bdpl.Status.IsConditionTrue(bdv1.InstanceGroupsReady)).To(BeTrue())
+		})
+	})
+
+	Context("when the StatefulSets of an instance group are not ready", func() {
+		BeforeEach(func() {
+			client = fake.NewFakeClient(instance, statefulSet("foo-nats-z0", 2, 1), statefulSet("foo-nats-z1", 2, 0))
+		})
+
+		It("sets the InstanceGroupsReady condition to false", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			bdpl := &bdv1.BOSHDeployment{}
+			Expect(client.Get(context.Background(), request.NamespacedName, bdpl)).To(Succeed())
+			condition := bdpl.Status.GetCondition(bdv1.InstanceGroupsReady)
+			Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			Expect(condition.Message).To(ContainSubstring("nats (1/4)"))
+		})
+	})
+
+	Context("when another reconciler wrote the status concurrently", func() {
+		BeforeEach(func() {
+			c := fake.NewFakeClient(instance, statefulSet("foo-nats-z0", 2, 2), statefulSet("foo-nats-z1", 2, 2))
+			client = &conflictingClient{
+				Client: c,
+				conflict: func() {
+					bdpl := &bdv1.BOSHDeployment{}
+					Expect(c.Get(context.Background(), request.NamespacedName, bdpl)).To(Succeed())
+					bdpl.Status.InstanceGroups[0].BPMSecretVersion = "3"
+					Expect(c.Status().Update(context.Background(), bdpl)).To(Succeed())
+				},
+			}
+		})
+
+		It("applies the changes to the latest version", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			bdpl := &bdv1.BOSHDeployment{}
+			Expect(client.Get(context.Background(), request.NamespacedName, bdpl)).To(Succeed())
+			Expect(bdpl.Status.InstanceGroups).To(Equal([]bdv1.InstanceGroupStatus{
+				{Name: "nats", DesiredReplicas: 4, ReadyReplicas: 4, BPMSecretVersion: "3"},
+			}))
+		})
+	})
+
+	Context("when the BOSHDeployment doesn't exist", func() {
+		BeforeEach(func() {
+			client = fake.NewFakeClient()
+		})
+
+		It("skips the reconcile", func() {
+			result, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+		})
+	})
+})
+
+// conflictingClient fails the first status update with a conflict, after
+// calling conflict to change the BOSHDeployment concurrently
+type conflictingClient struct {
+	crc.Client
+	conflict func()
+}
+
+func (c *conflictingClient) Status() crc.StatusWriter {
+	return &conflictingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type conflictingStatusWriter struct {
+	crc.StatusWriter
+	client *conflictingClient
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...crc.UpdateOption) error {
+	if w.client.conflict != nil {
+		w.client.conflict()
+		w.client.conflict = nil
+		return apierrors.NewConflict(schema.GroupResource{}, "foo", errors.New("object was modified"))
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
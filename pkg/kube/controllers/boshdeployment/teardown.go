@@ -0,0 +1,211 @@
+package boshdeployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+// teardownRequeueAfter is the interval used to check if the pods of an instance group are gone
+const teardownRequeueAfter = 5 * time.Second
+
+// hasFinalizer returns true if the BOSHDeployment has our finalizer
+func hasFinalizer(instance *bdv1.BOSHDeployment) bool {
+	for _, f := range instance.GetFinalizers() {
+		if f == bdv1.Finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// addFinalizer adds our finalizer to the BOSHDeployment, if it's missing
+func (r *ReconcileBOSHDeployment) addFinalizer(ctx context.Context, instance *bdv1.BOSHDeployment) error {
+	if hasFinalizer(instance) {
+		return nil
+	}
+
+	controllerutil.AddFinalizer(instance, bdv1.Finalizer)
+	err := r.client.Update(ctx, instance)
+	if err != nil {
+		return errors.Wrapf(err, "adding finalizer to BOSHDeployment '%s/%s'", instance.Namespace, instance.Name)
+	}
+	return nil
+}
+
+// teardown deletes the instance groups of a BOSHDeployment in the reverse
+// order of their deployment. It waits for all pods of an instance group,
+// including their drain scripts, to terminate before continuing with the next
+// one. Once all instance groups are gone the finalizer is removed and the
+// remaining resources are garbage collected.
+func (r *ReconcileBOSHDeployment) teardown(ctx context.Context, instance *bdv1.BOSHDeployment) (reconcile.Result, error) {
+	if !hasFinalizer(instance) {
+		log.Debugf(ctx, "Skip reconcile: BOSHDeployment '%s/%s' is being deleted", instance.Namespace, instance.Name)
+		return reconcile.Result{}, nil
+	}
+
+	manifest, err := r.withOpsManifest(ctx, instance)
+	if err != nil {
+		return reconcile.Result{},
+			log.WithEvent(instance, "TeardownError").Errorf(ctx, "failed to read with-ops manifest for BOSHDeployment '%s/%s': %v", instance.Namespace, instance.Name, err)
+	}
+
+	if manifest != nil {
+		for _, stage := range teardownStages(manifest) {
+			pending, err := r.teardownInstanceGroups(ctx, instance, stage)
+			if err != nil {
+				return reconcile.Result{},
+					log.WithEvent(instance, "TeardownError").Errorf(ctx, "failed to tear down BOSHDeployment '%s/%s': %v", instance.Namespace, instance.Name, err)
+			}
+
+			if len(pending) > 0 {
+				msg := fmt.Sprintf("waiting for pods of instance groups to terminate: %s", strings.Join(pending, ", "))
+				log.WithEvent(instance, "Teardown").Infof(ctx, "BOSHDeployment '%s/%s' is %s", instance.Namespace, instance.Name, msg)
+				err = updateStatus(ctx, r.client, instance, func(bdpl *bdv1.BOSHDeployment) error {
+					bdpl.Status.SetCondition(bdv1.Ready, corev1.ConditionFalse, "Terminating", msg)
+					return nil
+				})
+				return reconcile.Result{RequeueAfter: teardownRequeueAfter}, err
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, bdv1.Finalizer)
+	err = r.client.Update(ctx, instance)
+	if err != nil {
+		return reconcile.Result{},
+			log.WithEvent(instance, "TeardownError").Errorf(ctx, "failed to remove finalizer from BOSHDeployment '%s/%s': %v", instance.Namespace, instance.Name, err)
+	}
+
+	log.Infof(ctx, "Instance groups of BOSHDeployment '%s/%s' have been torn down", instance.Namespace, instance.Name)
+	return reconcile.Result{}, nil
+}
+
+// withOpsManifest reads the with-ops manifest from its secret. The manifest
+// can't be resolved again, as the referenced config maps and secrets might
+// have been deleted already. Returns nil if the secret doesn't exist.
+func (r *ReconcileBOSHDeployment) withOpsManifest(ctx context.Context, instance *bdv1.BOSHDeployment) (*bdm.Manifest, error) {
+	secretName := names.DeploymentSecretName(names.DeploymentSecretTypeManifestWithOps, instance.Name, "")
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: secretName}, secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "getting secret '%s'", secretName)
+	}
+
+	data, ok := secret.Data["manifest.yaml"]
+	if !ok {
+		return nil, nil
+	}
+
+	return bdm.LoadYAML(data)
+}
+
+// teardownStages groups the instance groups into the stages they are
+// deployed in and returns the stages in reverse order. Instance groups with
+// 'update.serial: false' are deployed in parallel to the previous instance
+// group. Errands are skipped, they are cleaned up by garbage collection.
+func teardownStages(manifest *bdm.Manifest) [][]*bdm.InstanceGroup {
+	stages := [][]*bdm.InstanceGroup{}
+	for _, ig := range manifest.InstanceGroups {
+		if ig.LifeCycle == bdm.IGTypeErrand || ig.LifeCycle == bdm.IGTypeAutoErrand {
+			continue
+		}
+
+		serial := true
+		if ig.Update != nil && ig.Update.Serial != nil {
+			serial = *ig.Update.Serial
+		} else if manifest.Update != nil && manifest.Update.Serial != nil {
+			serial = *manifest.Update.Serial
+		}
+
+		if serial || len(stages) == 0 {
+			stages = append(stages, []*bdm.InstanceGroup{ig})
+			continue
+		}
+		stages[len(stages)-1] = append(stages[len(stages)-1], ig)
+	}
+
+	for i, j := 0, len(stages)-1; i < j; i, j = i+1, j-1 {
+		stages[i], stages[j] = stages[j], stages[i]
+	}
+	return stages
+}
+
+// teardownInstanceGroups deletes the QuarksStatefulSets of the given instance
+// groups and returns the names of the instance groups, which still have pods.
+// Once all pods are gone, the PVCs are deleted if requested by the PVC policy
+// annotation.
+func (r *ReconcileBOSHDeployment) teardownInstanceGroups(ctx context.Context, instance *bdv1.BOSHDeployment, instanceGroups []*bdm.InstanceGroup) ([]string, error) {
+	pending := []string{}
+	for _, ig := range instanceGroups {
+		qsts := &qstsv1a1.QuarksStatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ig.QuarksStatefulSetName(instance.Name),
+				Namespace: instance.Namespace,
+			},
+		}
+		err := r.client.Delete(ctx, qsts, crc.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !apierrors.IsNotFound(err) {
+			return pending, errors.Wrapf(err, "deleting QuarksStatefulSet '%s'", qsts.Name)
+		}
+
+		pods := &corev1.PodList{}
+		err = r.client.List(ctx, pods, crc.InNamespace(instance.Namespace), instanceGroupLabels(instance, ig))
+		if err != nil {
+			return pending, errors.Wrapf(err, "listing pods of instance group '%s'", ig.Name)
+		}
+		if len(pods.Items) > 0 {
+			pending = append(pending, ig.Name)
+		}
+	}
+
+	if len(pending) > 0 || instance.GetAnnotations()[bdv1.AnnotationPVCPolicy] != bdv1.PVCPolicyDelete {
+		return pending, nil
+	}
+
+	for _, ig := range instanceGroups {
+		pvcs := &corev1.PersistentVolumeClaimList{}
+		err := r.client.List(ctx, pvcs, crc.InNamespace(instance.Namespace), instanceGroupLabels(instance, ig))
+		if err != nil {
+			return pending, errors.Wrapf(err, "listing PVCs of instance group '%s'", ig.Name)
+		}
+
+		for i := range pvcs.Items {
+			log.Debugf(ctx, "Deleting PVC '%s/%s'", pvcs.Items[i].Namespace, pvcs.Items[i].Name)
+			err = r.client.Delete(ctx, &pvcs.Items[i])
+			if err != nil && !apierrors.IsNotFound(err) {
+				return pending, errors.Wrapf(err, "deleting PVC '%s'", pvcs.Items[i].Name)
+			}
+		}
+	}
+
+	return pending, nil
+}
+
+// instanceGroupLabels returns the labels which select the pods and PVCs of an instance group
+func instanceGroupLabels(instance *bdv1.BOSHDeployment, ig *bdm.InstanceGroup) crc.MatchingLabels {
+	return crc.MatchingLabels{
+		bdm.LabelDeploymentName:    instance.Name,
+		bdm.LabelInstanceGroupName: ig.Name,
+	}
+}
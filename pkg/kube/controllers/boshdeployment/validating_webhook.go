@@ -21,6 +21,7 @@ import (
 	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/statefulset"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
 	wh "code.cloudfoundry.org/cf-operator/pkg/kube/util/webhook"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/withops"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
@@ -68,6 +69,7 @@ type Validator struct {
 	log          *zap.SugaredLogger
 	config       *config.Config
 	client       client.Client
+	apiReader    client.Reader
 	decoder      *admission.Decoder
 	pollTimeout  time.Duration
 	pollInterval time.Duration
@@ -87,9 +89,10 @@ func NewValidator(log *zap.SugaredLogger, config *config.Config) admission.Handl
 }
 
 // OpsResourcesExist verify if a resource exist in the namespace,
-// it will check its existence during 5 seconds,
+// or the namespace of the reference, it will check its existence during 5 seconds,
 // otherwise it will timeout.
 func (v *Validator) OpsResourcesExist(ctx context.Context, specOpsResource []bdv1.ResourceReference, ns string) (bool, string) {
+	c := v.referenceClient()
 	timeOut := time.After(v.pollTimeout)
 	tick := time.NewTicker(v.pollInterval)
 	defer tick.Stop()
@@ -97,8 +100,8 @@ func (v *Validator) OpsResourcesExist(ctx context.Context, specOpsResource []bdv
 	missingResources := map[string]bool{}
 
 	for {
-		configMaps := &corev1.ConfigMapList{}
-		secrets := &corev1.SecretList{}
+		configMaps := map[string]*corev1.ConfigMapList{}
+		secrets := map[string]*corev1.SecretList{}
 
 		select {
 		case <-timeOut:
@@ -110,28 +113,41 @@ func (v *Validator) OpsResourcesExist(ctx context.Context, specOpsResource []bdv
 			}
 			return false, fmt.Sprintf("Timeout reached. Resources '%s' do not exist", strings.Join(missingResourcesNames, " "))
 		case <-tick.C:
-			// List all configmaps
-			err := v.client.List(ctx, configMaps, client.InNamespace(ns))
-			if err != nil {
-				return false, fmt.Sprintf("error listing configMaps in namespace '%s': %v", ns, err)
-			}
+			for _, ref := range specOpsResource {
+				namespace := crossnamespace.Namespace(ns, ref)
+				if _, ok := configMaps[namespace]; ok {
+					continue
+				}
 
-			// List all secrets
-			err = v.client.List(ctx, secrets, client.InNamespace(ns))
-			if err != nil {
-				return false, fmt.Sprintf("error listing secrets in namespace '%s': %v", ns, err)
+				// List all configmaps
+				configMaps[namespace] = &corev1.ConfigMapList{}
+				err := c.List(ctx, configMaps[namespace], client.InNamespace(namespace))
+				if err != nil {
+					return false, fmt.Sprintf("error listing configMaps in namespace '%s': %v", namespace, err)
+				}
+
+				// List all secrets
+				secrets[namespace] = &corev1.SecretList{}
+				err = c.List(ctx, secrets[namespace], client.InNamespace(namespace))
+				if err != nil {
+					return false, fmt.Sprintf("error listing secrets in namespace '%s': %v", namespace, err)
+				}
 			}
 		}
 
 		// Check to see if all references exist
 		allExist := true
 		for _, ref := range specOpsResource {
+			namespace := crossnamespace.Namespace(ns, ref)
 			resourceName := fmt.Sprintf("%s/%s", ref.Type, ref.Name)
+			if namespace != ns {
+				resourceName = fmt.Sprintf("%s/%s/%s", ref.Type, namespace, ref.Name)
+			}
 
 			found := false
 			switch ref.Type {
 			case bdv1.ConfigMapReference:
-				for _, configMap := range configMaps.Items {
+				for _, configMap := range configMaps[namespace].Items {
 					if configMap.Name == ref.Name {
 						found = true
 						break
@@ -139,7 +155,7 @@ func (v *Validator) OpsResourcesExist(ctx context.Context, specOpsResource []bdv
 				}
 
 			case bdv1.SecretReference:
-				for _, secret := range secrets.Items {
+				for _, secret := range secrets[namespace].Items {
 					if secret.Name == ref.Name {
 						found = true
 						break
@@ -178,14 +194,29 @@ func (v *Validator) Handle(ctx context.Context, req admission.Request) admission
 	}
 
 	v.log.Infof("Verifying dependencies for deployment '%s'", boshDeployment.Name)
+	for _, ref := range boshDeployment.Spec.References() {
+		err := crossnamespace.Validate(boshDeployment.Namespace, ref)
+		if err != nil {
+			return admission.Response{
+				AdmissionResponse: v1beta1.AdmissionResponse{
+					Allowed: false,
+					Result: &metav1.Status{
+						Message: fmt.Sprintf("Failed to validate references: %s", err.Error()),
+					},
+				},
+			}
+		}
+	}
+
 	withops := withops.NewResolver(
-		v.client,
+		v.referenceClient(),
 		func() withops.Interpolator { return withops.NewInterpolator() },
 		func(deploymentName string, m bdm.Manifest) (withops.DomainNameService, error) {
 			return boshdns.NewDNS(deploymentName, m)
 		},
 	)
-	resourceExist, msg := v.OpsResourcesExist(ctx, boshDeployment.Spec.Ops, boshDeployment.Namespace)
+	refs := append(append([]bdv1.ResourceReference{}, boshDeployment.Spec.Ops...), boshDeployment.Spec.Vars...)
+	resourceExist, msg := v.OpsResourcesExist(ctx, refs, boshDeployment.Namespace)
 	if !resourceExist {
 		return admission.Response{
 			AdmissionResponse: v1beta1.AdmissionResponse{
@@ -234,7 +265,10 @@ func validateUpdateBlock(manifest manifest.Manifest) error {
 	if _, err := statefulset.ExtractWatchTime(manifest.Update.CanaryWatchTime, "canary_watch_time"); err != nil {
 		return err
 	}
-	_, err := statefulset.ExtractWatchTime(manifest.Update.UpdateWatchTime, "update_watch_time")
+	if _, err := statefulset.ExtractWatchTime(manifest.Update.UpdateWatchTime, "update_watch_time"); err != nil {
+		return err
+	}
+	_, err := statefulset.ExtractMaxInFlight(manifest.Update.MaxInFlight)
 	return err
 }
 
@@ -248,6 +282,25 @@ func (v *Validator) InjectClient(c client.Client) error {
 	return nil
 }
 
+// Validator implements inject.APIReader.
+// A reader will be automatically injected.
+var _ inject.APIReader = &Validator{}
+
+// InjectAPIReader injects the reader, which is used for references to
+// shared namespaces.
+func (v *Validator) InjectAPIReader(r client.Reader) error {
+	v.apiReader = r
+	return nil
+}
+
+// referenceClient returns a client, which can read references in shared namespaces
+func (v *Validator) referenceClient() client.Client {
+	if v.apiReader == nil {
+		return v.client
+	}
+	return crossnamespace.NewClient(v.client, v.apiReader)
+}
+
 // Validator implements inject.Decoder.
 // A decoder will be automatically injected.
 var _ admission.DecoderInjector = &Validator{}
@@ -21,6 +21,7 @@ import (
 	"code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/boshdeployment"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
 	"code.cloudfoundry.org/cf-operator/testing"
 	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
@@ -131,4 +132,55 @@ var _ = Describe("When the validating webhook handles a manifest", func() {
 			Expect(response.AdmissionResponse.Allowed).To(BeFalse())
 		})
 	})
+
+	Context("with a manifest in another namespace", func() {
+		BeforeEach(func() {
+			boshDeployment := bdv1.BOSHDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "default",
+				},
+				Spec: bdv1.BOSHDeploymentSpec{
+					Manifest: bdv1.ResourceReference{
+						Type:      bdv1.ConfigMapReference,
+						Name:      "shared-manifest",
+						Namespace: "shared",
+					},
+				},
+			}
+			boshDeploymentBytes, _ = json.Marshal(boshDeployment)
+		})
+
+		JustBeforeEach(func() {
+			manifestBytes, _ := manifest.Marshal()
+			reader := fake.NewFakeClient(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "shared-manifest",
+					Namespace: "shared",
+				},
+				Data: map[string]string{
+					bdv1.ManifestSpecName: string(manifestBytes),
+				},
+			})
+			validator.(inject.APIReader).InjectAPIReader(reader)
+		})
+
+		AfterEach(func() {
+			crossnamespace.SetAllowedNamespaces(nil)
+		})
+
+		It("the manifest is rejected if the namespace is not allowed", func() {
+			response := validateBoshDeployment()
+			Expect(response.AdmissionResponse.Allowed).To(BeFalse())
+			Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("Failed to validate references"))
+			Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("namespace 'shared' is not allowed"))
+		})
+
+		It("the manifest is accepted if the namespace is allowed", func() {
+			crossnamespace.SetAllowedNamespaces([]string{"shared"})
+
+			response := validateBoshDeployment()
+			Expect(response.AdmissionResponse.Allowed).To(BeTrue())
+		})
+	})
 })
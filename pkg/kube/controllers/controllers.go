@@ -47,10 +47,12 @@ const (
 var addToManagerFuncs = []func(context.Context, *config.Config, manager.Manager) error{
 	watchnamespace.AddTerminate,
 	boshdeployment.AddDeployment,
+	boshdeployment.AddDeploymentStatus,
 	boshdeployment.AddBPM,
 	quarkssecret.AddQuarksSecret,
 	quarkssecret.AddCertificateSigningRequest,
 	quarkssecret.AddSecretRotation,
+	quarkssecret.AddSecretCopy,
 	quarksstatefulset.AddQuarksStatefulSet,
 	statefulset.AddStatefulSetRollout,
 	quarkslink.AddRestart,
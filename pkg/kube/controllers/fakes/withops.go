@@ -26,6 +26,22 @@ type FakeWithOps struct {
 		result2 []string
 		result3 error
 	}
+	ManifestWithGitReferencesStub        func(*v1alpha1.BOSHDeployment, string) (*manifest.Manifest, []v1alpha1.GitReferenceStatus, error)
+	manifestWithGitReferencesMutex       sync.RWMutex
+	manifestWithGitReferencesArgsForCall []struct {
+		arg1 *v1alpha1.BOSHDeployment
+		arg2 string
+	}
+	manifestWithGitReferencesReturns struct {
+		result1 *manifest.Manifest
+		result2 []v1alpha1.GitReferenceStatus
+		result3 error
+	}
+	manifestWithGitReferencesReturnsOnCall map[int]struct {
+		result1 *manifest.Manifest
+		result2 []v1alpha1.GitReferenceStatus
+		result3 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -97,11 +113,80 @@ func (fake *FakeWithOps) ManifestReturnsOnCall(i int, result1 *manifest.Manifest
 	}{result1, result2, result3}
 }
 
+func (fake *FakeWithOps) ManifestWithGitReferences(arg1 *v1alpha1.BOSHDeployment, arg2 string) (*manifest.Manifest, []v1alpha1.GitReferenceStatus, error) {
+	fake.manifestWithGitReferencesMutex.Lock()
+	ret, specificReturn := fake.manifestWithGitReferencesReturnsOnCall[len(fake.manifestWithGitReferencesArgsForCall)]
+	fake.manifestWithGitReferencesArgsForCall = append(fake.manifestWithGitReferencesArgsForCall, struct {
+		arg1 *v1alpha1.BOSHDeployment
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("ManifestWithGitReferences", []interface{}{arg1, arg2})
+	fake.manifestWithGitReferencesMutex.Unlock()
+	if fake.ManifestWithGitReferencesStub != nil {
+		return fake.ManifestWithGitReferencesStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.manifestWithGitReferencesReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeWithOps) ManifestWithGitReferencesCallCount() int {
+	fake.manifestWithGitReferencesMutex.RLock()
+	defer fake.manifestWithGitReferencesMutex.RUnlock()
+	return len(fake.manifestWithGitReferencesArgsForCall)
+}
+
+func (fake *FakeWithOps) ManifestWithGitReferencesCalls(stub func(*v1alpha1.BOSHDeployment, string) (*manifest.Manifest, []v1alpha1.GitReferenceStatus, error)) {
+	fake.manifestWithGitReferencesMutex.Lock()
+	defer fake.manifestWithGitReferencesMutex.Unlock()
+	fake.ManifestWithGitReferencesStub = stub
+}
+
+func (fake *FakeWithOps) ManifestWithGitReferencesArgsForCall(i int) (*v1alpha1.BOSHDeployment, string) {
+	fake.manifestWithGitReferencesMutex.RLock()
+	defer fake.manifestWithGitReferencesMutex.RUnlock()
+	argsForCall := fake.manifestWithGitReferencesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeWithOps) ManifestWithGitReferencesReturns(result1 *manifest.Manifest, result2 []v1alpha1.GitReferenceStatus, result3 error) {
+	fake.manifestWithGitReferencesMutex.Lock()
+	defer fake.manifestWithGitReferencesMutex.Unlock()
+	fake.ManifestWithGitReferencesStub = nil
+	fake.manifestWithGitReferencesReturns = struct {
+		result1 *manifest.Manifest
+		result2 []v1alpha1.GitReferenceStatus
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeWithOps) ManifestWithGitReferencesReturnsOnCall(i int, result1 *manifest.Manifest, result2 []v1alpha1.GitReferenceStatus, result3 error) {
+	fake.manifestWithGitReferencesMutex.Lock()
+	defer fake.manifestWithGitReferencesMutex.Unlock()
+	fake.ManifestWithGitReferencesStub = nil
+	if fake.manifestWithGitReferencesReturnsOnCall == nil {
+		fake.manifestWithGitReferencesReturnsOnCall = make(map[int]struct {
+			result1 *manifest.Manifest
+			result2 []v1alpha1.GitReferenceStatus
+			result3 error
+		})
+	}
+	fake.manifestWithGitReferencesReturnsOnCall[i] = struct {
+		result1 *manifest.Manifest
+		result2 []v1alpha1.GitReferenceStatus
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakeWithOps) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.manifestMutex.RLock()
 	defer fake.manifestMutex.RUnlock()
+	fake.manifestWithGitReferencesMutex.RLock()
+	defer fake.manifestWithGitReferencesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,147 @@
+package quarkssecret
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// DefaultCARotationGracePeriod is the default time for which the trust
+// bundle of a rotated CA still contains the previous CA
+const DefaultCARotationGracePeriod = 24 * time.Hour
+
+var caRotationGracePeriod = DefaultCARotationGracePeriod
+
+// SetCARotationGracePeriod sets the time for which the trust bundle of a
+// rotated CA still contains the previous CA
+func SetCARotationGracePeriod(gracePeriod time.Duration) {
+	caRotationGracePeriod = gracePeriod
+}
+
+// isSelfSignedCA returns true for CAs, which are generated and self-signed
+// by the operator. Only these are rotated in stages, intermediate CAs are
+// reissued like any other certificate signed by their CA.
+func isSelfSignedCA(instance *qsv1a1.QuarksSecret) bool {
+	request := instance.Spec.Request.CertificateRequest
+	return isRenewable(instance) && request.IsCA && request.CARef.Name == ""
+}
+
+// caBundle returns the trust bundle for a newly generated self-signed CA. It
+// contains the previous CA, if it is still valid, and true in that case.
+func (r *ReconcileQuarksSecret) caBundle(ctx context.Context, instance *qsv1a1.QuarksSecret, ca []byte) ([]byte, bool, error) {
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: instance.Spec.SecretName, Namespace: instance.GetNamespace()}, secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ca, false, nil
+		}
+		return nil, false, errors.Wrapf(err, "could not get secret '%s'", instance.Spec.SecretName)
+	}
+
+	previous := secret.Data["certificate"]
+	cert, err := parseCertificate(previous)
+	if err != nil || time.Now().After(cert.NotAfter) || bytes.Equal(previous, ca) {
+		return ca, false, nil
+	}
+
+	bundle := bytes.Join([][]byte{bytes.TrimSpace(ca), bytes.TrimSpace(previous), {}}, []byte("\n"))
+	return bundle, true, nil
+}
+
+// trustBundle returns the trust bundle of the CA secret, if it contains the
+// signing CA. Otherwise, e.g. for intermediate CAs, it returns the CA itself.
+func (r *ReconcileQuarksSecret) trustBundle(ctx context.Context, instance *qsv1a1.QuarksSecret, ca []byte) ([]byte, error) {
+	caSecret := &corev1.Secret{}
+	caRef := instance.Spec.Request.CertificateRequest.CARef
+	err := r.client.Get(ctx, types.NamespacedName{Name: caRef.Name, Namespace: instance.GetNamespace()}, caSecret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get CA secret '%s'", caRef.Name)
+	}
+
+	bundle := caSecret.Data["ca"]
+	if len(bundle) > 0 && bytes.Contains(bundle, bytes.TrimSpace(ca)) {
+		return bundle, nil
+	}
+	return ca, nil
+}
+
+// gracePeriodEnd returns the time at which the previous CA is removed from
+// the trust bundles
+func gracePeriodEnd(instance *qsv1a1.QuarksSecret) time.Time {
+	return lastRotated(instance).Add(caRotationGracePeriod)
+}
+
+// completeCARotation removes the previous CA from the trust bundle of the CA
+// and of the certificates signed by it
+func (r *ReconcileQuarksSecret) completeCARotation(ctx context.Context, instance *qsv1a1.QuarksSecret) error {
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: instance.Spec.SecretName, Namespace: instance.GetNamespace()}, secret)
+	if err != nil {
+		return errors.Wrapf(err, "could not get secret '%s'", instance.Spec.SecretName)
+	}
+	ca := secret.Data["certificate"]
+
+	err = r.updateTrustBundle(ctx, secret, ca)
+	if err != nil {
+		return err
+	}
+
+	qsecs := &qsv1a1.QuarksSecretList{}
+	err = r.client.List(ctx, qsecs, client.InNamespace(instance.GetNamespace()))
+	if err != nil {
+		return errors.Wrapf(err, "could not list QuarksSecrets signed by '%s'", instance.GetName())
+	}
+
+	for _, qsec := range qsecs.Items {
+		if qsec.Spec.Type != qsv1a1.Certificate || qsec.Spec.Request.CertificateRequest.CARef.Name != instance.Spec.SecretName {
+			continue
+		}
+
+		signed := &corev1.Secret{}
+		err = r.client.Get(ctx, types.NamespacedName{Name: qsec.Spec.SecretName, Namespace: qsec.GetNamespace()}, signed)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrapf(err, "could not get secret '%s'", qsec.Spec.SecretName)
+		}
+
+		err = r.updateTrustBundle(ctx, signed, ca)
+		if err != nil {
+			return err
+		}
+	}
+
+	instance.Status.CARotation = qsv1a1.CARotationCompleted
+	err = r.client.Status().Update(ctx, instance)
+	if err != nil {
+		return errors.Wrapf(err, "could not update QuarksSecret status '%s'", instance.GetName())
+	}
+
+	ctxlog.WithEvent(instance, "CARotation").Infof(ctx, "Completed rotation of CA '%s', removed the previous CA from the trust bundles", instance.Name)
+	return nil
+}
+
+// updateTrustBundle replaces a trust bundle, which contains the CA, by the CA
+func (r *ReconcileQuarksSecret) updateTrustBundle(ctx context.Context, secret *corev1.Secret, ca []byte) error {
+	bundle := secret.Data["ca"]
+	if bytes.Equal(bundle, ca) || !bytes.Contains(bundle, bytes.TrimSpace(ca)) {
+		return nil
+	}
+
+	secret.Data["ca"] = ca
+	err := r.client.Update(ctx, secret)
+	if err != nil {
+		return errors.Wrapf(err, "could not update trust bundle of secret '%s'", secret.Name)
+	}
+	return nil
+}
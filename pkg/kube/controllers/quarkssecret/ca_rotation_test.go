@@ -0,0 +1,233 @@
+package quarkssecret_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	qscontroller "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/quarkssecret"
+)
+
+var _ = Describe("ReconcileQuarksSecret CA rotation", func() {
+	var (
+		f              *fixture
+		reconciler     reconcile.Reconciler
+		previousCA     credsgen.Certificate
+		newCA          credsgen.Certificate
+		bundle         string
+		updatedSecrets map[string]*corev1.Secret
+	)
+
+	BeforeEach(func() {
+		f = newFixture(qsv1a1.QuarksSecretSpec{
+			Type:       "certificate",
+			SecretName: "generated-secret",
+			Request: qsv1a1.Request{
+				CertificateRequest: qsv1a1.CertificateRequest{
+					CommonName: "example.com",
+					IsCA:       true,
+				},
+			},
+		})
+		previousCA = certificate(time.Now().AddDate(-1, 0, 0), time.Now().AddDate(0, 0, 10))
+		newCA = certificate(time.Now(), time.Now().AddDate(1, 0, 0))
+		bundle = string(newCA.Certificate) + string(previousCA.Certificate)
+		updatedSecrets = map[string]*corev1.Secret{}
+
+		f.generator.GenerateCertificateReturns(newCA, nil)
+		f.client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+			secret := object.(*corev1.Secret)
+			updatedSecrets[secret.Name] = secret
+			return nil
+		})
+		f.client.UpdateCalls(func(context context.Context, object runtime.Object, _ ...crc.UpdateOption) error {
+			secret := object.(*corev1.Secret)
+			updatedSecrets[secret.Name] = secret
+			return nil
+		})
+	})
+
+	JustBeforeEach(func() {
+		reconciler = f.reconciler()
+	})
+
+	AfterEach(func() {
+		qscontroller.SetCARotationGracePeriod(qscontroller.DefaultCARotationGracePeriod)
+	})
+
+	lastStatus := func() qsv1a1.QuarksSecretStatus {
+		count := f.statusWriter.UpdateCallCount()
+		Expect(count).To(BeNumerically(">", 0))
+		_, object, _ := f.statusWriter.UpdateArgsForCall(count - 1)
+		return object.(*qsv1a1.QuarksSecret).Status
+	}
+
+	Context("when generating a new CA", func() {
+		It("uses the CA as trust bundle", func() {
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(updatedSecrets["generated-secret"].StringData["ca"]).To(Equal(string(newCA.Certificate)))
+			Expect(lastStatus().CARotation).To(BeEmpty())
+		})
+	})
+
+	Context("when rotating a CA", func() {
+		BeforeEach(func() {
+			f.secrets["generated-secret"] = certificateSecret(previousCA)
+		})
+
+		It("publishes a trust bundle with the previous CA", func() {
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			secret := updatedSecrets["generated-secret"]
+			Expect(secret.StringData["certificate"]).To(Equal(string(newCA.Certificate)))
+			Expect(secret.StringData["ca"]).To(Equal(bundle))
+
+			Expect(lastStatus().CARotation).To(Equal(qsv1a1.CARotationOverlapping))
+			Expect(result.RequeueAfter).To(BeNumerically("~", qscontroller.DefaultCARotationGracePeriod, time.Minute))
+		})
+
+		It("requeues at the end of the configured grace period", func() {
+			qscontroller.SetCARotationGracePeriod(time.Hour)
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+		})
+
+		It("reissues the certificates signed by the CA", func() {
+			f.client.ListCalls(func(context context.Context, object runtime.Object, _ ...crc.ListOption) error {
+				object.(*qsv1a1.QuarksSecretList).Items = []qsv1a1.QuarksSecret{signedQuarksSecret("leaf", "generated-secret", true)}
+				return nil
+			})
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, object, _ := f.statusWriter.UpdateArgsForCall(f.statusWriter.UpdateCallCount() - 1)
+			leaf := object.(*qsv1a1.QuarksSecret)
+			Expect(leaf.Name).To(Equal("leaf"))
+			Expect(leaf.Status.Generated).To(BeFalse())
+		})
+
+		It("drops an expired previous CA", func() {
+			f.secrets["generated-secret"] = certificateSecret(certificate(time.Now().AddDate(-1, 0, 0), time.Now().Add(-time.Hour)))
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(updatedSecrets["generated-secret"].StringData["ca"]).To(Equal(string(newCA.Certificate)))
+			Expect(lastStatus().CARotation).To(BeEmpty())
+		})
+	})
+
+	Context("when reissuing a certificate signed by a rotated CA", func() {
+		BeforeEach(func() {
+			f.qSecret.Spec.Request.CertificateRequest.IsCA = false
+			f.qSecret.Spec.Request.CertificateRequest.CARef = qsv1a1.SecretReference{Name: "ca-secret", Key: "certificate"}
+			f.qSecret.Spec.Request.CertificateRequest.CAKeyRef = qsv1a1.SecretReference{Name: "ca-secret", Key: "private_key"}
+			caSecret := certificateSecret(newCA)
+			caSecret.Name = "ca-secret"
+			caSecret.Data["ca"] = []byte(bundle)
+			f.secrets["ca-secret"] = caSecret
+			f.generator.GenerateCertificateReturns(certificate(time.Now(), time.Now().AddDate(1, 0, 0)), nil)
+		})
+
+		It("uses the trust bundle of the CA", func() {
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(updatedSecrets["generated-secret"].StringData["ca"]).To(Equal(bundle))
+		})
+	})
+
+	Context("when the CA rotation is overlapping", func() {
+		var lastRotated metav1.Time
+
+		BeforeEach(func() {
+			caSecret := certificateSecret(newCA)
+			caSecret.Data["ca"] = []byte(bundle)
+			f.secrets["generated-secret"] = caSecret
+
+			leafSecret := certificateSecret(certificate(time.Now(), time.Now().AddDate(1, 0, 0)))
+			leafSecret.Name = "leaf"
+			leafSecret.Data["ca"] = []byte(bundle)
+			f.secrets["leaf"] = leafSecret
+
+			f.client.ListCalls(func(context context.Context, object runtime.Object, _ ...crc.ListOption) error {
+				object.(*qsv1a1.QuarksSecretList).Items = []qsv1a1.QuarksSecret{
+					signedQuarksSecret("leaf", "generated-secret", true),
+					signedQuarksSecret("other-leaf", "other-ca", true),
+				}
+				return nil
+			})
+
+			f.qSecret.Status.Generated = true
+			f.qSecret.Status.CARotation = qsv1a1.CARotationOverlapping
+		})
+
+		JustBeforeEach(func() {
+			f.qSecret.Status.LastReconcile = &lastRotated
+			f.qSecret.Status.LastRotated = &lastRotated
+		})
+
+		Context("within the grace period", func() {
+			BeforeEach(func() {
+				lastRotated = metav1.NewTime(time.Now().Add(-20 * time.Hour))
+			})
+
+			It("keeps the previous CA", func() {
+				result, err := reconciler.Reconcile(f.request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(updatedSecrets).To(BeEmpty())
+				Expect(result.RequeueAfter).To(BeNumerically("~", 4*time.Hour, time.Minute))
+			})
+		})
+
+		Context("after the grace period", func() {
+			BeforeEach(func() {
+				lastRotated = metav1.NewTime(time.Now().Add(-25 * time.Hour))
+			})
+
+			It("removes the previous CA from the trust bundles", func() {
+				_, err := reconciler.Reconcile(f.request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(updatedSecrets).To(HaveLen(2))
+				Expect(updatedSecrets["generated-secret"].Data["ca"]).To(Equal(newCA.Certificate))
+				Expect(updatedSecrets["leaf"].Data["ca"]).To(Equal(newCA.Certificate))
+				Expect(lastStatus().CARotation).To(Equal(qsv1a1.CARotationCompleted))
+			})
+		})
+	})
+})
+
+// signedQuarksSecret returns a certificate QuarksSecret signed by the CA
+func signedQuarksSecret(name string, ca string, generated bool) qsv1a1.QuarksSecret {
+	return qsv1a1.QuarksSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: qsv1a1.QuarksSecretSpec{
+			Type:       "certificate",
+			SecretName: name,
+			Request: qsv1a1.Request{
+				CertificateRequest: qsv1a1.CertificateRequest{
+					CARef: qsv1a1.SecretReference{Name: ca, Key: "certificate"},
+				},
+			},
+		},
+		Status: qsv1a1.QuarksSecretStatus{Generated: generated},
+	}
+}
@@ -0,0 +1,131 @@
+package quarkssecret
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// DefaultRenewalWindow is the default time before their expiry, at which
+// generated certificates are renewed
+const DefaultRenewalWindow = 30 * 24 * time.Hour
+
+var renewalWindow = DefaultRenewalWindow
+
+// SetRenewalWindow sets the time before their expiry, at which generated
+// certificates are renewed
+func SetRenewalWindow(window time.Duration) {
+	renewalWindow = window
+}
+
+// isRenewable returns true for certificates, which are generated and signed
+// by the operator. Cluster-signed certificates are not renewed, as a new
+// signing request would have to be approved.
+func isRenewable(instance *qsv1a1.QuarksSecret) bool {
+	return instance.Spec.Type == qsv1a1.Certificate &&
+		instance.Spec.Request.CertificateRequest.SignerType != qsv1a1.ClusterSigner
+}
+
+// renewalTime returns the time at which a certificate is renewed. If the
+// renewal window is longer than a third of the validity, the certificate is
+// renewed after two thirds of its validity instead.
+func renewalTime(notBefore time.Time, notAfter time.Time) time.Time {
+	validity := notAfter.Sub(notBefore)
+	if renewalWindow > validity/3 {
+		return notBefore.Add(validity / 3 * 2)
+	}
+	return notAfter.Add(-renewalWindow)
+}
+
+// parseCertificate parses the first PEM encoded certificate
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("could not decode certificate PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// setNotAfter records the expiry of the certificate in the status and
+// returns true if it changed
+func setNotAfter(instance *qsv1a1.QuarksSecret, cert *x509.Certificate) bool {
+	if instance.Status.NotAfter != nil && instance.Status.NotAfter.Time.Equal(cert.NotAfter) {
+		return false
+	}
+	notAfter := metav1.NewTime(cert.NotAfter)
+	instance.Status.NotAfter = &notAfter
+	return true
+}
+
+// checkRenewal returns true if the generated certificate has to be renewed
+// now. Otherwise it returns the time until the renewal, which is zero if the
+// certificate can not be renewed.
+func (r *ReconcileQuarksSecret) checkRenewal(ctx context.Context, instance *qsv1a1.QuarksSecret) (bool, time.Duration, error) {
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: instance.Spec.SecretName, Namespace: instance.GetNamespace()}, secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			ctxlog.WithEvent(instance, "CertificateRenewal").Infof(ctx, "Renewing certificate of QuarksSecret '%s', secret '%s' does not exist", instance.Name, instance.Spec.SecretName)
+			return true, 0, nil
+		}
+		return false, 0, errors.Wrapf(err, "could not get secret '%s'", instance.Spec.SecretName)
+	}
+
+	cert, err := parseCertificate(secret.Data["certificate"])
+	if err != nil {
+		ctxlog.WithEvent(instance, "CertificateRenewalError").Errorf(ctx, "Certificate of QuarksSecret '%s' can not be renewed, failed to parse secret '%s': %s", instance.Name, instance.Spec.SecretName, err)
+		return false, 0, nil
+	}
+
+	if setNotAfter(instance, cert) {
+		err = r.client.Status().Update(ctx, instance)
+		if err != nil {
+			return false, 0, errors.Wrapf(err, "could not update QuarksSecret status '%s'", instance.GetName())
+		}
+	}
+
+	renewAt := renewalTime(cert.NotBefore, cert.NotAfter)
+	if time.Now().Before(renewAt) {
+		return false, time.Until(renewAt), nil
+	}
+
+	ctxlog.WithEvent(instance, "CertificateRenewal").Infof(ctx, "Renewing certificate of QuarksSecret '%s', it expires at %s", instance.Name, cert.NotAfter.Format(time.RFC3339))
+	return true, 0, nil
+}
+
+// renewSignedCertificates triggers the regeneration of the generated
+// certificates, which are signed by the CA of the instance
+func (r *ReconcileQuarksSecret) renewSignedCertificates(ctx context.Context, instance *qsv1a1.QuarksSecret) error {
+	qsecs := &qsv1a1.QuarksSecretList{}
+	err := r.client.List(ctx, qsecs, client.InNamespace(instance.GetNamespace()))
+	if err != nil {
+		return errors.Wrapf(err, "could not list QuarksSecrets signed by '%s'", instance.GetName())
+	}
+
+	for i := range qsecs.Items {
+		qsec := &qsecs.Items[i]
+		if !isRenewable(qsec) || !qsec.Status.Generated || qsec.Spec.Request.CertificateRequest.CARef.Name != instance.Spec.SecretName {
+			continue
+		}
+
+		ctxlog.WithEvent(instance, "CertificateRenewal").Infof(ctx, "Renewing certificate of QuarksSecret '%s', its CA '%s' changed", qsec.Name, instance.Name)
+		qsec.Status.Generated = false
+		err = r.client.Status().Update(ctx, qsec)
+		if err != nil {
+			return errors.Wrapf(err, "could not update QuarksSecret status '%s'", qsec.GetName())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package quarkssecret_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	qscontroller "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/quarkssecret"
+)
+
+var _ = Describe("ReconcileQuarksSecret certificate renewal", func() {
+	var (
+		f          *fixture
+		reconciler reconcile.Reconciler
+		newCert    credsgen.Certificate
+	)
+
+	BeforeEach(func() {
+		f = newFixture(qsv1a1.QuarksSecretSpec{
+			Type:       "certificate",
+			SecretName: "generated-secret",
+			Request: qsv1a1.Request{
+				CertificateRequest: qsv1a1.CertificateRequest{
+					CommonName: "foo.com",
+					CARef:      qsv1a1.SecretReference{Name: "mysecret", Key: "ca"},
+					CAKeyRef:   qsv1a1.SecretReference{Name: "mysecret", Key: "key"},
+				},
+			},
+		})
+		f.secrets["mysecret"] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "default"},
+			Data: map[string][]byte{
+				"ca":  []byte("theca"),
+				"key": []byte("the_private_key"),
+			},
+		}
+		newCert = certificate(time.Now(), time.Now().AddDate(1, 0, 0))
+		f.generator.GenerateCertificateReturns(newCert, nil)
+	})
+
+	JustBeforeEach(func() {
+		reconciler = f.reconciler()
+	})
+
+	AfterEach(func() {
+		qscontroller.SetRenewalWindow(qscontroller.DefaultRenewalWindow)
+	})
+
+	Context("when generating a certificate", func() {
+		It("records the expiry and requeues before it", func() {
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := f.statusWriter.UpdateArgsForCall(0)
+			status := object.(*qsv1a1.QuarksSecret).Status
+			Expect(status.Generated).To(BeTrue())
+			Expect(status.NotAfter).ToNot(BeNil())
+			Expect(status.NotAfter.Time).To(BeTemporally("~", time.Now().AddDate(1, 0, 0), time.Minute))
+
+			expected := time.Until(time.Now().AddDate(1, 0, 0).Add(-qscontroller.DefaultRenewalWindow))
+			Expect(result.RequeueAfter).To(BeNumerically("~", expected, time.Minute))
+		})
+
+		It("uses the configured renewal window", func() {
+			qscontroller.SetRenewalWindow(24 * time.Hour)
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			expected := time.Until(time.Now().AddDate(1, 0, 0).Add(-24 * time.Hour))
+			Expect(result.RequeueAfter).To(BeNumerically("~", expected, time.Minute))
+		})
+
+		It("renews short-lived certificates after two thirds of their validity", func() {
+			f.generator.GenerateCertificateReturns(certificate(time.Now(), time.Now().Add(3*time.Hour)), nil)
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", 2*time.Hour, time.Minute))
+		})
+
+		It("does not schedule the renewal of cluster-signed certificates", func() {
+			f.qSecret.Spec.Request.CertificateRequest.SignerType = qsv1a1.ClusterSigner
+			f.generator.GenerateCertificateSigningRequestReturns([]byte("csr"), []byte("key"), nil)
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+		})
+	})
+
+	Context("when the certificate was generated", func() {
+		BeforeEach(func() {
+			f.qSecret.Status.Generated = true
+		})
+
+		It("requeues before the expiry", func() {
+			notAfter := time.Now().AddDate(0, 6, 0)
+			f.secrets["generated-secret"] = certificateSecret(certificate(time.Now().AddDate(0, -6, 0), notAfter))
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GenerateCertificateCallCount()).To(Equal(0))
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Until(notAfter.Add(-qscontroller.DefaultRenewalWindow)), time.Minute))
+
+			By("recording the expiry of certificates generated by previous versions")
+			Expect(f.statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := f.statusWriter.UpdateArgsForCall(0)
+			Expect(object.(*qsv1a1.QuarksSecret).Status.NotAfter.Time).To(BeTemporally("~", notAfter, time.Second))
+		})
+
+		It("renews the certificate within the renewal window", func() {
+			f.secrets["generated-secret"] = certificateSecret(certificate(time.Now().AddDate(-1, 0, 0), time.Now().AddDate(0, 0, 10)))
+			f.client.UpdateCalls(func(context context.Context, object runtime.Object, _ ...crc.UpdateOption) error {
+				secret := object.(*corev1.Secret)
+				Expect(secret.StringData["certificate"]).To(Equal(string(newCert.Certificate)))
+				return nil
+			})
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.generator.GenerateCertificateCallCount()).To(Equal(1))
+			_, generationRequest := f.generator.GenerateCertificateArgsForCall(0)
+			Expect(generationRequest.CA.Certificate).To(Equal([]byte("theca")))
+			Expect(f.client.UpdateCallCount()).To(Equal(1))
+			Expect(result.RequeueAfter).To(BeNumerically(">", 300*24*time.Hour))
+		})
+
+		It("does not renew a certificate, which was replaced by a user", func() {
+			secret := certificateSecret(certificate(time.Now().AddDate(-1, 0, 0), time.Now().AddDate(0, 0, 10)))
+			secret.Labels = nil
+			f.secrets["generated-secret"] = secret
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GenerateCertificateCallCount()).To(Equal(0))
+			Expect(f.client.CreateCallCount()).To(Equal(0))
+			Expect(f.client.UpdateCallCount()).To(Equal(0))
+			Expect(result).To(Equal(reconcile.Result{}))
+		})
+
+		It("skips certificates, which can not be parsed", func() {
+			f.secrets["generated-secret"] = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "generated-secret", Namespace: "default"},
+				Data:       map[string][]byte{"certificate": []byte("the_cert")},
+			}
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GenerateCertificateCallCount()).To(Equal(0))
+			Expect(result).To(Equal(reconcile.Result{}))
+		})
+	})
+
+	Context("when generating a CA", func() {
+		BeforeEach(func() {
+			f.qSecret.Spec.Request.CertificateRequest.IsCA = true
+			f.qSecret.Spec.Request.CertificateRequest.CARef = qsv1a1.SecretReference{}
+			f.qSecret.Spec.Request.CertificateRequest.CAKeyRef = qsv1a1.SecretReference{}
+
+			f.client.ListCalls(func(context context.Context, object runtime.Object, _ ...crc.ListOption) error {
+				list := object.(*qsv1a1.QuarksSecretList)
+				list.Items = []qsv1a1.QuarksSecret{
+					signedQuarksSecret("signed", "generated-secret", true),
+					signedQuarksSecret("not-yet-generated", "generated-secret", false),
+					signedQuarksSecret("signed-by-other-ca", "other-ca", true),
+				}
+				return nil
+			})
+		})
+
+		It("renews the certificates signed by the CA", func() {
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.statusWriter.UpdateCallCount()).To(Equal(2))
+			_, object, _ := f.statusWriter.UpdateArgsForCall(1)
+			renewed := object.(*qsv1a1.QuarksSecret)
+			Expect(renewed.Name).To(Equal("signed"))
+			Expect(renewed.Status.Generated).To(BeFalse())
+		})
+	})
+})
+
+// certificate returns a self-signed certificate with the given validity
+func certificate(notBefore time.Time, notAfter time.Time) credsgen.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "foo.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	return credsgen.Certificate{
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		PrivateKey:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+// certificateSecret returns the generated secret of the certificate
+func certificateSecret(cert credsgen.Certificate) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "generated-secret",
+			Namespace: "default",
+			Labels:    map[string]string{qsv1a1.LabelKind: qsv1a1.GeneratedSecretKind},
+		},
+		Data: map[string][]byte{
+			"certificate": cert.Certificate,
+			"private_key": cert.PrivateKey,
+		},
+	}
+}
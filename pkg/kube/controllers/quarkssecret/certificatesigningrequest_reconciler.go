@@ -127,6 +127,14 @@ func (r *ReconcileCertificateSigningRequest) Reconcile(request reconcile.Request
 			ctxlog.Errorf(ctx, "Failed to delete the CSR private key secret: %v", err.Error())
 			return reconcile.Result{}, err
 		}
+
+		// CSRs created by older versions have no quarksSecret name annotation
+		if name, ok := annotations[qev1a1.AnnotationQSecName]; ok {
+			err = r.updateQuarksSecretStatus(ctx, namespace, name, certSecret.Name)
+			if err != nil {
+				ctxlog.Errorf(ctx, "Failed to update the status of quarksSecret '%s/%s': %v", namespace, name, err.Error())
+			}
+		}
 	} else {
 		err = r.approveRequest(ctx, csr.Name)
 		if err != nil {
@@ -167,6 +175,22 @@ func (r *ReconcileCertificateSigningRequest) approveRequest(ctx context.Context,
 	return nil
 }
 
+// updateQuarksSecretStatus marks the quarksSecret of an issued CSR as generated
+func (r *ReconcileCertificateSigningRequest) updateQuarksSecretStatus(ctx context.Context, namespace string, name string, secretName string) error {
+	qsec := &qev1a1.QuarksSecret{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, qsec)
+	if err != nil {
+		return errors.Wrapf(err, "could not get quarksSecret '%s/%s'", namespace, name)
+	}
+
+	setGeneratedConditions(&qsec.Status, fmt.Sprintf("Secret '%s' was generated", secretName))
+	err = r.client.Status().Update(ctx, qsec)
+	if err != nil {
+		return errors.Wrapf(err, "could not update status of quarksSecret '%s/%s'", namespace, name)
+	}
+	return nil
+}
+
 // createSecret creates secret
 func (r *ReconcileCertificateSigningRequest) createSecret(ctx context.Context, secret *corev1.Secret) error {
 	ctxlog.Debugf(ctx, "Creating secret '%s'", secret.Name)
@@ -256,6 +256,32 @@ var _ = Describe("ReconcileCertificateSigningRequest", func() {
 			Expect(client.DeleteCallCount()).To(Equal(1))
 		})
 
+		It("marks the quarksSecret as generated", func() {
+			csr.Annotations[qsv1a1.AnnotationQSecName] = "fake-qsec"
+			qsec := &qsv1a1.QuarksSecret{}
+			qsec.Status.SetCondition(qsv1a1.WaitingForCSRApprovalCondition, corev1.ConditionTrue, "WaitingForCSRApproval", "")
+			get := client.GetStub
+			client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+				switch object := object.(type) {
+				case *qsv1a1.QuarksSecret:
+					Expect(nn).To(Equal(types.NamespacedName{Namespace: "fake-namespace", Name: "fake-qsec"}))
+					qsec.DeepCopyInto(object)
+					return nil
+				}
+				return get(context, nn, object)
+			})
+			statusWriter := &cfakes.FakeStatusWriter{}
+			client.StatusCalls(func() crc.StatusWriter { return statusWriter })
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := statusWriter.UpdateArgsForCall(0)
+			status := object.(*qsv1a1.QuarksSecret).Status
+			Expect(status.IsConditionTrue(qsv1a1.GeneratedCondition)).To(BeTrue())
+			Expect(status.IsConditionTrue(qsv1a1.WaitingForCSRApprovalCondition)).To(BeFalse())
+		})
+
 		It("Skips reconcile when getting nil annotations", func() {
 			csr.Annotations = nil
 
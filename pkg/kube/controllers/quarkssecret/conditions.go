@@ -0,0 +1,103 @@
+package quarkssecret
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// Reasons of the QuarksSecret conditions
+const (
+	reasonGenerated          = "Generated"
+	reasonCSRIssued          = "CSRIssued"
+	reasonCAReady            = "CAReady"
+	reasonGenerationFailed   = "GenerationFailed"
+	reasonWaitingForValues   = "WaitingForValues"
+	reasonWaitingForBackend  = "WaitingForBackend"
+	reasonGenerationRecovery = "Recovered"
+)
+
+// specHash returns a hash of the spec, which is recorded in the status when
+// the secret is generated
+func specHash(spec qsv1a1.QuarksSecretSpec) string {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// isClusterSigned returns true if the certificate is signed by the cluster,
+// after its certificate signing request was approved
+func isClusterSigned(instance *qsv1a1.QuarksSecret) bool {
+	return instance.Spec.Type == qsv1a1.Certificate &&
+		instance.Spec.Request.CertificateRequest.SignerType == qsv1a1.ClusterSigner
+}
+
+// clearCondition sets an existing condition to false
+func clearCondition(status *qsv1a1.QuarksSecretStatus, conditionType qsv1a1.QuarksSecretConditionType, reason string) {
+	if status.GetCondition(conditionType) != nil {
+		status.SetCondition(conditionType, corev1.ConditionFalse, reason, "")
+	}
+}
+
+// setGeneratedConditions marks the secret as generated and clears the
+// conditions, which explain why it was not generated
+func setGeneratedConditions(status *qsv1a1.QuarksSecretStatus, message string) {
+	status.SetCondition(qsv1a1.GeneratedCondition, corev1.ConditionTrue, reasonGenerated, message)
+	clearCondition(status, qsv1a1.WaitingForCACondition, reasonCAReady)
+	clearCondition(status, qsv1a1.WaitingForCSRApprovalCondition, reasonCSRIssued)
+	clearCondition(status, qsv1a1.FailedCondition, reasonGenerationRecovery)
+}
+
+// setWaiting records why the secret can't be generated yet. The condition
+// type is only set, if it's not the Generated condition itself.
+func (r *ReconcileQuarksSecret) setWaiting(ctx context.Context, instance *qsv1a1.QuarksSecret, conditionType qsv1a1.QuarksSecretConditionType, reason string, message string) {
+	old := instance.Status.DeepCopy()
+
+	if conditionType != qsv1a1.GeneratedCondition {
+		instance.Status.SetCondition(conditionType, corev1.ConditionTrue, reason, message)
+	}
+	if !instance.Status.Generated {
+		instance.Status.SetCondition(qsv1a1.GeneratedCondition, corev1.ConditionFalse, reason, message)
+	}
+	clearCondition(&instance.Status, qsv1a1.FailedCondition, reasonGenerationRecovery)
+
+	r.updateConditions(ctx, instance, old)
+}
+
+// setFailed records the error of the last generation. It returns the error,
+// so the reconcile is retried.
+func (r *ReconcileQuarksSecret) setFailed(ctx context.Context, instance *qsv1a1.QuarksSecret, err error) error {
+	old := instance.Status.DeepCopy()
+
+	instance.Status.SetCondition(qsv1a1.FailedCondition, corev1.ConditionTrue, reasonGenerationFailed, err.Error())
+	if !instance.Status.Generated {
+		instance.Status.SetCondition(qsv1a1.GeneratedCondition, corev1.ConditionFalse, reasonGenerationFailed, err.Error())
+	}
+
+	r.updateConditions(ctx, instance, old)
+	return err
+}
+
+// updateConditions writes the status if the conditions changed. Errors are
+// only logged, the reconcile is retried anyway.
+func (r *ReconcileQuarksSecret) updateConditions(ctx context.Context, instance *qsv1a1.QuarksSecret, old *qsv1a1.QuarksSecretStatus) {
+	instance.Status.ObservedGeneration = instance.GetGeneration()
+	if reflect.DeepEqual(old, &instance.Status) {
+		return
+	}
+
+	err := r.client.Status().Update(ctx, instance)
+	if err != nil {
+		ctxlog.Errorf(ctx, "Failed to update conditions of QuarksSecret '%s': %s", instance.Name, err)
+	}
+}
@@ -0,0 +1,138 @@
+package quarkssecret_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+)
+
+var _ = Describe("ReconcileQuarksSecret status conditions", func() {
+	var (
+		f          *fixture
+		reconciler reconcile.Reconciler
+	)
+
+	BeforeEach(func() {
+		f = newFixture(qsv1a1.QuarksSecretSpec{
+			Type:       "rsa",
+			SecretName: "generated-secret",
+		})
+		f.qSecret.Generation = 3
+		f.generator.GenerateRSAKeyReturns(credsgen.RSAKey{PrivateKey: []byte("private"), PublicKey: []byte("public")}, nil)
+	})
+
+	JustBeforeEach(func() {
+		reconciler = f.reconciler()
+	})
+
+	lastStatus := func() qsv1a1.QuarksSecretStatus {
+		Expect(f.statusWriter.UpdateCallCount()).To(BeNumerically(">", 0))
+		_, object, _ := f.statusWriter.UpdateArgsForCall(f.statusWriter.UpdateCallCount() - 1)
+		return object.(*qsv1a1.QuarksSecret).Status
+	}
+
+	It("marks a generated secret", func() {
+		_, err := reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+
+		status := lastStatus()
+		Expect(status.IsConditionTrue(qsv1a1.GeneratedCondition)).To(BeTrue())
+		Expect(status.ObservedGeneration).To(Equal(int64(3)))
+		Expect(status.SpecHash).ToNot(BeEmpty())
+	})
+
+	It("changes the spec hash with the spec", func() {
+		_, err := reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+		hash := lastStatus().SpecHash
+
+		f.qSecret.Spec.SecretName = "other-secret"
+		_, err = reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lastStatus().SpecHash).ToNot(Equal(hash))
+	})
+
+	It("records the error of a failed generation", func() {
+		f.generator.GenerateRSAKeyReturns(credsgen.RSAKey{}, fmt.Errorf("no entropy"))
+
+		_, err := reconciler.Reconcile(f.request)
+		Expect(err).To(HaveOccurred())
+
+		status := lastStatus()
+		Expect(status.IsConditionTrue(qsv1a1.FailedCondition)).To(BeTrue())
+		Expect(status.GetCondition(qsv1a1.FailedCondition).Message).To(ContainSubstring("no entropy"))
+		Expect(status.GetCondition(qsv1a1.GeneratedCondition).Status).To(Equal(corev1.ConditionFalse))
+		Expect(status.GetCondition(qsv1a1.GeneratedCondition).Reason).To(Equal("GenerationFailed"))
+	})
+
+	It("clears the failure once the secret is generated", func() {
+		f.qSecret.Status.SetCondition(qsv1a1.FailedCondition, corev1.ConditionTrue, "GenerationFailed", "no entropy")
+
+		_, err := reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+
+		status := lastStatus()
+		Expect(status.IsConditionTrue(qsv1a1.GeneratedCondition)).To(BeTrue())
+		Expect(status.IsConditionTrue(qsv1a1.FailedCondition)).To(BeFalse())
+	})
+
+	Context("when the CA of a certificate does not exist", func() {
+		BeforeEach(func() {
+			f.qSecret.Spec.Type = "certificate"
+			f.qSecret.Spec.Request.CertificateRequest = qsv1a1.CertificateRequest{
+				CommonName: "example.com",
+				CARef:      qsv1a1.SecretReference{Name: "mysecret", Key: "ca"},
+				CAKeyRef:   qsv1a1.SecretReference{Name: "mysecret", Key: "key"},
+			}
+		})
+
+		It("waits for the CA", func() {
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+
+			status := lastStatus()
+			Expect(status.IsConditionTrue(qsv1a1.WaitingForCACondition)).To(BeTrue())
+			Expect(status.GetCondition(qsv1a1.WaitingForCACondition).Message).To(Equal("CA secret not found"))
+			Expect(status.GetCondition(qsv1a1.GeneratedCondition).Reason).To(Equal("WaitingForCA"))
+		})
+
+		It("does not update an unchanged status", func() {
+			f.qSecret.Generation = 0
+			f.qSecret.Status.SetCondition(qsv1a1.WaitingForCACondition, corev1.ConditionTrue, "WaitingForCA", "CA secret not found")
+			f.qSecret.Status.SetCondition(qsv1a1.GeneratedCondition, corev1.ConditionFalse, "WaitingForCA", "CA secret not found")
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.statusWriter.UpdateCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the certificate is signed by the cluster", func() {
+		BeforeEach(func() {
+			f.qSecret.Spec.Type = "certificate"
+			f.qSecret.Spec.Request.CertificateRequest = qsv1a1.CertificateRequest{
+				CommonName: "example.com",
+				SignerType: qsv1a1.ClusterSigner,
+			}
+			f.generator.GenerateCertificateSigningRequestReturns([]byte("csr"), []byte("key"), nil)
+		})
+
+		It("waits for the approval of the request", func() {
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			status := lastStatus()
+			Expect(status.IsConditionTrue(qsv1a1.WaitingForCSRApprovalCondition)).To(BeTrue())
+			Expect(status.IsConditionTrue(qsv1a1.GeneratedCondition)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package quarkssecret_test
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	generatorfakes "code.cloudfoundry.org/cf-operator/pkg/credsgen/fakes"
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/client/clientset/versioned/scheme"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers"
+	cfakes "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/fakes"
+	qscontroller "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/quarkssecret"
+	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+// fixture holds the fakes to reconcile the QuarksSecret 'default/foo'. The
+// fake client returns the QuarksSecrets and the secrets of the fixture, other
+// objects are not found.
+type fixture struct {
+	ctx          context.Context
+	manager      *cfakes.FakeManager
+	client       *cfakes.FakeClient
+	statusWriter *cfakes.FakeStatusWriter
+	generator    *generatorfakes.FakeGenerator
+	request      reconcile.Request
+	qSecret      *qsv1a1.QuarksSecret
+	qSecrets     map[string]*qsv1a1.QuarksSecret
+	secrets      map[string]*corev1.Secret
+}
+
+func newFixture(spec qsv1a1.QuarksSecretSpec) *fixture {
+	controllers.AddToScheme(scheme.Scheme)
+	_, log := helper.NewTestLogger()
+
+	f := &fixture{
+		ctx:          ctxlog.NewParentContext(log),
+		manager:      &cfakes.FakeManager{},
+		client:       &cfakes.FakeClient{},
+		statusWriter: &cfakes.FakeStatusWriter{},
+		generator:    &generatorfakes.FakeGenerator{},
+		request:      reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo", Namespace: "default"}},
+		qSecret: &qsv1a1.QuarksSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+			},
+			Spec: spec,
+		},
+		qSecrets: map[string]*qsv1a1.QuarksSecret{},
+		secrets:  map[string]*corev1.Secret{},
+	}
+
+	f.client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+		switch object := object.(type) {
+		case *qsv1a1.QuarksSecret:
+			if nn.Name == f.qSecret.Name {
+				f.qSecret.DeepCopyInto(object)
+				return nil
+			}
+			qSecret, ok := f.qSecrets[nn.Name]
+			if !ok {
+				return errors.NewNotFound(schema.GroupResource{}, nn.Name)
+			}
+			qSecret.DeepCopyInto(object)
+		case *corev1.Secret:
+			secret, ok := f.secrets[nn.Name]
+			if !ok {
+				return errors.NewNotFound(schema.GroupResource{}, nn.Name)
+			}
+			secret.DeepCopyInto(object)
+		}
+		return nil
+	})
+	f.client.StatusCalls(func() crc.StatusWriter { return f.statusWriter })
+	f.manager.GetClientReturns(f.client)
+	return f
+}
+
+// reconciler returns a QuarksSecret reconciler using the fakes of the fixture
+func (f *fixture) reconciler() reconcile.Reconciler {
+	config := &cfcfg.Config{CtxTimeOut: 10 * time.Second}
+	setReferenceFunc := func(owner, object metav1.Object, scheme *runtime.Scheme) error { return nil }
+	return qscontroller.NewQuarksSecretReconciler(f.ctx, config, f.manager, f.generator, setReferenceFunc)
+}
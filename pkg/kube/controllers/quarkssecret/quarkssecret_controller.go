@@ -3,6 +3,7 @@ package quarkssecret
 import (
 	"context"
 	"fmt"
+	"reflect"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -42,6 +43,11 @@ func AddQuarksSecret(ctx context.Context, config *config.Config, mgr manager.Man
 	p := predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
 			o := e.Object.(*qsv1a1.QuarksSecret)
+			// Generated certificates and secrets with a rotation policy are
+			// reconciled to schedule their renewal or rotation
+			if o.Status.Generated && (isRenewable(o) || o.Spec.Rotation != nil) {
+				return true
+			}
 			secrets, err := listSecrets(ctx, mgr.GetClient(), o)
 			if err != nil {
 				ctxlog.Errorf(ctx, "Failed to list secrets owned by QuarksSecret '%s': %s in quarksSecret reconciler", o.Name, err)
@@ -58,8 +64,12 @@ func AddQuarksSecret(ctx context.Context, config *config.Config, mgr manager.Man
 		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
 		GenericFunc: func(e event.GenericEvent) bool { return false },
 		UpdateFunc: func(e event.UpdateEvent) bool {
+			o := e.ObjectOld.(*qsv1a1.QuarksSecret)
 			n := e.ObjectNew.(*qsv1a1.QuarksSecret)
-			if !n.Status.Generated {
+			// A changed rotation policy reschedules the rotation, changed
+			// templates are rendered again
+			if !n.Status.Generated || !reflect.DeepEqual(o.Spec.Rotation, n.Spec.Rotation) ||
+				(n.Spec.Type == qsv1a1.Templated && !reflect.DeepEqual(o.Spec.Request.TemplatedConfig, n.Spec.Request.TemplatedConfig)) {
 				ctxlog.NewPredicateEvent(e.ObjectNew).Debug(
 					ctx, e.MetaNew, "qsv1a1.QuarksSecret",
 					fmt.Sprintf("Update predicate passed for '%s'", e.MetaNew.GetName()),
@@ -74,6 +84,44 @@ func AddQuarksSecret(ctx context.Context, config *config.Config, mgr manager.Man
 		return errors.Wrapf(err, "Watching quarks secrets failed in quarksSecret controller.")
 	}
 
+	// Watch for changes to secrets, which are referenced by templated
+	// QuarksSecrets
+	p = predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return true },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			o := e.ObjectOld.(*corev1.Secret)
+			n := e.ObjectNew.(*corev1.Secret)
+			return !reflect.DeepEqual(o.Data, n.Data)
+		},
+	}
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: templatedSecretsReferencing(ctx, mgr.GetClient()),
+	}, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching secrets failed in quarksSecret controller.")
+	}
+
+	// Watch for QuarksSecrets, which are referenced by templated
+	// QuarksSecrets, to render them once the values are generated
+	p = predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return e.Object.(*qsv1a1.QuarksSecret).Status.Generated },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			o := e.ObjectOld.(*qsv1a1.QuarksSecret)
+			n := e.ObjectNew.(*qsv1a1.QuarksSecret)
+			return !o.Status.Generated && n.Status.Generated
+		},
+	}
+	err = c.Watch(&source.Kind{Type: &qsv1a1.QuarksSecret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: templatedSecretsReferencingQuarksSecret(ctx, mgr.GetClient()),
+	}, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching referenced quarks secrets failed in quarksSecret controller.")
+	}
+
 	return nil
 }
 
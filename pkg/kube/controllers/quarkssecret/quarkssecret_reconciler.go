@@ -21,6 +21,7 @@ import (
 	"code.cloudfoundry.org/cf-operator/pkg/credsgen"
 	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/mutate"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/secretbackend"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
@@ -103,16 +104,78 @@ func (r *ReconcileQuarksSecret) Reconcile(request reconcile.Request) (reconcile.
 		return reconcile.Result{RequeueAfter: r.config.MeltdownRequeueAfter}, nil
 	}
 
-	// Check if allowed to generate secret, could be already done or
-	// created manually by a user
-	skipReconcile, err := r.skipReconcile(ctx, instance)
-	if err != nil {
-		ctxlog.Errorf(ctx, "Error reading the secret: %v", err.Error())
-		return reconcile.Result{}, err
+	// The hash is taken before generating, which fills in defaults
+	hash := specHash(instance.Spec)
+
+	// Secrets with a backend are fetched from it, or restored from it if
+	// they are missing in the cluster
+	if instance.Spec.Backend != nil {
+		loaded, err := r.loadFromBackend(ctx, instance)
+		if err != nil {
+			if secretbackend.IsNotFound(err) {
+				ctxlog.WithEvent(instance, "BackendNotFound").Infof(ctx, "Secret '%s' not found in backend '%s', retry after %s: %s", instance.Spec.SecretName, instance.Spec.Backend.Name, backendRetryInterval, err)
+				r.setWaiting(ctx, instance, qsv1a1.GeneratedCondition, reasonWaitingForBackend, err.Error())
+				return reconcile.Result{RequeueAfter: backendRetryInterval}, nil
+			}
+			err = ctxlog.WithEvent(instance, "BackendError").Errorf(ctx, "Failed to load secret '%s' from backend '%s': %s", instance.Spec.SecretName, instance.Spec.Backend.Name, err)
+			return reconcile.Result{}, r.setFailed(ctx, instance, err)
+		}
+		if loaded {
+			ctxlog.WithEvent(instance, "SkipReconcile").Infof(ctx, "Skip reconcile: secret of quarksSecret '%s' was loaded from its backend", instance.Name)
+			return reconcile.Result{}, nil
+		}
 	}
-	if skipReconcile {
-		ctxlog.WithEvent(instance, "SkipReconcile").Infof(ctx, "Skip reconcile: quarksSecret '%s' is already generated", instance.Name)
-		return reconcile.Result{}, nil
+
+	// Generated certificates are renewed before they expire and secrets
+	// with a rotation policy are rotated
+	if instance.Status.Generated && (isRenewable(instance) || instance.Spec.Rotation != nil) {
+		renew, renewIn := false, time.Duration(0)
+		if isRenewable(instance) {
+			renew, renewIn, err = r.checkRenewal(ctx, instance)
+			if err != nil {
+				ctxlog.Errorf(ctx, "Error checking the renewal of the certificate: %v", err.Error())
+				return reconcile.Result{}, err
+			}
+		}
+		rotate, rotateIn := r.checkRotation(ctx, instance)
+		if !renew && !rotate {
+			var gracePeriodIn time.Duration
+			if instance.Status.CARotation == qsv1a1.CARotationOverlapping {
+				gracePeriodIn = time.Until(gracePeriodEnd(instance))
+				if gracePeriodIn <= 0 {
+					err = r.completeCARotation(ctx, instance)
+					if err != nil {
+						ctxlog.Errorf(ctx, "Error completing the rotation of the CA: %v", err.Error())
+						return reconcile.Result{}, err
+					}
+				}
+			}
+			ctxlog.WithEvent(instance, "SkipReconcile").Infof(ctx, "Skip reconcile: quarksSecret '%s' is already generated", instance.Name)
+			return reconcile.Result{RequeueAfter: earliest(renewIn, rotateIn, gracePeriodIn)}, nil
+		}
+
+		// Don't overwrite a secret, which was replaced by a user
+		userSecret, err := r.isUserSecret(ctx, instance)
+		if err != nil {
+			ctxlog.Errorf(ctx, "Error reading the secret: %v", err.Error())
+			return reconcile.Result{}, err
+		}
+		if userSecret {
+			ctxlog.WithEvent(instance, "SkipReconcile").Infof(ctx, "Skip reconcile: secret '%s' of quarksSecret '%s' was created by a user", instance.Spec.SecretName, instance.Name)
+			return reconcile.Result{}, nil
+		}
+	} else {
+		// Check if allowed to generate secret, could be already done or
+		// created manually by a user
+		skipReconcile, err := r.skipReconcile(ctx, instance)
+		if err != nil {
+			ctxlog.Errorf(ctx, "Error reading the secret: %v", err.Error())
+			return reconcile.Result{}, err
+		}
+		if skipReconcile {
+			ctxlog.WithEvent(instance, "SkipReconcile").Infof(ctx, "Skip reconcile: quarksSecret '%s' is already generated", instance.Name)
+			return reconcile.Result{}, nil
+		}
 	}
 
 	// Create secret
@@ -122,21 +185,35 @@ func (r *ReconcileQuarksSecret) Reconcile(request reconcile.Request) (reconcile.
 		err = r.createPasswordSecret(ctx, instance)
 		if err != nil {
 			ctxlog.Infof(ctx, "Error generating password secret: %s", err.Error())
-			return reconcile.Result{}, errors.Wrap(err, "generating password secret failed.")
+			return reconcile.Result{}, r.setFailed(ctx, instance, errors.Wrap(err, "generating password secret failed."))
+		}
+	case qsv1a1.User:
+		ctxlog.Info(ctx, "Generating user")
+		err = r.createUserSecret(ctx, instance)
+		if err != nil {
+			ctxlog.Infof(ctx, "Error generating user secret: %s", err.Error())
+			return reconcile.Result{}, r.setFailed(ctx, instance, errors.Wrap(err, "generating user secret failed."))
 		}
 	case qsv1a1.RSAKey:
 		ctxlog.Info(ctx, "Generating RSA Key")
 		err = r.createRSASecret(ctx, instance)
 		if err != nil {
 			ctxlog.Infof(ctx, "Error generating RSA key secret: %s", err.Error())
-			return reconcile.Result{}, errors.Wrap(err, "generating RSA key secret failed.")
+			return reconcile.Result{}, r.setFailed(ctx, instance, errors.Wrap(err, "generating RSA key secret failed."))
+		}
+	case qsv1a1.Key:
+		ctxlog.Info(ctx, "Generating Key")
+		err = r.createKeySecret(ctx, instance)
+		if err != nil {
+			ctxlog.Infof(ctx, "Error generating key secret: %s", err.Error())
+			return reconcile.Result{}, r.setFailed(ctx, instance, errors.Wrap(err, "generating key secret failed."))
 		}
 	case qsv1a1.SSHKey:
 		ctxlog.Info(ctx, "Generating SSH Key")
 		err = r.createSSHSecret(ctx, instance)
 		if err != nil {
 			ctxlog.Infof(ctx, "Error generating SSH key secret: %s", err.Error())
-			return reconcile.Result{}, errors.Wrap(err, "generating SSH key secret failed.")
+			return reconcile.Result{}, r.setFailed(ctx, instance, errors.Wrap(err, "generating SSH key secret failed."))
 		}
 	case qsv1a1.Certificate:
 		ctxlog.Info(ctx, "Generating certificate")
@@ -144,24 +221,74 @@ func (r *ReconcileQuarksSecret) Reconcile(request reconcile.Request) (reconcile.
 		if err != nil {
 			if isCaNotReady(err) {
 				ctxlog.Info(ctx, fmt.Sprintf("CA for secret '%s' is not ready yet: %s", instance.Name, err))
+				r.setWaiting(ctx, instance, qsv1a1.WaitingForCACondition, string(qsv1a1.WaitingForCACondition), errors.Cause(err).Error())
 				return reconcile.Result{RequeueAfter: time.Second * 5}, nil
 			}
 			ctxlog.Info(ctx, "Error generating certificate secret: "+err.Error())
-			return reconcile.Result{}, errors.Wrap(err, "generating certificate secret.")
+			return reconcile.Result{}, r.setFailed(ctx, instance, errors.Wrap(err, "generating certificate secret."))
+		}
+	case qsv1a1.Templated:
+		ctxlog.Info(ctx, "Rendering templated secret")
+		err = r.createTemplatedSecret(ctx, instance)
+		if err != nil {
+			if isValuesNotReady(err) {
+				// The secret and QuarksSecret watches trigger a reconcile,
+				// once the values exist
+				ctxlog.Infof(ctx, "Values for secret '%s' are not ready yet: %s", instance.Name, err)
+				r.setWaiting(ctx, instance, qsv1a1.GeneratedCondition, reasonWaitingForValues, err.Error())
+				return reconcile.Result{}, nil
+			}
+			ctxlog.Infof(ctx, "Error rendering templated secret: %s", err.Error())
+			return reconcile.Result{}, r.setFailed(ctx, instance, errors.Wrap(err, "rendering templated secret failed."))
 		}
 	default:
 		err = ctxlog.WithEvent(instance, "InvalidTypeError").Errorf(ctx, "Invalid type: %s", instance.Spec.Type)
+		return reconcile.Result{}, r.setFailed(ctx, instance, err)
+	}
+
+	err = r.updateStatus(ctx, instance, hash)
+	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, r.updateStatus(ctx, instance)
+	var renewIn, gracePeriodIn time.Duration
+	if isRenewable(instance) && instance.Status.NotAfter != nil {
+		if instance.Spec.Request.CertificateRequest.IsCA {
+			err = r.renewSignedCertificates(ctx, instance)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		renewIn = time.Until(renewalTime(instance.Status.LastReconcile.Time, instance.Status.NotAfter.Time))
+	}
+	if instance.Status.CARotation == qsv1a1.CARotationOverlapping {
+		gracePeriodIn = time.Until(gracePeriodEnd(instance))
+	}
+	_, rotateIn := r.checkRotation(ctx, instance)
+
+	return reconcile.Result{RequeueAfter: earliest(renewIn, rotateIn, gracePeriodIn)}, nil
 }
 
-func (r *ReconcileQuarksSecret) updateStatus(ctx context.Context, instance *qsv1a1.QuarksSecret) error {
+// updateStatus records the generation of the secret and the hash of the spec
+// it was generated from
+func (r *ReconcileQuarksSecret) updateStatus(ctx context.Context, instance *qsv1a1.QuarksSecret, hash string) error {
 	instance.Status.Generated = true
 
 	now := metav1.Now()
 	instance.Status.LastReconcile = &now
+	instance.Status.LastRotated = &now
+	instance.Status.ObservedGeneration = instance.GetGeneration()
+	instance.Status.SpecHash = hash
+	if isClusterSigned(instance) {
+		// The certificate is written, once the request is approved
+		message := fmt.Sprintf("Waiting for the approval of certificatesigningrequest '%s'", names.CSRName(instance.Namespace, instance.Name))
+		instance.Status.SetCondition(qsv1a1.WaitingForCSRApprovalCondition, corev1.ConditionTrue, string(qsv1a1.WaitingForCSRApprovalCondition), message)
+		instance.Status.SetCondition(qsv1a1.GeneratedCondition, corev1.ConditionFalse, string(qsv1a1.WaitingForCSRApprovalCondition), message)
+		clearCondition(&instance.Status, qsv1a1.WaitingForCACondition, reasonCAReady)
+		clearCondition(&instance.Status, qsv1a1.FailedCondition, reasonGenerationRecovery)
+	} else {
+		setGeneratedConditions(&instance.Status, fmt.Sprintf("Secret '%s' was generated", instance.Spec.SecretName))
+	}
 	err := r.client.Status().Update(ctx, instance)
 	if err != nil {
 		return errors.Wrapf(err, "could not create or update QuarksSecret status '%s'", instance.GetName())
@@ -187,6 +314,26 @@ func (r *ReconcileQuarksSecret) createPasswordSecret(ctx context.Context, instan
 	return r.createSecret(ctx, instance, secret)
 }
 
+func (r *ReconcileQuarksSecret) createUserSecret(ctx context.Context, instance *qsv1a1.QuarksSecret) error {
+	request := credsgen.UserGenerationRequest{
+		Username: instance.Spec.Request.UserRequest.Username,
+	}
+	user := r.generator.GenerateUser(instance.GetName(), request)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Spec.SecretName,
+			Namespace: instance.GetNamespace(),
+		},
+		StringData: map[string]string{
+			"username": user.Username,
+			"password": user.Password,
+		},
+	}
+
+	return r.createSecret(ctx, instance, secret)
+}
+
 func (r *ReconcileQuarksSecret) createRSASecret(ctx context.Context, instance *qsv1a1.QuarksSecret) error {
 	key, err := r.generator.GenerateRSAKey(instance.GetName())
 	if err != nil {
@@ -207,8 +354,29 @@ func (r *ReconcileQuarksSecret) createRSASecret(ctx context.Context, instance *q
 	return r.createSecret(ctx, instance, secret)
 }
 
+func (r *ReconcileQuarksSecret) createKeySecret(ctx context.Context, instance *qsv1a1.QuarksSecret) error {
+	key, err := r.generator.GenerateKey(instance.GetName(), keyGenerationRequest(instance.Spec.Request.KeyRequest))
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Spec.SecretName,
+			Namespace: instance.GetNamespace(),
+		},
+		StringData: map[string]string{
+			"algorithm":   key.Algorithm,
+			"private_key": string(key.PrivateKey),
+			"public_key":  string(key.PublicKey),
+		},
+	}
+
+	return r.createSecret(ctx, instance, secret)
+}
+
 func (r *ReconcileQuarksSecret) createSSHSecret(ctx context.Context, instance *qsv1a1.QuarksSecret) error {
-	key, err := r.generator.GenerateSSHKey(instance.GetName())
+	key, err := r.generator.GenerateSSHKey(instance.GetName(), keyGenerationRequest(instance.Spec.Request.KeyRequest))
 	if err != nil {
 		return err
 	}
@@ -320,7 +488,32 @@ func (r *ReconcileQuarksSecret) createCertificateSecret(ctx context.Context, ins
 		}
 
 		if len(generationRequest.CA.Certificate) > 0 {
-			secret.StringData["ca"] = string(generationRequest.CA.Certificate)
+			// Certificates signed by a rotated CA trust the previous CA, too
+			bundle, err := r.trustBundle(ctx, instance, generationRequest.CA.Certificate)
+			if err != nil {
+				return err
+			}
+			secret.StringData["ca"] = string(bundle)
+		} else if isSelfSignedCA(instance) {
+			// The trust bundle of a rotated CA contains the previous CA
+			// until the end of the grace period
+			bundle, overlapping, err := r.caBundle(ctx, instance, cert.Certificate)
+			if err != nil {
+				return err
+			}
+			secret.StringData["ca"] = string(bundle)
+			if overlapping {
+				instance.Status.CARotation = qsv1a1.CARotationOverlapping
+				ctxlog.WithEvent(instance, "CARotation").Infof(ctx, "Rotating CA '%s', the trust bundle contains the previous CA for %s", instance.Name, caRotationGracePeriod)
+			}
+		}
+
+		// The expiry is recorded to renew the certificate in time
+		parsedCert, err := parseCertificate(cert.Certificate)
+		if err != nil {
+			ctxlog.WithEvent(instance, "CertificateRenewalError").Errorf(ctx, "Certificate of QuarksSecret '%s' can not be renewed, failed to parse it: %s", instance.Name, err)
+		} else {
+			setNotAfter(instance, parsedCert)
 		}
 
 		return r.createSecret(ctx, instance, secret)
@@ -330,13 +523,19 @@ func (r *ReconcileQuarksSecret) createCertificateSecret(ctx context.Context, ins
 }
 
 // Skip reconcile when
-// * secret is already generated according to qsecs status field
+// * secret is already generated according to qsecs status field (except templated secrets)
 // * secret exists, but was not generated (user created secret)
 func (r *ReconcileQuarksSecret) skipReconcile(ctx context.Context, instance *qsv1a1.QuarksSecret) (bool, error) {
-	if instance.Status.Generated {
+	if instance.Status.Generated && instance.Spec.Type != qsv1a1.Templated {
 		return true, nil
 	}
 
+	return r.isUserSecret(ctx, instance)
+}
+
+// isUserSecret returns true if the secret of the QuarksSecret exists, but
+// was not generated by the operator, e.g. because it was created by a user
+func (r *ReconcileQuarksSecret) isUserSecret(ctx context.Context, instance *qsv1a1.QuarksSecret) (bool, error) {
 	secretName := instance.Spec.SecretName
 
 	existingSecret := &corev1.Secret{}
@@ -361,8 +560,18 @@ func (r *ReconcileQuarksSecret) skipReconcile(ctx context.Context, instance *qsv
 	return false, nil
 }
 
-// createSecret applies common properties(labels and ownerReferences) to the secret and creates it
+// createSecret stores the secret in the backend of the QuarksSecret and writes it to the cluster
 func (r *ReconcileQuarksSecret) createSecret(ctx context.Context, instance *qsv1a1.QuarksSecret, secret *corev1.Secret) error {
+	err := r.storeInBackend(ctx, instance, secret)
+	if err != nil {
+		return err
+	}
+
+	return r.writeSecret(ctx, instance, secret)
+}
+
+// writeSecret applies common properties(labels and ownerReferences) to the secret and creates it
+func (r *ReconcileQuarksSecret) writeSecret(ctx context.Context, instance *qsv1a1.QuarksSecret, secret *corev1.Secret) error {
 	ctxlog.Debugf(ctx, "Creating secret '%s'", secret.Name)
 
 	secretLabels := secret.GetLabels()
@@ -395,10 +604,14 @@ func (r *ReconcileQuarksSecret) generateCertificateGenerationRequest(ctx context
 	var request credsgen.CertificateGenerationRequest
 	switch certificateRequest.SignerType {
 	case qsv1a1.ClusterSigner:
-		// Generate cluster-signed CA certificate
+		// Generate cluster-signed CA certificate, the validity is
+		// determined by the cluster signer
 		request = credsgen.CertificateGenerationRequest{
 			CommonName:       certificateRequest.CommonName,
 			AlternativeNames: certificateRequest.AlternativeNames,
+			KeyAlgorithm:     certificateRequest.KeyAlgorithm,
+			KeyLength:        certificateRequest.KeyLength,
+			Subject:          certificateSubject(certificateRequest),
 		}
 	case qsv1a1.LocalSigner:
 		// Generate local-issued CA certificate
@@ -406,6 +619,10 @@ func (r *ReconcileQuarksSecret) generateCertificateGenerationRequest(ctx context
 			IsCA:             certificateRequest.IsCA,
 			CommonName:       certificateRequest.CommonName,
 			AlternativeNames: certificateRequest.AlternativeNames,
+			Duration:         time.Duration(certificateRequest.Duration) * 24 * time.Hour,
+			KeyAlgorithm:     certificateRequest.KeyAlgorithm,
+			KeyLength:        certificateRequest.KeyLength,
+			Subject:          certificateSubject(certificateRequest),
 		}
 
 		if len(certificateRequest.CARef.Name) > 0 {
@@ -453,6 +670,25 @@ func (r *ReconcileQuarksSecret) generateCertificateGenerationRequest(ctx context
 	return request, nil
 }
 
+// keyGenerationRequest returns the generation parameters for keys and SSH keys
+func keyGenerationRequest(keyRequest qsv1a1.KeyRequest) credsgen.KeyGenerationRequest {
+	return credsgen.KeyGenerationRequest{
+		Algorithm: keyRequest.Algorithm,
+		Length:    keyRequest.Length,
+	}
+}
+
+// certificateSubject returns the subject fields of the certificate request
+func certificateSubject(certificateRequest qsv1a1.CertificateRequest) credsgen.Subject {
+	return credsgen.Subject{
+		Organization:       certificateRequest.Organization,
+		OrganizationalUnit: certificateRequest.OrganizationalUnit,
+		Locality:           certificateRequest.Locality,
+		State:              certificateRequest.State,
+		Country:            certificateRequest.Country,
+	}
+}
+
 // createCertificateSigningRequest creates CertificateSigningRequest Object
 func (r *ReconcileQuarksSecret) createCertificateSigningRequest(ctx context.Context, instance *qsv1a1.QuarksSecret, csr []byte) error {
 	csrName := names.CSRName(instance.Namespace, instance.Name)
@@ -464,6 +700,7 @@ func (r *ReconcileQuarksSecret) createCertificateSigningRequest(ctx context.Cont
 	}
 	annotations[qsv1a1.AnnotationCertSecretName] = instance.Spec.SecretName
 	annotations[qsv1a1.AnnotationQSecNamespace] = instance.Namespace
+	annotations[qsv1a1.AnnotationQSecName] = instance.Name
 
 	csrObj := &certv1.CertificateSigningRequest{
 		ObjectMeta: metav1.ObjectMeta{
@@ -130,6 +130,33 @@ var _ = Describe("ReconcileQuarksSecret", func() {
 		})
 	})
 
+	Context("when generating users", func() {
+		BeforeEach(func() {
+			qSecret.Spec.Type = "user"
+			qSecret.Spec.Request.UserRequest.Username = "admin"
+		})
+
+		It("generates users", func() {
+			generator.GenerateUserCalls(func(name string, request credsgen.UserGenerationRequest) credsgen.User {
+				Expect(request.Username).To(Equal("admin"))
+				return credsgen.User{Username: "admin", Password: "securepassword"}
+			})
+			client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+				secret := object.(*corev1.Secret)
+				Expect(secret.StringData["username"]).To(Equal("admin"))
+				Expect(secret.StringData["password"]).To(Equal("securepassword"))
+				Expect(secret.GetName()).To(Equal("generated-secret"))
+				Expect(secret.GetLabels()).To(HaveKeyWithValue(qsv1a1.LabelKind, qsv1a1.GeneratedSecretKind))
+				return nil
+			})
+
+			result, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.CreateCallCount()).To(Equal(1))
+			Expect(reconcile.Result{}).To(Equal(result))
+		})
+	})
+
 	Context("when generating RSA keys", func() {
 		BeforeEach(func() {
 			qSecret.Spec.Type = "rsa"
@@ -154,6 +181,36 @@ var _ = Describe("ReconcileQuarksSecret", func() {
 		})
 	})
 
+	Context("when generating keys", func() {
+		BeforeEach(func() {
+			qSecret.Spec.Type = "key"
+			qSecret.Spec.Request.KeyRequest = qsv1a1.KeyRequest{Algorithm: qsv1a1.ECDSAKeyAlgorithm, Length: 384}
+
+			generator.GenerateKeyCalls(func(name string, request credsgen.KeyGenerationRequest) (credsgen.Key, error) {
+				Expect(request.Algorithm).To(Equal(credsgen.ECDSAAlgorithm))
+				Expect(request.Length).To(Equal(384))
+				return credsgen.Key{Algorithm: "ecdsa", PrivateKey: []byte("private"), PublicKey: []byte("public")}, nil
+			})
+		})
+
+		It("generates keys", func() {
+			client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+				secret := object.(*corev1.Secret)
+				Expect(secret.StringData["algorithm"]).To(Equal("ecdsa"))
+				Expect(secret.StringData["private_key"]).To(Equal("private"))
+				Expect(secret.StringData["public_key"]).To(Equal("public"))
+				Expect(secret.GetName()).To(Equal("generated-secret"))
+				Expect(secret.GetLabels()).To(HaveKeyWithValue(qsv1a1.LabelKind, qsv1a1.GeneratedSecretKind))
+				return nil
+			})
+
+			result, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.CreateCallCount()).To(Equal(1))
+			Expect(reconcile.Result{}).To(Equal(result))
+		})
+	})
+
 	Context("when generating SSH keys", func() {
 		BeforeEach(func() {
 			qSecret.Spec.Type = "ssh"
@@ -181,6 +238,16 @@ var _ = Describe("ReconcileQuarksSecret", func() {
 			Expect(client.CreateCallCount()).To(Equal(1))
 			Expect(reconcile.Result{}).To(Equal(result))
 		})
+
+		It("considers the key algorithm", func() {
+			qSecret.Spec.Request.KeyRequest.Algorithm = qsv1a1.Ed25519KeyAlgorithm
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(generator.GenerateSSHKeyCallCount()).To(Equal(1))
+			_, keyRequest := generator.GenerateSSHKeyArgsForCall(0)
+			Expect(keyRequest.Algorithm).To(Equal(credsgen.Ed25519Algorithm))
+		})
 	})
 
 	Context("when generating certificates", func() {
@@ -254,10 +321,20 @@ var _ = Describe("ReconcileQuarksSecret", func() {
 				})
 
 				It("considers generation parameters", func() {
+					qSecret.Spec.Request.CertificateRequest.Duration = 30
+					qSecret.Spec.Request.CertificateRequest.KeyAlgorithm = qsv1a1.ECDSAKeyAlgorithm
+					qSecret.Spec.Request.CertificateRequest.KeyLength = 384
+					qSecret.Spec.Request.CertificateRequest.Organization = "Cloud Foundry"
+					qSecret.Spec.Request.CertificateRequest.Country = "DE"
 					generator.GenerateCertificateCalls(func(name string, request credsgen.CertificateGenerationRequest) (credsgen.Certificate, error) {
 						Expect(request.IsCA).To(BeFalse())
 						Expect(request.CommonName).To(Equal("foo.com"))
 						Expect(request.AlternativeNames).To(Equal([]string{"bar.com", "baz.com"}))
+						Expect(request.Duration).To(Equal(30 * 24 * time.Hour))
+						Expect(request.KeyAlgorithm).To(Equal(credsgen.ECDSAAlgorithm))
+						Expect(request.KeyLength).To(Equal(384))
+						Expect(request.Subject.Organization).To(Equal("Cloud Foundry"))
+						Expect(request.Subject.Country).To(Equal("DE"))
 						return credsgen.Certificate{Certificate: []byte("the_cert"), PrivateKey: []byte("private_key"), IsCA: false}, nil
 					})
 					client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
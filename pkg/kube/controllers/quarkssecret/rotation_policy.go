@@ -0,0 +1,97 @@
+package quarkssecret
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// rotationTime returns the time of the next rotation according to the
+// policy. It returns the zero time if the policy does not rotate the secret.
+func rotationTime(policy *qsv1a1.RotationPolicy, lastRotated time.Time) (time.Time, error) {
+	if policy == nil {
+		return time.Time{}, nil
+	}
+
+	if policy.Interval != nil && policy.Schedule != "" {
+		return time.Time{}, errors.New("interval and schedule are mutually exclusive")
+	}
+
+	var rotateAt time.Time
+	switch {
+	case policy.Interval != nil:
+		if policy.Interval.Duration <= 0 {
+			return time.Time{}, errors.Errorf("invalid interval '%s', must be positive", policy.Interval.Duration)
+		}
+		rotateAt = lastRotated.Add(policy.Interval.Duration)
+	case policy.Schedule != "":
+		schedule, err := cron.ParseStandard(policy.Schedule)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid schedule '%s'", policy.Schedule)
+		}
+		rotateAt = schedule.Next(lastRotated)
+	}
+
+	if policy.MaxAge != nil {
+		if policy.MaxAge.Duration <= 0 {
+			return time.Time{}, errors.Errorf("invalid max age '%s', must be positive", policy.MaxAge.Duration)
+		}
+		expiresAt := lastRotated.Add(policy.MaxAge.Duration)
+		if rotateAt.IsZero() || expiresAt.Before(rotateAt) {
+			rotateAt = expiresAt
+		}
+	}
+
+	return rotateAt, nil
+}
+
+// lastRotated returns the time the secret was generated. Secrets generated
+// by previous versions have no rotation timestamp, their last reconcile is
+// used instead.
+func lastRotated(instance *qsv1a1.QuarksSecret) time.Time {
+	if instance.Status.LastRotated != nil {
+		return instance.Status.LastRotated.Time
+	}
+	if instance.Status.LastReconcile != nil {
+		return instance.Status.LastReconcile.Time
+	}
+	return time.Now()
+}
+
+// checkRotation returns true if the generated secret has to be rotated now.
+// Otherwise it returns the time until the rotation, which is zero if the
+// secret is not rotated by its policy.
+func (r *ReconcileQuarksSecret) checkRotation(ctx context.Context, instance *qsv1a1.QuarksSecret) (bool, time.Duration) {
+	rotateAt, err := rotationTime(instance.Spec.Rotation, lastRotated(instance))
+	if err != nil {
+		ctxlog.WithEvent(instance, "RotationPolicyError").Errorf(ctx, "Secret of QuarksSecret '%s' is not rotated, invalid rotation policy: %s", instance.Name, err)
+		return false, 0
+	}
+	if rotateAt.IsZero() {
+		return false, 0
+	}
+
+	if time.Now().Before(rotateAt) {
+		return false, time.Until(rotateAt)
+	}
+
+	ctxlog.WithEvent(instance, "SecretRotation").Infof(ctx, "Rotating secret '%s' of QuarksSecret '%s', it was generated at %s", instance.Spec.SecretName, instance.Name, lastRotated(instance).Format(time.RFC3339))
+	return true, 0
+}
+
+// earliest returns the shortest of the positive durations, zero if there is
+// none
+func earliest(durations ...time.Duration) time.Duration {
+	var result time.Duration
+	for _, d := range durations {
+		if d > 0 && (result == 0 || d < result) {
+			result = d
+		}
+	}
+	return result
+}
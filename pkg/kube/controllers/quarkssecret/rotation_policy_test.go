@@ -0,0 +1,174 @@
+package quarkssecret_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+)
+
+var _ = Describe("ReconcileQuarksSecret rotation policy", func() {
+	var (
+		f          *fixture
+		reconciler reconcile.Reconciler
+	)
+
+	duration := func(d time.Duration) *metav1.Duration {
+		return &metav1.Duration{Duration: d}
+	}
+
+	BeforeEach(func() {
+		f = newFixture(qsv1a1.QuarksSecretSpec{
+			Type:       "password",
+			SecretName: "generated-secret",
+			Rotation: &qsv1a1.RotationPolicy{
+				Interval: duration(24 * time.Hour),
+			},
+		})
+		f.secrets["generated-secret"] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "generated-secret",
+				Namespace: "default",
+				Labels:    map[string]string{qsv1a1.LabelKind: qsv1a1.GeneratedSecretKind},
+			},
+		}
+		f.generator.GeneratePasswordReturns("securepassword")
+	})
+
+	JustBeforeEach(func() {
+		reconciler = f.reconciler()
+	})
+
+	Context("when generating the secret", func() {
+		It("records the rotation and requeues for the next one", func() {
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(1))
+			Expect(result.RequeueAfter).To(BeNumerically("~", 24*time.Hour, time.Minute))
+
+			Expect(f.statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := f.statusWriter.UpdateArgsForCall(0)
+			status := object.(*qsv1a1.QuarksSecret).Status
+			Expect(status.LastRotated).ToNot(BeNil())
+			Expect(status.LastRotated.Time).To(BeTemporally("~", time.Now(), time.Minute))
+		})
+
+		It("does not requeue without a rotation policy", func() {
+			f.qSecret.Spec.Rotation = nil
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+		})
+	})
+
+	Context("when the secret was generated", func() {
+		setLastRotated := func(t time.Time) {
+			lastRotated := metav1.NewTime(t)
+			f.qSecret.Status.Generated = true
+			f.qSecret.Status.LastReconcile = &lastRotated
+			f.qSecret.Status.LastRotated = &lastRotated
+		}
+
+		It("requeues until the interval passed", func() {
+			setLastRotated(time.Now().Add(-20 * time.Hour))
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(0))
+			Expect(result.RequeueAfter).To(BeNumerically("~", 4*time.Hour, time.Minute))
+		})
+
+		It("rotates the secret after the interval", func() {
+			setLastRotated(time.Now().Add(-25 * time.Hour))
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(1))
+			Expect(result.RequeueAfter).To(BeNumerically("~", 24*time.Hour, time.Minute))
+
+			_, object, _ := f.statusWriter.UpdateArgsForCall(0)
+			Expect(object.(*qsv1a1.QuarksSecret).Status.LastRotated.Time).To(BeTemporally("~", time.Now(), time.Minute))
+		})
+
+		It("does not rotate a secret, which was replaced by a user", func() {
+			setLastRotated(time.Now().Add(-25 * time.Hour))
+			f.secrets["generated-secret"].Labels = nil
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(0))
+			Expect(f.client.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("uses the last reconcile of secrets without a rotation timestamp", func() {
+			setLastRotated(time.Now().Add(-25 * time.Hour))
+			f.qSecret.Status.LastRotated = nil
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(1))
+		})
+
+		It("rotates the secret according to the schedule", func() {
+			f.qSecret.Spec.Rotation = &qsv1a1.RotationPolicy{Schedule: "0 * * * *"}
+			setLastRotated(time.Now().Add(-2 * time.Hour))
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(1))
+		})
+
+		It("requeues until the next scheduled rotation", func() {
+			f.qSecret.Spec.Rotation = &qsv1a1.RotationPolicy{Schedule: "@yearly"}
+			setLastRotated(time.Now())
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(0))
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", 366*24*time.Hour))
+		})
+
+		It("rotates the secret when it reaches its max age", func() {
+			f.qSecret.Spec.Rotation = &qsv1a1.RotationPolicy{
+				Schedule: "@yearly",
+				MaxAge:   duration(time.Hour),
+			}
+			setLastRotated(time.Now().Add(-2 * time.Hour))
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(1))
+		})
+
+		It("does not rotate the secret with an invalid policy", func() {
+			f.qSecret.Spec.Rotation = &qsv1a1.RotationPolicy{
+				Interval: duration(time.Hour),
+				Schedule: "@yearly",
+			}
+			setLastRotated(time.Now().Add(-2 * time.Hour))
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(0))
+			Expect(result).To(Equal(reconcile.Result{}))
+		})
+
+		It("does not rotate the secret with an invalid schedule", func() {
+			f.qSecret.Spec.Rotation = &qsv1a1.RotationPolicy{Schedule: "every day"}
+			setLastRotated(time.Now().Add(-48 * time.Hour))
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(0))
+			Expect(result).To(Equal(reconcile.Result{}))
+		})
+	})
+})
@@ -0,0 +1,150 @@
+package quarkssecret
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/secretbackend"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// backendRetryInterval is the time after which a secret, which is missing
+// in its backend, is fetched again
+const backendRetryInterval = time.Minute
+
+// isFetched returns true if the material of the secret is fetched from its
+// backend instead of being generated
+func isFetched(instance *qsv1a1.QuarksSecret) bool {
+	return instance.Spec.Backend != nil && instance.Spec.Backend.Mode == qsv1a1.BackendFetch
+}
+
+// backendPath returns the path of the secret in its backend. The path is
+// always inside of the namespace of the QuarksSecret, so secrets of other
+// namespaces can't be read or overwritten.
+func backendPath(instance *qsv1a1.QuarksSecret) (string, error) {
+	p := instance.Spec.SecretName
+	if instance.Spec.Backend.Path != "" {
+		p = instance.Spec.Backend.Path
+	}
+	if strings.HasPrefix(p, "/") {
+		return "", errors.Errorf("backend path '%s' must be relative", p)
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return "", errors.Errorf("backend path '%s' must not contain '..'", p)
+		}
+	}
+	return path.Join(instance.GetNamespace(), p), nil
+}
+
+// loadFromBackend writes the secret with the data from the backend. It
+// returns true if the secret must not be generated, because it was fetched,
+// restored or created by a user. Secrets, which are stored in a backend, are
+// only restored if they are missing in the cluster.
+func (r *ReconcileQuarksSecret) loadFromBackend(ctx context.Context, instance *qsv1a1.QuarksSecret) (bool, error) {
+	backend, err := secretbackend.Lookup(instance.Spec.Backend.Name)
+	if err != nil {
+		return false, err
+	}
+
+	existing := &corev1.Secret{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: instance.Spec.SecretName, Namespace: instance.GetNamespace()}, existing)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "could not get secret '%s'", instance.Spec.SecretName)
+		}
+		existing = nil
+	}
+
+	if existing != nil {
+		// skip the user generated secret
+		if existing.GetLabels()[qsv1a1.LabelKind] != qsv1a1.GeneratedSecretKind {
+			return true, nil
+		}
+		if !isFetched(instance) {
+			return false, nil
+		}
+	}
+
+	path, err := backendPath(instance)
+	if err != nil {
+		return false, err
+	}
+	data, err := backend.Get(ctx, path)
+	if err != nil {
+		if secretbackend.IsNotFound(err) && !isFetched(instance) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if existing != nil && reflect.DeepEqual(existing.Data, secretData(data)) {
+		return true, nil
+	}
+
+	ctxlog.Infof(ctx, "Loading secret '%s' from backend '%s' at path '%s'", instance.Spec.SecretName, instance.Spec.Backend.Name, path)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Spec.SecretName,
+			Namespace: instance.GetNamespace(),
+		},
+		StringData: data,
+	}
+	err = r.writeSecret(ctx, instance, secret)
+	if err != nil {
+		return false, err
+	}
+
+	return true, r.updateStatus(ctx, instance, specHash(instance.Spec))
+}
+
+// storeInBackend stores the generated secret in the backend of the
+// QuarksSecret, before it is written to the cluster
+func (r *ReconcileQuarksSecret) storeInBackend(ctx context.Context, instance *qsv1a1.QuarksSecret, secret *corev1.Secret) error {
+	if instance.Spec.Backend == nil || isFetched(instance) {
+		return nil
+	}
+
+	backend, err := secretbackend.Lookup(instance.Spec.Backend.Name)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{}
+	for key, value := range secret.Data {
+		data[key] = string(value)
+	}
+	for key, value := range secret.StringData {
+		data[key] = value
+	}
+
+	path, err := backendPath(instance)
+	if err != nil {
+		return err
+	}
+	err = backend.Put(ctx, path, data)
+	if err != nil {
+		return errors.Wrapf(err, "could not store secret '%s' in backend '%s'", secret.Name, instance.Spec.Backend.Name)
+	}
+	ctxlog.Debugf(ctx, "Stored secret '%s' in backend '%s' at path '%s'", secret.Name, instance.Spec.Backend.Name, path)
+	return nil
+}
+
+// secretData converts string data to the data of a secret
+func secretData(data map[string]string) map[string][]byte {
+	result := make(map[string][]byte, len(data))
+	for key, value := range data {
+		result[key] = []byte(value)
+	}
+	return result
+}
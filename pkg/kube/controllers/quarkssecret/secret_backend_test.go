@@ -0,0 +1,154 @@
+package quarkssecret_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/secretbackend"
+)
+
+var _ = Describe("ReconcileQuarksSecret secret backends", func() {
+	var (
+		f          *fixture
+		reconciler reconcile.Reconciler
+		dir        string
+		backend    *secretbackend.File
+	)
+
+	BeforeEach(func() {
+		f = newFixture(qsv1a1.QuarksSecretSpec{
+			Type:       "password",
+			SecretName: "generated-secret",
+			Backend:    &qsv1a1.SecretBackend{Name: secretbackend.FileBackend},
+		})
+
+		var err error
+		dir, err = ioutil.TempDir("", "secretbackend")
+		Expect(err).ToNot(HaveOccurred())
+		backend = secretbackend.NewFile(dir)
+		secretbackend.SetBackends(map[string]secretbackend.Backend{secretbackend.FileBackend: backend})
+
+		f.generator.GeneratePasswordReturns("securepassword")
+	})
+
+	JustBeforeEach(func() {
+		reconciler = f.reconciler()
+	})
+
+	AfterEach(func() {
+		secretbackend.SetBackends(nil)
+		os.RemoveAll(dir)
+	})
+
+	createdSecret := func() *corev1.Secret {
+		Expect(f.client.CreateCallCount()).To(Equal(1))
+		_, object, _ := f.client.CreateArgsForCall(0)
+		return object.(*corev1.Secret)
+	}
+
+	Context("when storing secrets in the backend", func() {
+		It("stores the generated secret", func() {
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(createdSecret().StringData).To(HaveKeyWithValue("password", "securepassword"))
+			data, err := backend.Get(f.ctx, "default/generated-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(map[string]string{"password": "securepassword"}))
+		})
+
+		It("uses the path of the spec inside of the namespace", func() {
+			f.qSecret.Spec.Backend.Path = "deployments/foo"
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = backend.Get(f.ctx, "default/deployments/foo")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects paths outside of the namespace", func() {
+			for _, path := range []string{"/other/foo", "../other/foo", "deployments/../../other/foo"} {
+				f.qSecret.Spec.Backend.Path = path
+
+				_, err := reconciler.Reconcile(f.request)
+				Expect(err).To(HaveOccurred(), path)
+			}
+			Expect(f.client.CreateCallCount()).To(Equal(0))
+		})
+
+		It("restores a secret, which is missing in the cluster", func() {
+			f.qSecret.Status.Generated = true
+			Expect(backend.Put(f.ctx, "default/generated-secret", map[string]string{"password": "storedpassword"})).To(Succeed())
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(0))
+			secret := createdSecret()
+			Expect(secret.StringData).To(HaveKeyWithValue("password", "storedpassword"))
+			Expect(secret.Labels).To(HaveKeyWithValue(qsv1a1.LabelKind, qsv1a1.GeneratedSecretKind))
+			Expect(f.statusWriter.UpdateCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when fetching secrets from the backend", func() {
+		BeforeEach(func() {
+			f.qSecret.Spec.Backend.Mode = qsv1a1.BackendFetch
+		})
+
+		It("creates the secret from the backend", func() {
+			Expect(backend.Put(f.ctx, "default/generated-secret", map[string]string{"password": "storedpassword"})).To(Succeed())
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(0))
+			Expect(createdSecret().StringData).To(HaveKeyWithValue("password", "storedpassword"))
+			_, object, _ := f.statusWriter.UpdateArgsForCall(0)
+			Expect(object.(*qsv1a1.QuarksSecret).Status.Generated).To(BeTrue())
+		})
+
+		It("waits for secrets, which are missing in the backend", func() {
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Minute))
+
+			Expect(f.generator.GeneratePasswordCallCount()).To(Equal(0))
+			Expect(f.client.CreateCallCount()).To(Equal(0))
+		})
+
+		It("does not overwrite a secret created by a user", func() {
+			Expect(backend.Put(f.ctx, "default/generated-secret", map[string]string{"password": "storedpassword"})).To(Succeed())
+			f.secrets["generated-secret"] = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "generated-secret", Namespace: "default"},
+			}
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.client.CreateCallCount()).To(Equal(0))
+			Expect(f.client.UpdateCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the backend is not configured", func() {
+		BeforeEach(func() {
+			f.qSecret.Spec.Backend.Name = secretbackend.VaultBackend
+		})
+
+		It("returns an error", func() {
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).To(MatchError(ContainSubstring("secret backend 'vault' is not configured")))
+			Expect(f.client.CreateCallCount()).To(Equal(0))
+		})
+	})
+})
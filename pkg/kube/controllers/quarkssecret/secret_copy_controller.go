@@ -0,0 +1,104 @@
+package quarkssecret
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// AddSecretCopy creates a new controller to keep the copies of generated
+// secrets in sync
+func AddSecretCopy(ctx context.Context, config *config.Config, mgr manager.Manager) error {
+	ctx = ctxlog.NewContextWithRecorder(ctx, "secret-copy-reconciler", mgr.GetEventRecorderFor("quarks-secret-recorder"))
+	r := NewSecretCopyReconciler(ctx, config, mgr)
+
+	// Create a new controller
+	c, err := controller.New("secret-copy-controller", mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: config.MaxQuarksSecretWorkers,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Adding secret copy controller to manager failed.")
+	}
+
+	// Watch for QuarksSecrets with copies
+	p := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			o := e.Object.(*qsv1a1.QuarksSecret)
+			if hasCopies(o) {
+				ctxlog.NewPredicateEvent(e.Object).Debug(
+					ctx, e.Meta, "qsv1a1.QuarksSecret",
+					fmt.Sprintf("Create predicate passed for '%s'", e.Meta.GetName()),
+				)
+				return true
+			}
+			return false
+		},
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			o := e.ObjectOld.(*qsv1a1.QuarksSecret)
+			n := e.ObjectNew.(*qsv1a1.QuarksSecret)
+			if hasCopies(n) && (!reflect.DeepEqual(o.Spec.Copies, n.Spec.Copies) || n.GetDeletionTimestamp() != nil) {
+				ctxlog.NewPredicateEvent(e.ObjectNew).Debug(
+					ctx, e.MetaNew, "qsv1a1.QuarksSecret",
+					fmt.Sprintf("Update predicate passed for '%s'", e.MetaNew.GetName()),
+				)
+				return true
+			}
+			return false
+		},
+	}
+	err = c.Watch(&source.Kind{Type: &qsv1a1.QuarksSecret{}}, &handler.EnqueueRequestForObject{}, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching quarks secrets failed in secret copy controller.")
+	}
+
+	// Watch for changes to generated secrets, which are owned by their
+	// QuarksSecret
+	p = predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isGeneratedSecret(e.Meta.GetLabels())
+		},
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			o := e.ObjectOld.(*corev1.Secret)
+			n := e.ObjectNew.(*corev1.Secret)
+			return isGeneratedSecret(n.GetLabels()) && !reflect.DeepEqual(o.Data, n.Data)
+		},
+	}
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &qsv1a1.QuarksSecret{},
+	}, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching secrets failed in secret copy controller.")
+	}
+
+	return nil
+}
+
+// hasCopies returns true if the QuarksSecret has or had copies
+func hasCopies(instance *qsv1a1.QuarksSecret) bool {
+	return len(instance.Spec.Copies) > 0 || len(instance.Status.Copies) > 0
+}
+
+// isGeneratedSecret returns true for secrets generated by a QuarksSecret
+func isGeneratedSecret(labels map[string]string) bool {
+	return labels[qsv1a1.LabelKind] == qsv1a1.GeneratedSecretKind
+}
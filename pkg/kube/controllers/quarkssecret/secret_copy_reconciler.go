@@ -0,0 +1,291 @@
+package quarkssecret
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// NewSecretCopyReconciler returns a new ReconcileSecretCopy
+func NewSecretCopyReconciler(ctx context.Context, config *config.Config, mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileSecretCopy{
+		ctx:    ctx,
+		config: config,
+		client: mgr.GetClient(),
+		reader: mgr.GetAPIReader(),
+	}
+}
+
+// ReconcileSecretCopy keeps the copies of the generated secret of a
+// QuarksSecret in sync
+type ReconcileSecretCopy struct {
+	ctx    context.Context
+	client client.Client
+	// reader reads copies in other namespaces, which are not cached
+	reader client.Reader
+	config *config.Config
+}
+
+// Reconcile copies the generated secret of a QuarksSecret to the namespaces
+// listed in its spec and deletes copies, which are no longer listed.
+func (r *ReconcileSecretCopy) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	instance := &qsv1a1.QuarksSecret{}
+
+	// Set the ctx to be Background, as the top-level context for incoming requests.
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.CtxTimeOut)
+	defer cancel()
+
+	ctxlog.Infof(ctx, "Reconciling copies of QuarksSecret %s", request.NamespacedName)
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			ctxlog.Info(ctx, "Skip reconcile: quarks secret not found")
+			return reconcile.Result{}, nil
+		}
+		ctxlog.Info(ctx, "Error reading the object")
+		return reconcile.Result{}, errors.Wrap(err, "Error reading quarksSecret")
+	}
+
+	if instance.GetDeletionTimestamp() != nil {
+		return reconcile.Result{}, r.deleteAllCopies(ctx, instance)
+	}
+
+	if len(instance.Spec.Copies) > 0 && !hasCopiesFinalizer(instance) {
+		controllerutil.AddFinalizer(instance, qsv1a1.CopiesFinalizer)
+		err = r.client.Update(ctx, instance)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "could not add finalizer to QuarksSecret '%s'", instance.GetName())
+		}
+	}
+
+	copies := []qsv1a1.SecretCopy{}
+	secret := &corev1.Secret{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: instance.Spec.SecretName, Namespace: instance.GetNamespace()}, secret)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, errors.Wrapf(err, "could not get secret '%s'", instance.Spec.SecretName)
+		}
+		ctxlog.Debugf(ctx, "Secret '%s' of QuarksSecret '%s' is not generated yet", instance.Spec.SecretName, instance.Name)
+		secret = nil
+	}
+
+	wanted := specCopies(instance)
+	for _, secretCopy := range wanted {
+		if secret == nil {
+			copies = append(copies, existingCopies(instance, secretCopy)...)
+			continue
+		}
+
+		err = r.syncCopy(ctx, instance, secret, secretCopy)
+		if err != nil {
+			ctxlog.WithEvent(instance, "SecretCopyError").Errorf(ctx, "Failed to copy secret '%s' of QuarksSecret '%s' to '%s/%s': %s", secret.Name, instance.Name, secretCopy.Namespace, secretCopy.Name, err)
+			copies = append(copies, existingCopies(instance, secretCopy)...)
+			continue
+		}
+		copies = append(copies, secretCopy)
+	}
+
+	// Delete copies, which were removed from the spec
+	for _, secretCopy := range instance.Status.Copies {
+		if containsCopy(wanted, secretCopy) {
+			continue
+		}
+		err = r.deleteCopy(ctx, instance, secretCopy)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if len(copies) != len(instance.Status.Copies) || (len(copies) > 0 && !reflect.DeepEqual(copies, instance.Status.Copies)) {
+		instance.Status.Copies = copies
+		err = r.client.Status().Update(ctx, instance)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "could not update QuarksSecret status '%s'", instance.GetName())
+		}
+	}
+
+	if len(instance.Spec.Copies) == 0 && hasCopiesFinalizer(instance) {
+		controllerutil.RemoveFinalizer(instance, qsv1a1.CopiesFinalizer)
+		err = r.client.Update(ctx, instance)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "could not remove finalizer from QuarksSecret '%s'", instance.GetName())
+		}
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// syncCopy creates or updates a copy of the secret. Secrets, which are not
+// copies of the QuarksSecret, are not overwritten.
+func (r *ReconcileSecretCopy) syncCopy(ctx context.Context, instance *qsv1a1.QuarksSecret, secret *corev1.Secret, secretCopy qsv1a1.SecretCopy) error {
+	if secretCopy.Namespace != instance.GetNamespace() && !crossnamespace.IsAllowed(secretCopy.Namespace) {
+		return errors.Errorf("namespace '%s' is not shared with other namespaces", secretCopy.Namespace)
+	}
+	if secretCopy.Namespace == instance.GetNamespace() && secretCopy.Name == secret.Name {
+		return errors.New("the copy is the generated secret itself")
+	}
+
+	existing := &corev1.Secret{}
+	err := r.reader.Get(ctx, types.NamespacedName{Name: secretCopy.Name, Namespace: secretCopy.Namespace}, existing)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "could not get copy")
+		}
+
+		ctxlog.Debugf(ctx, "Creating copy '%s/%s' of secret '%s'", secretCopy.Namespace, secretCopy.Name, secret.Name)
+		return r.client.Create(ctx, &corev1.Secret{
+			ObjectMeta: copyObjectMeta(instance, secretCopy),
+			Type:       secret.Type,
+			Data:       secret.Data,
+		})
+	}
+
+	if !isCopyOf(existing, instance) {
+		return errors.New("the secret exists and is not a copy")
+	}
+	if reflect.DeepEqual(existing.Data, secret.Data) {
+		return nil
+	}
+
+	ctxlog.Debugf(ctx, "Updating copy '%s/%s' of secret '%s'", secretCopy.Namespace, secretCopy.Name, secret.Name)
+	existing.Data = secret.Data
+	return r.client.Update(ctx, existing)
+}
+
+// deleteAllCopies deletes the copies of a QuarksSecret, which is being
+// deleted, and removes its finalizer
+func (r *ReconcileSecretCopy) deleteAllCopies(ctx context.Context, instance *qsv1a1.QuarksSecret) error {
+	if !hasCopiesFinalizer(instance) {
+		ctxlog.Debugf(ctx, "Skip reconcile: QuarksSecret '%s' is being deleted", instance.Name)
+		return nil
+	}
+
+	copies := specCopies(instance)
+	for _, secretCopy := range instance.Status.Copies {
+		if !containsCopy(copies, secretCopy) {
+			copies = append(copies, secretCopy)
+		}
+	}
+
+	for _, secretCopy := range copies {
+		err := r.deleteCopy(ctx, instance, secretCopy)
+		if err != nil {
+			return err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, qsv1a1.CopiesFinalizer)
+	err := r.client.Update(ctx, instance)
+	if err != nil {
+		return errors.Wrapf(err, "could not remove finalizer from QuarksSecret '%s'", instance.GetName())
+	}
+
+	ctxlog.Infof(ctx, "Copies of QuarksSecret '%s' have been deleted", instance.Name)
+	return nil
+}
+
+// deleteCopy deletes a copy of the generated secret, if it exists
+func (r *ReconcileSecretCopy) deleteCopy(ctx context.Context, instance *qsv1a1.QuarksSecret, secretCopy qsv1a1.SecretCopy) error {
+	existing := &corev1.Secret{}
+	err := r.reader.Get(ctx, types.NamespacedName{Name: secretCopy.Name, Namespace: secretCopy.Namespace}, existing)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "could not get copy '%s/%s'", secretCopy.Namespace, secretCopy.Name)
+	}
+	if !isCopyOf(existing, instance) {
+		return nil
+	}
+
+	ctxlog.Debugf(ctx, "Deleting copy '%s/%s' of QuarksSecret '%s'", secretCopy.Namespace, secretCopy.Name, instance.Name)
+	err = r.client.Delete(ctx, existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "could not delete copy '%s/%s'", secretCopy.Namespace, secretCopy.Name)
+	}
+	return nil
+}
+
+// specCopies returns the copies of the spec, using the name of the generated
+// secret as default
+func specCopies(instance *qsv1a1.QuarksSecret) []qsv1a1.SecretCopy {
+	copies := make([]qsv1a1.SecretCopy, 0, len(instance.Spec.Copies))
+	for _, secretCopy := range instance.Spec.Copies {
+		if secretCopy.Name == "" {
+			secretCopy.Name = instance.Spec.SecretName
+		}
+		copies = append(copies, secretCopy)
+	}
+	return copies
+}
+
+// existingCopies returns the copy if it was created before
+func existingCopies(instance *qsv1a1.QuarksSecret, secretCopy qsv1a1.SecretCopy) []qsv1a1.SecretCopy {
+	if containsCopy(instance.Status.Copies, secretCopy) {
+		return []qsv1a1.SecretCopy{secretCopy}
+	}
+	return nil
+}
+
+// containsCopy returns true if the copies contain the copy
+func containsCopy(copies []qsv1a1.SecretCopy, secretCopy qsv1a1.SecretCopy) bool {
+	for _, c := range copies {
+		if c == secretCopy {
+			return true
+		}
+	}
+	return false
+}
+
+// copyObjectMeta returns the meta data of a copy, which is labeled as copy
+// and annotated with the QuarksSecret it is a copy of
+func copyObjectMeta(instance *qsv1a1.QuarksSecret, secretCopy qsv1a1.SecretCopy) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      secretCopy.Name,
+		Namespace: secretCopy.Namespace,
+		Labels: map[string]string{
+			qsv1a1.LabelKind: qsv1a1.CopySecretKind,
+		},
+		Annotations: map[string]string{
+			qsv1a1.AnnotationCopyOf: copyOfAnnotation(instance),
+		},
+	}
+}
+
+// copyOfAnnotation returns the namespaced name of the QuarksSecret
+func copyOfAnnotation(instance *qsv1a1.QuarksSecret) string {
+	return fmt.Sprintf("%s/%s", instance.GetNamespace(), instance.GetName())
+}
+
+// isCopyOf returns true if the secret is a copy of the QuarksSecret
+func isCopyOf(secret *corev1.Secret, instance *qsv1a1.QuarksSecret) bool {
+	return secret.GetLabels()[qsv1a1.LabelKind] == qsv1a1.CopySecretKind &&
+		secret.GetAnnotations()[qsv1a1.AnnotationCopyOf] == copyOfAnnotation(instance)
+}
+
+// hasCopiesFinalizer returns true if the QuarksSecret has the finalizer,
+// which deletes its copies
+func hasCopiesFinalizer(instance *qsv1a1.QuarksSecret) bool {
+	for _, f := range instance.GetFinalizers() {
+		if f == qsv1a1.CopiesFinalizer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package quarkssecret_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/client/clientset/versioned/scheme"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers"
+	cfakes "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/fakes"
+	qscontroller "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/quarkssecret"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
+	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("ReconcileSecretCopy", func() {
+	var (
+		manager      *cfakes.FakeManager
+		reconciler   reconcile.Reconciler
+		request      reconcile.Request
+		ctx          context.Context
+		client       *cfakes.FakeClient
+		statusWriter *cfakes.FakeStatusWriter
+		qSecret      *qsv1a1.QuarksSecret
+		secrets      map[types.NamespacedName]*corev1.Secret
+	)
+
+	copyMeta := func(namespace string, name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{qsv1a1.LabelKind: qsv1a1.CopySecretKind},
+			Annotations: map[string]string{qsv1a1.AnnotationCopyOf: "default/foo"},
+		}
+	}
+
+	BeforeEach(func() {
+		controllers.AddToScheme(scheme.Scheme)
+		crossnamespace.SetAllowedNamespaces([]string{"shared"})
+		manager = &cfakes.FakeManager{}
+		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo", Namespace: "default"}}
+		_, log := helper.NewTestLogger()
+		ctx = ctxlog.NewParentContext(log)
+		qSecret = &qsv1a1.QuarksSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "foo",
+				Namespace:  "default",
+				Finalizers: []string{qsv1a1.CopiesFinalizer},
+			},
+			Spec: qsv1a1.QuarksSecretSpec{
+				Type:       "password",
+				SecretName: "generated-secret",
+				Copies: []qsv1a1.SecretCopy{
+					{Namespace: "shared"},
+					{Namespace: "shared", Name: "renamed"},
+				},
+			},
+		}
+		secrets = map[types.NamespacedName]*corev1.Secret{
+			{Namespace: "default", Name: "generated-secret"}: {
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "generated-secret",
+					Namespace: "default",
+					Labels:    map[string]string{qsv1a1.LabelKind: qsv1a1.GeneratedSecretKind},
+				},
+				Data: map[string][]byte{"password": []byte("securepassword")},
+			},
+		}
+
+		client = &cfakes.FakeClient{}
+		client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+			switch object := object.(type) {
+			case *qsv1a1.QuarksSecret:
+				qSecret.DeepCopyInto(object)
+			case *corev1.Secret:
+				secret, ok := secrets[nn]
+				if !ok {
+					return errors.NewNotFound(schema.GroupResource{}, nn.Name)
+				}
+				secret.DeepCopyInto(object)
+			}
+			return nil
+		})
+		statusWriter = &cfakes.FakeStatusWriter{}
+		client.StatusCalls(func() crc.StatusWriter { return statusWriter })
+		manager.GetClientReturns(client)
+		manager.GetAPIReaderReturns(client)
+	})
+
+	JustBeforeEach(func() {
+		config := &cfcfg.Config{CtxTimeOut: 10 * time.Second}
+		reconciler = qscontroller.NewSecretCopyReconciler(ctx, config, manager)
+	})
+
+	AfterEach(func() {
+		crossnamespace.SetAllowedNamespaces(nil)
+	})
+
+	Context("when the secret is generated", func() {
+		It("creates the copies", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.CreateCallCount()).To(Equal(2))
+			_, object, _ := client.CreateArgsForCall(0)
+			secretCopy := object.(*corev1.Secret)
+			Expect(secretCopy.Namespace).To(Equal("shared"))
+			Expect(secretCopy.Name).To(Equal("generated-secret"))
+			Expect(secretCopy.Labels).To(HaveKeyWithValue(qsv1a1.LabelKind, qsv1a1.CopySecretKind))
+			Expect(secretCopy.Annotations).To(HaveKeyWithValue(qsv1a1.AnnotationCopyOf, "default/foo"))
+			Expect(secretCopy.Data).To(HaveKeyWithValue("password", []byte("securepassword")))
+
+			_, object, _ = client.CreateArgsForCall(1)
+			Expect(object.(*corev1.Secret).Name).To(Equal("renamed"))
+
+			Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ = statusWriter.UpdateArgsForCall(0)
+			Expect(object.(*qsv1a1.QuarksSecret).Status.Copies).To(Equal([]qsv1a1.SecretCopy{
+				{Namespace: "shared", Name: "generated-secret"},
+				{Namespace: "shared", Name: "renamed"},
+			}))
+		})
+
+		It("adds the finalizer", func() {
+			qSecret.Finalizers = nil
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.UpdateCallCount()).To(Equal(1))
+			_, object, _ := client.UpdateArgsForCall(0)
+			Expect(object.(*qsv1a1.QuarksSecret).Finalizers).To(ContainElement(qsv1a1.CopiesFinalizer))
+		})
+
+		It("updates copies after a rotation", func() {
+			secrets[types.NamespacedName{Namespace: "shared", Name: "generated-secret"}] = &corev1.Secret{
+				ObjectMeta: copyMeta("shared", "generated-secret"),
+				Data:       map[string][]byte{"password": []byte("oldpassword")},
+			}
+			secrets[types.NamespacedName{Namespace: "shared", Name: "renamed"}] = &corev1.Secret{
+				ObjectMeta: copyMeta("shared", "renamed"),
+				Data:       map[string][]byte{"password": []byte("securepassword")},
+			}
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.CreateCallCount()).To(Equal(0))
+			Expect(client.UpdateCallCount()).To(Equal(1))
+			_, object, _ := client.UpdateArgsForCall(0)
+			secretCopy := object.(*corev1.Secret)
+			Expect(secretCopy.Name).To(Equal("generated-secret"))
+			Expect(secretCopy.Data).To(HaveKeyWithValue("password", []byte("securepassword")))
+		})
+
+		It("does not overwrite secrets, which are not copies", func() {
+			secrets[types.NamespacedName{Namespace: "shared", Name: "renamed"}] = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "renamed", Namespace: "shared"},
+				Data:       map[string][]byte{"password": []byte("userpassword")},
+			}
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.CreateCallCount()).To(Equal(1))
+			Expect(client.UpdateCallCount()).To(Equal(0))
+			_, object, _ := statusWriter.UpdateArgsForCall(0)
+			Expect(object.(*qsv1a1.QuarksSecret).Status.Copies).To(Equal([]qsv1a1.SecretCopy{
+				{Namespace: "shared", Name: "generated-secret"},
+			}))
+		})
+
+		It("does not copy to namespaces, which are not shared", func() {
+			qSecret.Spec.Copies = []qsv1a1.SecretCopy{{Namespace: "kube-system"}}
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.CreateCallCount()).To(Equal(0))
+		})
+
+		It("deletes copies, which were removed from the spec", func() {
+			qSecret.Spec.Copies = qSecret.Spec.Copies[:1]
+			qSecret.Status.Copies = []qsv1a1.SecretCopy{
+				{Namespace: "shared", Name: "generated-secret"},
+				{Namespace: "shared", Name: "renamed"},
+			}
+			secrets[types.NamespacedName{Namespace: "shared", Name: "generated-secret"}] = &corev1.Secret{
+				ObjectMeta: copyMeta("shared", "generated-secret"),
+				Data:       map[string][]byte{"password": []byte("securepassword")},
+			}
+			secrets[types.NamespacedName{Namespace: "shared", Name: "renamed"}] = &corev1.Secret{
+				ObjectMeta: copyMeta("shared", "renamed"),
+			}
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.DeleteCallCount()).To(Equal(1))
+			_, object, _ := client.DeleteArgsForCall(0)
+			Expect(object.(*corev1.Secret).Name).To(Equal("renamed"))
+
+			_, object, _ = statusWriter.UpdateArgsForCall(0)
+			Expect(object.(*qsv1a1.QuarksSecret).Status.Copies).To(HaveLen(1))
+		})
+	})
+
+	Context("when the secret is not generated yet", func() {
+		BeforeEach(func() {
+			delete(secrets, types.NamespacedName{Namespace: "default", Name: "generated-secret"})
+		})
+
+		It("does not create copies", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.CreateCallCount()).To(Equal(0))
+			Expect(statusWriter.UpdateCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the QuarksSecret is deleted", func() {
+		BeforeEach(func() {
+			now := metav1.Now()
+			qSecret.DeletionTimestamp = &now
+			qSecret.Status.Copies = []qsv1a1.SecretCopy{{Namespace: "shared", Name: "generated-secret"}}
+			secrets[types.NamespacedName{Namespace: "shared", Name: "generated-secret"}] = &corev1.Secret{
+				ObjectMeta: copyMeta("shared", "generated-secret"),
+			}
+		})
+
+		It("deletes the copies and removes the finalizer", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.DeleteCallCount()).To(Equal(1))
+			_, object, _ := client.DeleteArgsForCall(0)
+			Expect(object.(*corev1.Secret).Name).To(Equal("generated-secret"))
+			Expect(object.(*corev1.Secret).Namespace).To(Equal("shared"))
+
+			Expect(client.UpdateCallCount()).To(Equal(1))
+			_, object, _ = client.UpdateArgsForCall(0)
+			Expect(object.(*qsv1a1.QuarksSecret).Finalizers).To(BeEmpty())
+		})
+	})
+})
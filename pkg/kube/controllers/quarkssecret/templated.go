@@ -0,0 +1,256 @@
+package quarkssecret
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+type valuesNotReadyError struct {
+	message string
+}
+
+// Error returns the error message
+func (e *valuesNotReadyError) Error() string {
+	return e.message
+}
+
+func isValuesNotReady(err error) bool {
+	_, ok := errors.Cause(err).(*valuesNotReadyError)
+	return ok
+}
+
+// templateFuncs returns the functions available in the templates of
+// templated secrets, in addition to the builtin functions of text/template.
+// The existing data of the rendered secret is used to keep htpasswd hashes,
+// which still match the password, since bcrypt uses a random salt.
+func templateFuncs(existing map[string][]byte) template.FuncMap {
+	return template.FuncMap{
+		"b64enc": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"toJson": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		"htpasswd": func(username string, password string) (string, error) {
+			if hash, ok := existingHtpasswd(existing, username, password); ok {
+				return username + ":" + hash, nil
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			return username + ":" + string(hash), err
+		},
+	}
+}
+
+// existingHtpasswd returns the bcrypt hash of an htpasswd entry of the user
+// in the existing data, if it matches the password
+func existingHtpasswd(existing map[string][]byte, username string, password string) (string, bool) {
+	prefix := username + ":"
+	for _, value := range existing {
+		for _, line := range strings.Split(string(value), "\n") {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+			hash := strings.TrimPrefix(line, prefix)
+			if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+				return hash, true
+			}
+		}
+	}
+	return "", false
+}
+
+// createTemplatedSecret renders the templates with the values of the
+// referenced secrets
+func (r *ReconcileQuarksSecret) createTemplatedSecret(ctx context.Context, instance *qsv1a1.QuarksSecret) error {
+	config := instance.Spec.Request.TemplatedConfig
+
+	values := map[string]string{}
+	for name, ref := range config.Values {
+		secretName, err := r.valueSecretName(ctx, instance.GetNamespace(), ref)
+		if err != nil {
+			return err
+		}
+
+		secret := &corev1.Secret{}
+		err = r.client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: instance.GetNamespace()}, secret)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return &valuesNotReadyError{message: "secret '" + secretName + "' not found"}
+			}
+			return errors.Wrapf(err, "could not get secret '%s'", secretName)
+		}
+		value, ok := secret.Data[ref.Key]
+		if !ok {
+			return &valuesNotReadyError{message: "key '" + ref.Key + "' not found in secret '" + secretName + "'"}
+		}
+		values[name] = string(value)
+	}
+
+	existing := &corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: instance.Spec.SecretName, Namespace: instance.GetNamespace()}, existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "could not get secret '%s'", instance.Spec.SecretName)
+	}
+
+	data, err := renderTemplates(config.Templates, values, existing.Data)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Spec.SecretName,
+			Namespace: instance.GetNamespace(),
+		},
+		StringData: data,
+	}
+
+	return r.createSecret(ctx, instance, secret)
+}
+
+// valueSecretName returns the name of the secret, which holds the value. A
+// value of a QuarksSecret is read from its secret, once it is generated.
+func (r *ReconcileQuarksSecret) valueSecretName(ctx context.Context, namespace string, ref qsv1a1.TemplatedValueReference) (string, error) {
+	if ref.QuarksSecretName == "" {
+		return ref.Name, nil
+	}
+
+	qsec := &qsv1a1.QuarksSecret{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: ref.QuarksSecretName, Namespace: namespace}, qsec)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", &valuesNotReadyError{message: "quarksSecret '" + ref.QuarksSecretName + "' not found"}
+		}
+		return "", errors.Wrapf(err, "could not get quarksSecret '%s'", ref.QuarksSecretName)
+	}
+	if !qsec.Status.Generated {
+		return "", &valuesNotReadyError{message: "quarksSecret '" + ref.QuarksSecretName + "' is not generated yet"}
+	}
+	return qsec.Spec.SecretName, nil
+}
+
+// renderTemplates renders each template with the values
+func renderTemplates(templates map[string]string, values map[string]string, existing map[string][]byte) (map[string]string, error) {
+	if len(templates) == 0 {
+		return nil, errors.New("no templates specified")
+	}
+
+	input := struct {
+		Values map[string]string
+	}{values}
+
+	data := map[string]string{}
+	for key, text := range templates {
+		t, err := template.New(key).Option("missingkey=error").Funcs(templateFuncs(existing)).Parse(text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse template of key '%s'", key)
+		}
+
+		var buf bytes.Buffer
+		err = t.Execute(&buf, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not render template of key '%s'", key)
+		}
+		data[key] = buf.String()
+	}
+	return data, nil
+}
+
+// referencesSecret returns true if the templated QuarksSecret uses values of
+// the secret. secretNames maps the names of the QuarksSecrets in the
+// namespace to the names of their secrets.
+func referencesSecret(instance *qsv1a1.QuarksSecret, secretName string, secretNames map[string]string) bool {
+	if instance.Spec.Type != qsv1a1.Templated {
+		return false
+	}
+	for _, ref := range instance.Spec.Request.TemplatedConfig.Values {
+		if ref.QuarksSecretName != "" {
+			if secretNames[ref.QuarksSecretName] == secretName {
+				return true
+			}
+			continue
+		}
+		if ref.Name == secretName {
+			return true
+		}
+	}
+	return false
+}
+
+// referencesQuarksSecret returns true if the templated QuarksSecret uses
+// values of the QuarksSecret
+func referencesQuarksSecret(instance *qsv1a1.QuarksSecret, name string) bool {
+	if instance.Spec.Type != qsv1a1.Templated {
+		return false
+	}
+	for _, ref := range instance.Spec.Request.TemplatedConfig.Values {
+		if ref.QuarksSecretName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// templatedSecretsReferencing returns a mapper, which enqueues the templated
+// QuarksSecrets using values of a secret
+func templatedSecretsReferencing(ctx context.Context, c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		return templatedSecretsMatching(ctx, c, a.Meta, func(qsec *qsv1a1.QuarksSecret, secretNames map[string]string) bool {
+			return referencesSecret(qsec, a.Meta.GetName(), secretNames)
+		})
+	}
+}
+
+// templatedSecretsReferencingQuarksSecret returns a mapper, which enqueues
+// the templated QuarksSecrets using values of a QuarksSecret
+func templatedSecretsReferencingQuarksSecret(ctx context.Context, c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		return templatedSecretsMatching(ctx, c, a.Meta, func(qsec *qsv1a1.QuarksSecret, _ map[string]string) bool {
+			return referencesQuarksSecret(qsec, a.Meta.GetName())
+		})
+	}
+}
+
+// templatedSecretsMatching returns requests for the QuarksSecrets in the
+// namespace of the object, which match
+func templatedSecretsMatching(ctx context.Context, c client.Client, meta metav1.Object, match func(*qsv1a1.QuarksSecret, map[string]string) bool) []reconcile.Request {
+	qsecs := &qsv1a1.QuarksSecretList{}
+	err := c.List(ctx, qsecs, client.InNamespace(meta.GetNamespace()))
+	if err != nil {
+		ctxlog.Errorf(ctx, "Failed to list QuarksSecrets referencing '%s': %s", meta.GetName(), err)
+		return nil
+	}
+
+	secretNames := map[string]string{}
+	for _, qsec := range qsecs.Items {
+		secretNames[qsec.Name] = qsec.Spec.SecretName
+	}
+
+	requests := []reconcile.Request{}
+	for _, qsec := range qsecs.Items {
+		if match(&qsec, secretNames) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: qsec.Name, Namespace: qsec.Namespace},
+			})
+		}
+	}
+	return requests
+}
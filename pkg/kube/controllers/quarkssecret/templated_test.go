@@ -0,0 +1,221 @@
+package quarkssecret_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+)
+
+var _ = Describe("ReconcileQuarksSecret templated secrets", func() {
+	var (
+		f          *fixture
+		reconciler reconcile.Reconciler
+	)
+
+	BeforeEach(func() {
+		f = newFixture(qsv1a1.QuarksSecretSpec{
+			Type:       "templated",
+			SecretName: "generated-secret",
+			Request: qsv1a1.Request{
+				TemplatedConfig: qsv1a1.TemplatedConfig{
+					Templates: map[string]string{
+						"url":      "postgres://{{ .Values.user }}:{{ .Values.password }}@db:5432",
+						"htpasswd": `{{ htpasswd .Values.user .Values.password }}`,
+					},
+					Values: map[string]qsv1a1.TemplatedValueReference{
+						"user":     {Name: "db-user", Key: "username"},
+						"password": {Name: "db-user", Key: "password"},
+					},
+				},
+			},
+		})
+		f.secrets["db-user"] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-user", Namespace: "default"},
+			Data: map[string][]byte{
+				"username": []byte("admin"),
+				"password": []byte("securepassword"),
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		reconciler = f.reconciler()
+	})
+
+	renderedSecret := func() *corev1.Secret {
+		Expect(f.client.CreateCallCount()).To(Equal(1))
+		_, object, _ := f.client.CreateArgsForCall(0)
+		return object.(*corev1.Secret)
+	}
+
+	It("renders the templates with the referenced values", func() {
+		_, err := reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+
+		secret := renderedSecret()
+		Expect(secret.Name).To(Equal("generated-secret"))
+		Expect(secret.Labels).To(HaveKeyWithValue(qsv1a1.LabelKind, qsv1a1.GeneratedSecretKind))
+		Expect(secret.StringData["url"]).To(Equal("postgres://admin:securepassword@db:5432"))
+
+		parts := strings.SplitN(secret.StringData["htpasswd"], ":", 2)
+		Expect(parts[0]).To(Equal("admin"))
+		Expect(bcrypt.CompareHashAndPassword([]byte(parts[1]), []byte("securepassword"))).To(Succeed())
+
+		Expect(f.statusWriter.UpdateCallCount()).To(Equal(1))
+	})
+
+	It("renders the secret again when it was generated before", func() {
+		f.qSecret.Status.Generated = true
+		f.secrets["generated-secret"] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "generated-secret",
+				Namespace: "default",
+				Labels:    map[string]string{qsv1a1.LabelKind: qsv1a1.GeneratedSecretKind},
+			},
+			StringData: map[string]string{"url": "postgres://admin:oldpassword@db:5432"},
+		}
+
+		_, err := reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(f.client.UpdateCallCount()).To(Equal(1))
+		_, object, _ := f.client.UpdateArgsForCall(0)
+		Expect(object.(*corev1.Secret).StringData["url"]).To(Equal("postgres://admin:securepassword@db:5432"))
+	})
+
+	It("keeps the htpasswd hash, if it still matches the password", func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("securepassword"), bcrypt.MinCost)
+		Expect(err).ToNot(HaveOccurred())
+		f.qSecret.Status.Generated = true
+		f.secrets["generated-secret"] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "generated-secret",
+				Namespace: "default",
+				Labels:    map[string]string{qsv1a1.LabelKind: qsv1a1.GeneratedSecretKind},
+			},
+			Data: map[string][]byte{"htpasswd": []byte("admin:" + string(hash))},
+		}
+
+		_, err = reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(f.client.UpdateCallCount()).To(Equal(1))
+		_, object, _ := f.client.UpdateArgsForCall(0)
+		Expect(object.(*corev1.Secret).StringData["htpasswd"]).To(Equal("admin:" + string(hash)))
+	})
+
+	It("generates a new htpasswd hash, if the password changed", func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("oldpassword"), bcrypt.MinCost)
+		Expect(err).ToNot(HaveOccurred())
+		f.qSecret.Status.Generated = true
+		f.secrets["generated-secret"] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "generated-secret",
+				Namespace: "default",
+				Labels:    map[string]string{qsv1a1.LabelKind: qsv1a1.GeneratedSecretKind},
+			},
+			Data: map[string][]byte{"htpasswd": []byte("admin:" + string(hash))},
+		}
+
+		_, err = reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(f.client.UpdateCallCount()).To(Equal(1))
+		_, object, _ := f.client.UpdateArgsForCall(0)
+		parts := strings.SplitN(object.(*corev1.Secret).StringData["htpasswd"], ":", 2)
+		Expect(parts[1]).ToNot(Equal(string(hash)))
+		Expect(bcrypt.CompareHashAndPassword([]byte(parts[1]), []byte("securepassword"))).To(Succeed())
+	})
+
+	It("does not overwrite a secret created by a user", func() {
+		f.secrets["generated-secret"] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "generated-secret", Namespace: "default"},
+		}
+
+		_, err := reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.client.CreateCallCount()).To(Equal(0))
+		Expect(f.client.UpdateCallCount()).To(Equal(0))
+	})
+
+	It("waits for referenced secrets, which don't exist yet", func() {
+		delete(f.secrets, "db-user")
+
+		result, err := reconciler.Reconcile(f.request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(reconcile.Result{}))
+		Expect(f.client.CreateCallCount()).To(Equal(0))
+
+		Expect(f.statusWriter.UpdateCallCount()).To(Equal(1))
+		_, object, _ := f.statusWriter.UpdateArgsForCall(0)
+		condition := object.(*qsv1a1.QuarksSecret).Status.GetCondition(qsv1a1.GeneratedCondition)
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("WaitingForValues"))
+	})
+
+	Context("when a value references a QuarksSecret", func() {
+		BeforeEach(func() {
+			f.qSecret.Spec.Request.TemplatedConfig.Values["password"] = qsv1a1.TemplatedValueReference{QuarksSecretName: "db-password", Key: "password"}
+			f.qSecrets["db-password"] = &qsv1a1.QuarksSecret{
+				ObjectMeta: metav1.ObjectMeta{Name: "db-password", Namespace: "default"},
+				Spec:       qsv1a1.QuarksSecretSpec{Type: "password", SecretName: "gen-db-password"},
+				Status:     qsv1a1.QuarksSecretStatus{Generated: true},
+			}
+			f.secrets["gen-db-password"] = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "gen-db-password", Namespace: "default"},
+				Data:       map[string][]byte{"password": []byte("generatedpassword")},
+			}
+		})
+
+		It("renders the templates with the value of the generated secret", func() {
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+
+			secret := renderedSecret()
+			Expect(secret.StringData["url"]).To(Equal("postgres://admin:generatedpassword@db:5432"))
+		})
+
+		It("waits for the QuarksSecret to be generated", func() {
+			f.qSecrets["db-password"].Status.Generated = false
+
+			result, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+			Expect(f.client.CreateCallCount()).To(Equal(0))
+
+			_, object, _ := f.statusWriter.UpdateArgsForCall(0)
+			condition := object.(*qsv1a1.QuarksSecret).Status.GetCondition(qsv1a1.GeneratedCondition)
+			Expect(condition.Reason).To(Equal("WaitingForValues"))
+			Expect(condition.Message).To(ContainSubstring("quarksSecret 'db-password' is not generated yet"))
+		})
+
+		It("waits for the QuarksSecret to exist", func() {
+			delete(f.qSecrets, "db-password")
+
+			_, err := reconciler.Reconcile(f.request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.client.CreateCallCount()).To(Equal(0))
+
+			_, object, _ := f.statusWriter.UpdateArgsForCall(0)
+			condition := object.(*qsv1a1.QuarksSecret).Status.GetCondition(qsv1a1.GeneratedCondition)
+			Expect(condition.Message).To(ContainSubstring("quarksSecret 'db-password' not found"))
+		})
+	})
+
+	It("fails for templates using unknown values", func() {
+		f.qSecret.Spec.Request.TemplatedConfig.Templates["url"] = "{{ .Values.host }}"
+
+		_, err := reconciler.Reconcile(f.request)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("could not render template of key 'url'"))
+		Expect(f.client.CreateCallCount()).To(Equal(0))
+	})
+})
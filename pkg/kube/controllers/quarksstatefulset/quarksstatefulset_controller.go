@@ -6,8 +6,11 @@ import (
 	"reflect"
 
 	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -17,7 +20,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/statefulset"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/pause"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/reference"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
@@ -172,5 +178,73 @@ func AddQuarksStatefulSet(ctx context.Context, config *config.Config, mgr manage
 		return errors.Wrapf(err, "Watching secrets failed in QuarksStatefulSet controller failed.")
 	}
 
+	// Watch for resumed BOSHDeployments, to apply the changes made while the deployment was paused
+	bdplPredicates := predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc:  pause.IsResumed,
+	}
+	err = c.Watch(&source.Kind{Type: &bdv1.BOSHDeployment{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			qstsList := &qstsv1a1.QuarksStatefulSetList{}
+			err := mgr.GetClient().List(ctx, qstsList,
+				crc.InNamespace(a.Meta.GetNamespace()),
+				crc.MatchingLabels{bdv1.LabelDeploymentName: a.Meta.GetName()},
+			)
+			if err != nil {
+				ctxlog.Errorf(ctx, "Failed to list QuarksStatefulSets of BOSHDeployment '%s': %v", a.Meta.GetName(), err)
+			}
+
+			reconciles := []reconcile.Request{}
+			for _, qsts := range qstsList.Items {
+				reconciliation := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: qsts.Namespace, Name: qsts.Name}}
+				ctxlog.NewMappingEvent(a.Object).Debug(ctx, reconciliation, "QuarksStatefulSet", a.Meta.GetName(), "boshdeployment")
+				reconciles = append(reconciles, reconciliation)
+			}
+			return reconciles
+		}),
+	}, bdplPredicates)
+	if err != nil {
+		return errors.Wrapf(err, "Watching BOSHDeployments failed in QuarksStatefulSet controller.")
+	}
+
+	// Watch for finished rollouts of StatefulSets, to update the next zone
+	statefulSetPredicates := predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldState := e.MetaOld.GetAnnotations()[statefulset.AnnotationCanaryRollout]
+			newState := e.MetaNew.GetAnnotations()[statefulset.AnnotationCanaryRollout]
+			return oldState != newState && newState == qstsv1a1.RolloutPhaseDone
+		},
+	}
+	err = c.Watch(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			name, ok := a.Meta.GetLabels()[qstsv1a1.LabelQStsName]
+			if !ok {
+				return []reconcile.Request{}
+			}
+
+			qsts := &qstsv1a1.QuarksStatefulSet{}
+			err := mgr.GetClient().Get(ctx, types.NamespacedName{Namespace: a.Meta.GetNamespace(), Name: name}, qsts)
+			if err != nil {
+				ctxlog.Errorf(ctx, "Failed to get QuarksStatefulSet '%s' of StatefulSet '%s': %v", name, a.Meta.GetName(), err)
+				return []reconcile.Request{}
+			}
+			if !isSerialZoneUpdate(qsts) {
+				return []reconcile.Request{}
+			}
+
+			reconciliation := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: qsts.Namespace, Name: qsts.Name}}
+			ctxlog.NewMappingEvent(a.Object).Debug(ctx, reconciliation, "QuarksStatefulSet", a.Meta.GetName(), "statefulset")
+			return []reconcile.Request{reconciliation}
+		}),
+	}, statefulSetPredicates)
+	if err != nil {
+		return errors.Wrapf(err, "Watching StatefulSets failed in QuarksStatefulSet controller.")
+	}
+
 	return nil
 }
@@ -25,6 +25,7 @@ import (
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/statefulset"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/mutate"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/pause"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
@@ -100,6 +101,15 @@ func (r *ReconcileQuarksStatefulSet) Reconcile(request reconcile.Request) (recon
 		return reconcile.Result{}, err
 	}
 
+	paused, err := pause.IsPaused(ctx, r.client, qStatefulSet.Namespace, qStatefulSet.GetLabels())
+	if err != nil {
+		return reconcile.Result{}, ctxlog.WithEvent(qStatefulSet, "GetBOSHDeploymentError").Errorf(ctx, "Could not check if QuarksStatefulSet '%s' is paused: %v", request.NamespacedName, err)
+	}
+	if paused {
+		ctxlog.WithEvent(qStatefulSet, "Paused").Infof(ctx, "Skip QuarksStatefulSet reconcile: BOSHDeployment of '%s' is paused", request.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
 	// Update labels of versioned secrets in quarksStatefulSet spec
 	err = r.UpdateVersions(ctx, qStatefulSet)
 	if err != nil {
@@ -117,15 +127,24 @@ func (r *ReconcileQuarksStatefulSet) Reconcile(request reconcile.Request) (recon
 		return reconcile.Result{}, ctxlog.WithEvent(qStatefulSet, "CalculationError").Error(ctx, "Could not calculate StatefulSet owned by QuarksStatefulSet '", request.NamespacedName, "': ", err)
 	}
 
-	for _, desiredStatefulSet := range desiredStatefulSets {
-		// If it doesn't exist, create it
-		ctxlog.Info(ctx, "StatefulSet '", desiredStatefulSet.Name, "' owned by QuarksStatefulSet '", request.NamespacedName, "' not found, will be created.")
-
+	if isSerialZoneUpdate(qStatefulSet) {
 		if err = r.versionedSecretStore.SetSecretReferences(ctx, request.Namespace, &qStatefulSet.Spec.Template.Spec.Template.Spec); err != nil {
 			return reconcile.Result{}, ctxlog.WithEvent(qStatefulSet, "UpdateVersionedSecretReferencesError").Error(ctx, "Could not update versioned secret references in pod spec for QuarksStatefulSet '", request.NamespacedName, "': ", err)
 		}
-		if err := r.createStatefulSet(ctx, qStatefulSet, &desiredStatefulSet); err != nil {
-			return reconcile.Result{}, ctxlog.WithEvent(qStatefulSet, "CreateStatefulSetError").Error(ctx, "Could not create StatefulSet for QuarksStatefulSet '", request.NamespacedName, "': ", err)
+		if err := r.updateZonesSerially(ctx, qStatefulSet, desiredStatefulSets); err != nil {
+			return reconcile.Result{}, ctxlog.WithEvent(qStatefulSet, "CreateStatefulSetError").Error(ctx, "Could not update StatefulSets of zones for QuarksStatefulSet '", request.NamespacedName, "': ", err)
+		}
+	} else {
+		for _, desiredStatefulSet := range desiredStatefulSets {
+			// If it doesn't exist, create it
+			ctxlog.Info(ctx, "StatefulSet '", desiredStatefulSet.Name, "' owned by QuarksStatefulSet '", request.NamespacedName, "' not found, will be created.")
+
+			if err = r.versionedSecretStore.SetSecretReferences(ctx, request.Namespace, &qStatefulSet.Spec.Template.Spec.Template.Spec); err != nil {
+				return reconcile.Result{}, ctxlog.WithEvent(qStatefulSet, "UpdateVersionedSecretReferencesError").Error(ctx, "Could not update versioned secret references in pod spec for QuarksStatefulSet '", request.NamespacedName, "': ", err)
+			}
+			if err := r.createStatefulSet(ctx, qStatefulSet, &desiredStatefulSet); err != nil {
+				return reconcile.Result{}, ctxlog.WithEvent(qStatefulSet, "CreateStatefulSetError").Error(ctx, "Could not create StatefulSet for QuarksStatefulSet '", request.NamespacedName, "': ", err)
+			}
 		}
 	}
 
@@ -254,6 +273,19 @@ func (r *ReconcileQuarksStatefulSet) generateSingleStatefulSet(qStatefulSet *qst
 	annotations[qstsv1a1.AnnotationVersion] = strconv.Itoa(version)
 	annotations[statefulset.AnnotationCanaryRolloutEnabled] = "true"
 
+	// The rollout policy is only set on the StatefulSet, so changing it doesn't restart the pods
+	rolloutAnnotations := make(map[string]string)
+	if isSerialZoneUpdate(qStatefulSet) && zoneIndex > 0 {
+		// Only the first zone is updated with canaries
+		rolloutAnnotations[statefulset.AnnotationCanaries] = "0"
+	}
+	if qStatefulSet.Spec.RollbackOnFailure {
+		rolloutAnnotations[statefulset.AnnotationRollbackOnFailure] = "true"
+	}
+	if qStatefulSet.Spec.PauseAfterCanary {
+		rolloutAnnotations[statefulset.AnnotationPauseAfterCanary] = "true"
+	}
+
 	// Set updated properties
 	statefulSet.Spec.Template.SetLabels(util.UnionMaps(statefulSet.Spec.Template.GetLabels(), labels))
 	statefulSet.Spec.Template.SetAnnotations(util.UnionMaps(statefulSet.Spec.Template.GetAnnotations(), annotations))
@@ -262,9 +294,14 @@ func (r *ReconcileQuarksStatefulSet) generateSingleStatefulSet(qStatefulSet *qst
 	statefulSet.Spec.Selector = &metav1.LabelSelector{
 		MatchLabels: labels,
 	}
-	statefulSet.SetAnnotations(util.UnionMaps(statefulSet.GetAnnotations(), annotations))
+	statefulSet.SetAnnotations(util.UnionMaps(statefulSet.GetAnnotations(), annotations, rolloutAnnotations))
 
 	r.injectContainerEnv(&statefulSet.Spec.Template.Spec, zoneIndex, zoneName, qStatefulSet.Spec.Template.Spec.Replicas)
+	if isSerialZoneUpdate(qStatefulSet) {
+		if err := setTemplateHash(statefulSet); err != nil {
+			return &appsv1.StatefulSet{}, err
+		}
+	}
 	return statefulSet, nil
 }
 
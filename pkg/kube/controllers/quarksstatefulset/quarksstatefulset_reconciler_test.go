@@ -21,10 +21,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers"
 	cfakes "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/fakes"
 	qstscontroller "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/quarksstatefulset"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/statefulset"
 	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
@@ -147,6 +149,29 @@ var _ = Describe("ReconcileQuarksStatefulSet", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
+			Context("when the BOSHDeployment is paused", func() {
+				BeforeEach(func() {
+					desiredQStatefulSet.Labels = map[string]string{bdv1.LabelDeploymentName: "foo-deployment"}
+					bdpl := &bdv1.BOSHDeployment{
+						ObjectMeta: metav1.ObjectMeta{Name: "foo-deployment", Namespace: "default"},
+						Spec:       bdv1.BOSHDeploymentSpec{Paused: true},
+					}
+
+					client = fake.NewFakeClient(desiredQStatefulSet, bdpl)
+					manager.GetClientReturns(client)
+				})
+
+				It("doesn't create the statefulSet", func() {
+					result, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(result).To(Equal(reconcile.Result{}))
+
+					ss := &appsv1.StatefulSet{}
+					err = client.Get(context.Background(), types.NamespacedName{Name: "foo", Namespace: "default"}, ss)
+					Expect(errors.IsNotFound(err)).To(BeTrue())
+				})
+			})
+
 			Context("with multiple replicas", func() {
 				var ss *appsv1.StatefulSet
 				BeforeEach(func() {
@@ -176,6 +201,66 @@ var _ = Describe("ReconcileQuarksStatefulSet", func() {
 					Expect(ss.Spec.Template.GetLabels()).To(HaveKeyWithValue("quarks.cloudfoundry.org/az-index", "0"))
 				})
 
+				It("doesn't annotate the template hash, which is only needed for serial zone updates", func() {
+					Expect(ss.Annotations).ToNot(HaveKey(qstsv1a1.AnnotationTemplateHash))
+				})
+
+				It("doesn't enable the rollback of failed rollouts", func() {
+					Expect(ss.Annotations).ToNot(HaveKey("quarks.cloudfoundry.org/rollback-on-failure"))
+				})
+
+				Context("when rollback on failure is enabled", func() {
+					BeforeEach(func() {
+						desiredQStatefulSet.Spec.RollbackOnFailure = true
+						client = fake.NewFakeClient(
+							desiredQStatefulSet,
+						)
+						manager.GetClientReturns(client)
+					})
+
+					It("sets annotation to roll back failed rollouts", func() {
+						Expect(ss.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/rollback-on-failure", "true"))
+					})
+				})
+
+				Context("when pausing after the canaries is enabled", func() {
+					BeforeEach(func() {
+						desiredQStatefulSet.Spec.PauseAfterCanary = true
+						client = fake.NewFakeClient(
+							desiredQStatefulSet,
+						)
+						manager.GetClientReturns(client)
+					})
+
+					It("sets annotation to pause the rollout after the canaries", func() {
+						Expect(ss.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/pause-after-canary", "true"))
+					})
+				})
+
+				It("doesn't change the pod template when the rollout policy changes", func() {
+					template := ss.Spec.Template.DeepCopy()
+
+					qStatefulSet := &qstsv1a1.QuarksStatefulSet{}
+					err := client.Get(context.Background(), types.NamespacedName{Name: "foo", Namespace: "default"}, qStatefulSet)
+					Expect(err).ToNot(HaveOccurred())
+					qStatefulSet.Spec.RollbackOnFailure = true
+					qStatefulSet.Spec.PauseAfterCanary = true
+					Expect(client.Update(context.Background(), qStatefulSet)).To(Succeed())
+
+					_, err = reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+
+					err = client.Get(context.Background(), types.NamespacedName{Name: "foo", Namespace: "default"}, ss)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ss.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/rollback-on-failure", "true"))
+					Expect(ss.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/pause-after-canary", "true"))
+
+					// The version changes with every reconcile
+					delete(template.Annotations, qstsv1a1.AnnotationVersion)
+					delete(ss.Spec.Template.Annotations, qstsv1a1.AnnotationVersion)
+					Expect(ss.Spec.Template).To(Equal(*template))
+				})
+
 			})
 
 			Context("When zones has the values", func() {
@@ -386,6 +471,99 @@ var _ = Describe("ReconcileQuarksStatefulSet", func() {
 						}
 					})
 				})
+
+				When("zones are updated serially", func() {
+					getStatefulSet := func(name string) *appsv1.StatefulSet {
+						ss := &appsv1.StatefulSet{}
+						err := client.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, ss)
+						Expect(err).ToNot(HaveOccurred())
+						return ss
+					}
+
+					setRolloutState := func(name string, state string) {
+						ss := getStatefulSet(name)
+						ss.Annotations[statefulset.AnnotationCanaryRollout] = state
+						Expect(client.Update(context.Background(), ss)).To(Succeed())
+					}
+
+					BeforeEach(func() {
+						desiredQStatefulSet.Spec.ZoneUpdateStrategy = qstsv1a1.SerialZoneUpdate
+
+						client = fake.NewFakeClient(
+							desiredQStatefulSet,
+						)
+						manager.GetClientReturns(client)
+					})
+
+					It("creates the StatefulSets of all zones with canaries only in the first zone", func() {
+						_, err := reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+
+						for idx, name := range []string{"foo-z0", "foo-z1", "foo-z2"} {
+							ss := getStatefulSet(name)
+							Expect(ss.Annotations).To(HaveKey(qstsv1a1.AnnotationTemplateHash))
+							if idx == 0 {
+								Expect(ss.Annotations).ToNot(HaveKey(statefulset.AnnotationCanaries))
+							} else {
+								Expect(ss.Annotations).To(HaveKeyWithValue(statefulset.AnnotationCanaries, "0"))
+							}
+							Expect(ss.Spec.Template.Annotations).ToNot(HaveKey(statefulset.AnnotationCanaries))
+						}
+					})
+
+					It("updates the next zone once the rollout of the previous zone is done", func() {
+						_, err := reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						oldHash := getStatefulSet("foo-z0").Annotations[qstsv1a1.AnnotationTemplateHash]
+						for _, name := range []string{"foo-z0", "foo-z1", "foo-z2"} {
+							setRolloutState(name, qstsv1a1.RolloutPhaseDone)
+						}
+
+						qStatefulSet := &qstsv1a1.QuarksStatefulSet{}
+						err = client.Get(context.Background(), types.NamespacedName{Name: "foo", Namespace: "default"}, qStatefulSet)
+						Expect(err).ToNot(HaveOccurred())
+						qStatefulSet.Spec.Template.Spec.Replicas = pointers.Int32(2)
+						Expect(client.Update(context.Background(), qStatefulSet)).To(Succeed())
+
+						_, err = reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(*getStatefulSet("foo-z0").Spec.Replicas).To(Equal(int32(2)))
+						Expect(getStatefulSet("foo-z0").Annotations[qstsv1a1.AnnotationTemplateHash]).ToNot(Equal(oldHash))
+						Expect(*getStatefulSet("foo-z1").Spec.Replicas).To(Equal(int32(1)))
+						Expect(*getStatefulSet("foo-z2").Spec.Replicas).To(Equal(int32(1)))
+
+						By("waiting while the first zone rolls out")
+						setRolloutState("foo-z0", "Rollout")
+						_, err = reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(*getStatefulSet("foo-z1").Spec.Replicas).To(Equal(int32(1)))
+
+						By("updating the second zone once the first zone is done")
+						setRolloutState("foo-z0", qstsv1a1.RolloutPhaseDone)
+						_, err = reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(*getStatefulSet("foo-z1").Spec.Replicas).To(Equal(int32(2)))
+						Expect(*getStatefulSet("foo-z2").Spec.Replicas).To(Equal(int32(1)))
+					})
+
+					It("doesn't update the remaining zones after a rollback", func() {
+						_, err := reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+
+						ss := getStatefulSet("foo-z0")
+						ss.Annotations[statefulset.AnnotationCanaryRollout] = qstsv1a1.RolloutPhaseDone
+						ss.Annotations[statefulset.AnnotationRolledBackRevision] = "2"
+						Expect(client.Update(context.Background(), ss)).To(Succeed())
+
+						ss = getStatefulSet("foo-z1")
+						ss.Annotations[qstsv1a1.AnnotationTemplateHash] = "outdated"
+						Expect(client.Update(context.Background(), ss)).To(Succeed())
+
+						_, err = reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(getStatefulSet("foo-z1").Annotations).To(HaveKeyWithValue(qstsv1a1.AnnotationTemplateHash, "outdated"))
+					})
+				})
 			})
 		})
 
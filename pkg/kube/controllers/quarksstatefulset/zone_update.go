@@ -0,0 +1,103 @@
+package quarksstatefulset
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/statefulset"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// isSerialZoneUpdate returns true if the zones of the QuarksStatefulSet are updated one at a time
+func isSerialZoneUpdate(qStatefulSet *qstsv1a1.QuarksStatefulSet) bool {
+	return qStatefulSet.Spec.ZoneUpdateStrategy == qstsv1a1.SerialZoneUpdate && len(qStatefulSet.Spec.Zones) > 1
+}
+
+// templateHash returns a hash of the desired stateful set. The version
+// annotation is left out, as it changes with every reconcile.
+func templateHash(statefulSet *appsv1.StatefulSet) (string, error) {
+	desired := statefulSet.DeepCopy()
+	delete(desired.Annotations, qstsv1a1.AnnotationVersion)
+	delete(desired.Spec.Template.Annotations, qstsv1a1.AnnotationVersion)
+
+	data, err := json.Marshal(struct {
+		Labels      map[string]string
+		Annotations map[string]string
+		Spec        appsv1.StatefulSetSpec
+	}{desired.Labels, desired.Annotations, desired.Spec})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setTemplateHash annotates the stateful set with the hash of its desired state
+func setTemplateHash(statefulSet *appsv1.StatefulSet) error {
+	hash, err := templateHash(statefulSet)
+	if err != nil {
+		return errors.Wrapf(err, "could not calculate hash of StatefulSet '%s'", statefulSet.Name)
+	}
+	statefulSet.Annotations[qstsv1a1.AnnotationTemplateHash] = hash
+	return nil
+}
+
+// isZoneRolledOut returns true if the rollout of the stateful set is done.
+// A rolled back rollout blocks the remaining zones, until the next update.
+func isZoneRolledOut(statefulSet *appsv1.StatefulSet) bool {
+	if _, ok := statefulSet.Annotations[statefulset.AnnotationRolledBackRevision]; ok {
+		return false
+	}
+	state, ok := statefulSet.Annotations[statefulset.AnnotationCanaryRollout]
+	return !ok || state == qstsv1a1.RolloutPhaseDone
+}
+
+// updateZonesSerially creates the missing stateful sets and updates the
+// stateful sets of the zones in order, one at a time. A zone is only updated,
+// once the rollout of all previous zones is done, like BOSH updates one AZ
+// after the other.
+func (r *ReconcileQuarksStatefulSet) updateZonesSerially(ctx context.Context, qStatefulSet *qstsv1a1.QuarksStatefulSet, desiredStatefulSets []appsv1.StatefulSet) error {
+	waitingFor := ""
+	for i := range desiredStatefulSets {
+		desiredStatefulSet := &desiredStatefulSets[i]
+
+		existing := &appsv1.StatefulSet{}
+		err := r.client.Get(ctx, types.NamespacedName{Namespace: desiredStatefulSet.Namespace, Name: desiredStatefulSet.Name}, existing)
+		if apierrors.IsNotFound(err) {
+			if err := r.createStatefulSet(ctx, qStatefulSet, desiredStatefulSet); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not get StatefulSet '%s'", desiredStatefulSet.Name)
+		}
+
+		if existing.Annotations[qstsv1a1.AnnotationTemplateHash] == desiredStatefulSet.Annotations[qstsv1a1.AnnotationTemplateHash] {
+			if waitingFor == "" && !isZoneRolledOut(existing) {
+				waitingFor = existing.Name
+			}
+			continue
+		}
+
+		if waitingFor != "" {
+			ctxlog.Infof(ctx, "Update of StatefulSet '%s' waits for the rollout of StatefulSet '%s'", desiredStatefulSet.Name, waitingFor)
+			continue
+		}
+
+		if err := r.createStatefulSet(ctx, qStatefulSet, desiredStatefulSet); err != nil {
+			return err
+		}
+		waitingFor = desiredStatefulSet.Name
+	}
+	return nil
+}
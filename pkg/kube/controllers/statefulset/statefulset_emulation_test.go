@@ -109,6 +109,12 @@ func WithReplicas(replicas int32) UpdateOpts {
 	}
 }
 
+func WithAnnotation(key string, value string) UpdateOpts {
+	return func(sse *StatefulSetEmulation) {
+		sse.statefulSet.Annotations[key] = value
+	}
+}
+
 func WithFailure() UpdateOpts {
 	return func(sse *StatefulSetEmulation) {
 		sse.failed = true
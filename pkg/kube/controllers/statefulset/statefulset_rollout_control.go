@@ -0,0 +1,99 @@
+package statefulset
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+)
+
+// Actions, which can be requested with the rollout-action annotation
+const (
+	rolloutActionPause   = "pause"
+	rolloutActionResume  = "resume"
+	rolloutActionPromote = "promote"
+	rolloutActionAbort   = "abort"
+)
+
+// isPauseAfterCanary returns true if the rollout is paused, once the canaries are ready
+func isPauseAfterCanary(statefulSet *appsv1.StatefulSet) bool {
+	enabled, ok := statefulSet.GetAnnotations()[AnnotationPauseAfterCanary]
+	return ok && enabled == "true"
+}
+
+// isRolloutActive returns true if the rollout of the stateful set has neither finished nor failed
+func isRolloutActive(state string) bool {
+	return state != rolloutStateDone && state != rolloutStateFailed
+}
+
+// handleRolloutAction applies the action of the rollout-action annotation
+// and removes the annotation. It returns true if there was an action, so
+// the reconcile is finished.
+func (r *ReconcileStatefulSetRollout) handleRolloutAction(ctx context.Context, statefulSet appsv1.StatefulSet) (bool, error) {
+	action, ok := statefulSet.Annotations[AnnotationRolloutAction]
+	if !ok {
+		return false, nil
+	}
+	state := statefulSet.Annotations[AnnotationCanaryRollout]
+
+	var finished *qstsv1a1.RolloutHistoryEntry
+	switch {
+	case action == rolloutActionPause && (state == rolloutStateCanary || state == rolloutStateRollout):
+		statefulSet.Annotations[AnnotationCanaryRollout] = rolloutStatePaused
+	case action == rolloutActionResume && state == rolloutStatePaused:
+		// The rollout continues with the next batch, once the updated pods are ready
+		statefulSet.Annotations[AnnotationCanaryRollout] = rolloutStateRollout
+		statefulSet.Annotations[AnnotationUpdateStartTime] = strconv.FormatInt(time.Now().Unix(), 10)
+	case action == rolloutActionPromote && state != rolloutStateDone:
+		// All remaining pods are updated at once
+		statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = pointers.Int32(0)
+		statefulSet.Annotations[AnnotationCanaryRollout] = rolloutStateRollout
+		statefulSet.Annotations[AnnotationUpdateStartTime] = strconv.FormatInt(time.Now().Unix(), 10)
+	case action == rolloutActionAbort && isRolloutActive(state):
+		if isRollbackEnabled(&statefulSet) && canRollback(&statefulSet) {
+			ctxlog.WithEvent(&statefulSet, "RolloutAction").Infof(ctx, "Aborting rollout of StatefulSet '%s/%s'", statefulSet.Namespace, statefulSet.Name)
+			return true, r.rollback(ctx, statefulSet)
+		}
+		statefulSet.Annotations[AnnotationCanaryRollout] = rolloutStateFailed
+		finished = finishedRollout(&statefulSet, statefulSet.Status.UpdateRevision, qstsv1a1.RolloutPhaseFailed)
+	default:
+		ctxlog.WithEvent(&statefulSet, "RolloutActionError").Errorf(ctx, "Ignoring rollout action '%s' of StatefulSet '%s/%s' in state '%s'", action, statefulSet.Namespace, statefulSet.Name, state)
+		return true, r.applyRolloutAction(ctx, &statefulSet)
+	}
+
+	ctxlog.WithEvent(&statefulSet, "RolloutAction").Infof(ctx, "Applied rollout action '%s' to StatefulSet '%s/%s' in state '%s'", action, statefulSet.Namespace, statefulSet.Name, state)
+	if err := r.applyRolloutAction(ctx, &statefulSet); err != nil {
+		return true, err
+	}
+	r.updateRolloutStatus(ctx, &statefulSet, finished)
+	return true, nil
+}
+
+// applyRolloutAction updates the rollout state of the stateful set and removes the handled action
+func (r *ReconcileStatefulSetRollout) applyRolloutAction(ctx context.Context, statefulSet *appsv1.StatefulSet) error {
+	partition := statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition
+	state := statefulSet.Annotations[AnnotationCanaryRollout]
+	startTime := statefulSet.Annotations[AnnotationUpdateStartTime]
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.client, statefulSet, func() error {
+		statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = partition
+		statefulSet.Annotations[AnnotationCanaryRollout] = state
+		statefulSet.Annotations[AnnotationUpdateStartTime] = startTime
+		delete(statefulSet.Annotations, AnnotationRolloutAction)
+		meltdown.SetLastReconcile(&statefulSet.ObjectMeta, time.Now())
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "could not apply rollout action to StatefulSet '%s/%s'", statefulSet.Namespace, statefulSet.Name)
+	}
+	return nil
+}
@@ -7,13 +7,18 @@ import (
 	"github.com/pkg/errors"
 
 	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/pause"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 )
@@ -54,12 +59,50 @@ func AddStatefulSetRollout(ctx context.Context, config *config.Config, mgr manag
 		return errors.Wrapf(err, "Watching StatefulSet failed in StatefulSet rollout controller.")
 	}
 
+	// Continue rollouts, once a paused BOSHDeployment is resumed
+	bdplPredicates := predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc:  pause.IsResumed,
+	}
+	err = c.Watch(&source.Kind{Type: &bdv1.BOSHDeployment{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			statefulSets := &appsv1.StatefulSetList{}
+			err := mgr.GetClient().List(ctx, statefulSets,
+				crc.InNamespace(a.Meta.GetNamespace()),
+				crc.MatchingLabels{bdv1.LabelDeploymentName: a.Meta.GetName()},
+			)
+			if err != nil {
+				ctxlog.Errorf(ctx, "Failed to list StatefulSets of BOSHDeployment '%s': %v", a.Meta.GetName(), err)
+			}
+
+			reconciles := []reconcile.Request{}
+			for _, sts := range statefulSets.Items {
+				if _, ok := sts.Annotations[AnnotationCanaryRollout]; !ok {
+					continue
+				}
+				reconciliation := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: sts.Namespace, Name: sts.Name}}
+				ctxlog.NewMappingEvent(a.Object).Debug(ctx, reconciliation, "StatefulSet", a.Meta.GetName(), "boshdeployment")
+				reconciles = append(reconciles, reconciliation)
+			}
+			return reconciles
+		}),
+	}, bdplPredicates)
+	if err != nil {
+		return errors.Wrapf(err, "Watching BOSHDeployments failed in StatefulSet rollout controller.")
+	}
+
 	return nil
 }
 
 // CheckUpdate checks if update event should be processed
 func CheckUpdate(e event.UpdateEvent) bool {
 	newSts := e.ObjectNew.(*appsv1.StatefulSet)
+	oldSts := e.ObjectOld.(*appsv1.StatefulSet)
+	if action, ok := newSts.Annotations[AnnotationRolloutAction]; ok && action != oldSts.Annotations[AnnotationRolloutAction] {
+		return true
+	}
 	state, ok := newSts.Annotations[AnnotationCanaryRollout]
 	if !ok || state == rolloutStateDone || state == rolloutStateFailed {
 		return false
@@ -67,7 +110,6 @@ func CheckUpdate(e event.UpdateEvent) bool {
 	if state == rolloutStatePending {
 		return true
 	}
-	oldSts := e.ObjectOld.(*appsv1.StatefulSet)
 	if oldSts.Status.ReadyReplicas == newSts.Status.ReadyReplicas &&
 		oldSts.Status.UpdatedReplicas == newSts.Status.UpdatedReplicas &&
 		oldSts.Status.Replicas == newSts.Status.Replicas {
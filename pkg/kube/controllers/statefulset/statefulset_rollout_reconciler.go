@@ -9,6 +9,8 @@ import (
 	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
 
 	"code.cloudfoundry.org/cf-operator/pkg/kube/apis"
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/pause"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
@@ -29,6 +31,7 @@ const (
 	rolloutStateDone          = "Done"
 	rolloutStateFailed        = "Failed"
 	rolloutStateCanaryUpscale = "CanaryUpscale"
+	rolloutStatePaused        = "Paused"
 )
 
 var (
@@ -40,8 +43,22 @@ var (
 	AnnotationCanaryWatchTime = fmt.Sprintf("%s/canary-watch-time-ms", apis.GroupName)
 	// AnnotationUpdateWatchTime is the max time for the complete update
 	AnnotationUpdateWatchTime = fmt.Sprintf("%s/update-watch-time-ms", apis.GroupName)
+	// AnnotationCanaries is the number of pods updated in the canary phase
+	AnnotationCanaries = fmt.Sprintf("%s/canaries", apis.GroupName)
+	// AnnotationMaxInFlight is the number or percentage of pods updated at once after the canaries
+	AnnotationMaxInFlight = fmt.Sprintf("%s/max-in-flight", apis.GroupName)
+	// AnnotationRollbackOnFailure if set to "true" a failed rollout is rolled back to the previous revision
+	AnnotationRollbackOnFailure = fmt.Sprintf("%s/rollback-on-failure", apis.GroupName)
+	// AnnotationRolledBackRevision is the failed revision, which was rolled back
+	AnnotationRolledBackRevision = fmt.Sprintf("%s/rolled-back-revision", apis.GroupName)
+	// AnnotationPauseAfterCanary if set to "true" the rollout is paused, once the canaries are ready
+	AnnotationPauseAfterCanary = fmt.Sprintf("%s/pause-after-canary", apis.GroupName)
+	// AnnotationRolloutAction is an action requested by the user, i.e. pause, resume, promote or abort
+	AnnotationRolloutAction = fmt.Sprintf("%s/rollout-action", apis.GroupName)
 	// AnnotationUpdateStartTime is the timestamp when the update started
 	AnnotationUpdateStartTime = fmt.Sprintf("%s/update-start-time", apis.GroupName)
+	// AnnotationPausedTime is the timestamp when the rollout was paused with its BOSHDeployment
+	AnnotationPausedTime = fmt.Sprintf("%s/paused-time", apis.GroupName)
 )
 
 // NewStatefulSetRolloutReconciler returns a new reconcile.Reconciler
@@ -78,13 +95,32 @@ func (r *ReconcileStatefulSetRollout) Reconcile(request reconcile.Request) (reco
 		return reconcile.Result{}, err
 	}
 
+	paused, err := pause.IsPaused(ctx, r.client, statefulSet.Namespace, statefulSet.GetLabels())
+	if err != nil {
+		ctxlog.Error(ctx, "Could not check if StatefulSet is paused ", request.NamespacedName, err)
+		return reconcile.Result{}, err
+	}
+	if paused {
+		ctxlog.WithEvent(&statefulSet, "Paused").Infof(ctx, "Skip StatefulSet rollout: BOSHDeployment of '%s' is paused", request.NamespacedName)
+		return reconcile.Result{}, r.recordPause(ctx, &statefulSet)
+	}
+
+	if resumed, err := r.resumeAfterPause(ctx, &statefulSet); resumed || err != nil {
+		return reconcile.Result{Requeue: resumed}, err
+	}
+
 	if meltdown.NewAnnotationWindow(r.config.MeltdownDuration, statefulSet.Annotations).Contains(time.Now()) {
 		ctxlog.WithEvent(&statefulSet, "Meltdown").Debugf(ctx, "Resource '%s' is in meltdown, requeue reconcile after %s", statefulSet.Name, r.config.MeltdownRequeueAfter)
 		return reconcile.Result{RequeueAfter: r.config.MeltdownRequeueAfter}, nil
 	}
 
+	if handled, err := r.handleRolloutAction(ctx, statefulSet); handled || err != nil {
+		return reconcile.Result{}, err
+	}
+
 	var status = statefulSet.Annotations[AnnotationCanaryRollout]
-	if status == rolloutStateFailed || status == rolloutStateDone {
+	if status == rolloutStateFailed || status == rolloutStateDone || status == rolloutStatePaused {
+		r.updateRolloutStatus(ctx, &statefulSet, nil)
 		return reconcile.Result{}, nil
 	}
 
@@ -111,21 +147,20 @@ func (r *ReconcileStatefulSetRollout) Reconcile(request reconcile.Request) (reco
 			if *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition == 0 {
 				newStatus = rolloutStateDone
 			} else {
-				(*statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)--
+				movePartition(&statefulSet, getMaxInFlight(ctx, statefulSet))
 				newStatus = rolloutStateRollout
 			}
 		}
 	case rolloutStateCanary:
 		if getTimeOut(ctx, statefulSet, AnnotationCanaryWatchTime) < 0 {
-			newStatus = rolloutStateFailed
-			break
+			return reconcile.Result{}, r.failRollout(ctx, statefulSet)
 		}
 		fallthrough
 	case rolloutStateRollout:
 		if resultWithRetrigger.RequeueAfter > time.Minute {
 			resultWithRetrigger.RequeueAfter = time.Minute
 		}
-		ready, err := partitionPodIsReadyAndUpdated(ctx, r.client, &statefulSet)
+		ready, err := partitionPodsAreReadyAndUpdated(ctx, r.client, &statefulSet)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
@@ -138,17 +173,27 @@ func (r *ReconcileStatefulSetRollout) Reconcile(request reconcile.Request) (reco
 		if !ready {
 			break
 		}
-		(*statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)--
+		if status == rolloutStateCanary && isPauseAfterCanary(&statefulSet) {
+			ctxlog.WithEvent(&statefulSet, "RolloutPaused").Infof(ctx, "Canaries of StatefulSet '%s' are ready, pausing rollout", request.NamespacedName)
+			newStatus = rolloutStatePaused
+			break
+		}
+		movePartition(&statefulSet, getMaxInFlight(ctx, statefulSet))
 		dirty = true
 		newStatus = rolloutStateRollout
 	case rolloutStatePending:
 		if statefulSet.Status.Replicas < *statefulSet.Spec.Replicas {
 			newStatus = rolloutStateCanaryUpscale
 			resultWithRetrigger.RequeueAfter = getTimeOut(ctx, statefulSet, AnnotationUpdateWatchTime)
+		} else if canaries := getCanaries(ctx, statefulSet); canaries == 0 {
+			// Without canaries the rollout starts with the first batch
+			newStatus = rolloutStateRollout
+			movePartition(&statefulSet, getMaxInFlight(ctx, statefulSet))
+			dirty = true
 		} else {
 			resultWithRetrigger.RequeueAfter = getTimeOut(ctx, statefulSet, AnnotationCanaryWatchTime)
 			newStatus = rolloutStateCanary
-			(*statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)--
+			movePartition(&statefulSet, canaries)
 			dirty = true
 		}
 	}
@@ -163,17 +208,18 @@ func (r *ReconcileStatefulSetRollout) Reconcile(request reconcile.Request) (reco
 			return reconcile.Result{}, err
 		}
 	}
+
+	var finished *qstsv1a1.RolloutHistoryEntry
+	if statusChanged && newStatus == rolloutStateDone {
+		finished = finishedRollout(&statefulSet, statefulSet.Status.UpdateRevision, qstsv1a1.RolloutPhaseDone)
+	}
+	r.updateRolloutStatus(ctx, &statefulSet, finished)
 	return resultWithRetrigger, nil
 }
 
 func (r *ReconcileStatefulSetRollout) failIfTimedOut(ctx context.Context, statefulSet appsv1.StatefulSet, timeout string) (bool, error) {
 	if getTimeOut(ctx, statefulSet, timeout) < 0 {
-		statefulSet.Annotations[AnnotationCanaryRollout] = rolloutStateFailed
-		if err := r.updateStatefulSet(ctx, &statefulSet); err != nil {
-			ctxlog.Debug(ctx, "Error updating StatefulSet ", statefulSet.Name, err)
-			return true, err
-		}
-		return true, nil
+		return true, r.failRollout(ctx, statefulSet)
 	}
 	return false, nil
 }
@@ -184,8 +230,10 @@ func (r *ReconcileStatefulSetRollout) updateWithPartitionMove(ctx context.Contex
 		return err
 	}
 
-	if oldPartition != *statefulset.Spec.UpdateStrategy.RollingUpdate.Partition {
-		err = CleanupNonReadyPod(ctx, r.client, &statefulset, *statefulset.Spec.UpdateStrategy.RollingUpdate.Partition)
+	// Pods of the batch, which are not ready, are deleted to be recreated with the new version
+	partition := *statefulset.Spec.UpdateStrategy.RollingUpdate.Partition
+	for index := partition; index < oldPartition; index++ {
+		err = CleanupNonReadyPod(ctx, r.client, &statefulset, index)
 		if err != nil {
 			return err
 		}
@@ -194,6 +242,43 @@ func (r *ReconcileStatefulSetRollout) updateWithPartitionMove(ctx context.Contex
 
 }
 
+// movePartition lowers the partition by the batch size, so the next batch of pods is updated
+func movePartition(statefulSet *appsv1.StatefulSet, batch int32) {
+	partition := *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition - batch
+	if partition < 0 {
+		partition = 0
+	}
+	statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = pointers.Int32(partition)
+}
+
+// getCanaries returns the number of pods updated in the canary phase, zero skips the canary phase
+func getCanaries(ctx context.Context, statefulSet appsv1.StatefulSet) int32 {
+	canariesStr, ok := statefulSet.Annotations[AnnotationCanaries]
+	if !ok || canariesStr == "" {
+		return 1
+	}
+	canaries, err := strconv.Atoi(canariesStr)
+	if err != nil || canaries < 0 {
+		ctxlog.Errorf(ctx, "Invalid annotation %s: %s", AnnotationCanaries, canariesStr)
+		return 1
+	}
+	return int32(canaries)
+}
+
+// getMaxInFlight returns the number of pods updated at once after the canaries
+func getMaxInFlight(ctx context.Context, statefulSet appsv1.StatefulSet) int32 {
+	maxInFlightStr, ok := statefulSet.Annotations[AnnotationMaxInFlight]
+	if !ok || maxInFlightStr == "" {
+		return 1
+	}
+	batch, err := maxInFlight(maxInFlightStr, *statefulSet.Spec.Replicas)
+	if err != nil {
+		ctxlog.Errorf(ctx, "Invalid annotation %s: %s", AnnotationMaxInFlight, maxInFlightStr)
+		return 1
+	}
+	return batch
+}
+
 func getTimeOut(ctx context.Context, statefulSet appsv1.StatefulSet, watchTimeAnnotation string) time.Duration {
 	watchTimeStr, ok := statefulSet.Annotations[watchTimeAnnotation]
 	if !ok || watchTimeStr == "" {
@@ -244,19 +329,81 @@ func (r *ReconcileStatefulSetRollout) updateStatefulSet(ctx context.Context, sta
 	return nil
 }
 
-func partitionPodIsReadyAndUpdated(ctx context.Context, client crc.Client, statefulSet *appsv1.StatefulSet) (bool, error) {
-	ready := false
-	updated := false
-	if statefulSet.Spec.UpdateStrategy.RollingUpdate != nil {
-		pod, podReady, err := getPodWithIndex(ctx, client, statefulSet, *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+// recordPause stores the time the BOSHDeployment of an ongoing rollout was
+// paused, so the watch times can exclude the paused duration
+func (r *ReconcileStatefulSetRollout) recordPause(ctx context.Context, statefulSet *appsv1.StatefulSet) error {
+	if _, ok := statefulSet.Annotations[AnnotationPausedTime]; ok {
+		return nil
+	}
+	if _, ok := statefulSet.Annotations[AnnotationUpdateStartTime]; !ok {
+		return nil
+	}
+	if !isRolloutActive(statefulSet.Annotations[AnnotationCanaryRollout]) {
+		return nil
+	}
+
+	statefulSet.Annotations[AnnotationPausedTime] = strconv.FormatInt(time.Now().Unix(), 10)
+	if err := r.client.Update(ctx, statefulSet); err != nil {
+		return ctxlog.WithEvent(statefulSet, "UpdateError").Errorf(ctx, "Error recording pause time of StatefulSet '%s/%s': %s", statefulSet.Namespace, statefulSet.Name, err)
+	}
+	return nil
+}
+
+// resumeAfterPause shifts the update start time by the duration the
+// BOSHDeployment was paused and removes the pause time. It returns true if
+// the StatefulSet was updated, so the rollout continues with a new reconcile.
+func (r *ReconcileStatefulSetRollout) resumeAfterPause(ctx context.Context, statefulSet *appsv1.StatefulSet) (bool, error) {
+	pausedTimeStr, ok := statefulSet.Annotations[AnnotationPausedTime]
+	if !ok {
+		return false, nil
+	}
+	delete(statefulSet.Annotations, AnnotationPausedTime)
+
+	pausedTime, err := strconv.ParseInt(pausedTimeStr, 10, 64)
+	if err != nil {
+		ctxlog.Errorf(ctx, "Invalid annotation %s: %s", AnnotationPausedTime, pausedTimeStr)
+		pausedTime = time.Now().Unix()
+	}
+	if startTimeStr, ok := statefulSet.Annotations[AnnotationUpdateStartTime]; ok {
+		startTime, err := strconv.ParseInt(startTimeStr, 10, 64)
 		if err != nil {
-			ctxlog.Debug(ctx, "Error calling GetNoneReadyPod ", statefulSet.Namespace, "/", statefulSet.Name, err)
-			return false, err
+			startTime = pausedTime
 		}
-		if podReady {
-			ready = true
-			updated = pod.Labels[appsv1.StatefulSetRevisionLabel] == statefulSet.Status.UpdateRevision
+		startTime += time.Now().Unix() - pausedTime
+		statefulSet.Annotations[AnnotationUpdateStartTime] = strconv.FormatInt(startTime, 10)
+	}
+
+	if err := r.client.Update(ctx, statefulSet); err != nil {
+		return false, ctxlog.WithEvent(statefulSet, "UpdateError").Errorf(ctx, "Error resuming rollout of StatefulSet '%s/%s': %s", statefulSet.Namespace, statefulSet.Name, err)
+	}
+	return true, nil
+}
+
+// partitionPodsAreReadyAndUpdated returns true if the pods from the partition on, which includes the last batch, are ready and updated
+func partitionPodsAreReadyAndUpdated(ctx context.Context, client crc.Client, statefulSet *appsv1.StatefulSet) (bool, error) {
+	if statefulSet.Spec.UpdateStrategy.RollingUpdate == nil {
+		return false, nil
+	}
+	for index := *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition; index < *statefulSet.Spec.Replicas; index++ {
+		ready, err := partitionPodIsReadyAndUpdated(ctx, client, statefulSet, index)
+		if err != nil || !ready {
+			return false, err
 		}
 	}
+	return true, nil
+}
+
+func partitionPodIsReadyAndUpdated(ctx context.Context, client crc.Client, statefulSet *appsv1.StatefulSet, index int32) (bool, error) {
+	ready := false
+	updated := false
+	pod, podReady, err := getPodWithIndex(ctx, client, statefulSet, index)
+	if err != nil {
+		ctxlog.Debug(ctx, "Error calling GetNoneReadyPod ", statefulSet.Namespace, "/", statefulSet.Name, err)
+		return false, err
+	}
+	if podReady {
+		ready = true
+		updated = pod.Labels[appsv1.StatefulSetRevisionLabel] == statefulSet.Status.UpdateRevision
+	}
 	return ready && updated, nil
 }
@@ -141,6 +141,48 @@ var _ = Describe("ReconcileStatefulSetRollout", func() {
 		})
 	})
 
+	Context("Update with multiple canaries and max_in_flight", func() {
+		It("updates the pods in batches", func() {
+			for ev := emulation.Reconcile(); ev != nil; ev = emulation.Reconcile() {
+			}
+			By("Update ")
+			r := reconciler()
+			reconcile(r, emulation.Update(
+				WithAnnotation(statefulset.AnnotationCanaries, "2"),
+				WithAnnotation(statefulset.AnnotationMaxInFlight, "50%"),
+			))
+			Expect(client.UpdateCallCount()).To(Equal(1))
+			Expect(emulation.statefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Canary"))
+			Expect(int(*emulation.statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)).To(Equal(2))
+
+			for i := 3; i > 0; i-- {
+				By(fmt.Sprintf("pod %d is restarted", i))
+				r = reconciler()
+				reconcile(r, emulation.Reconcile())
+				Expect(client.UpdateCallCount()).To(Equal(0))
+
+				By(fmt.Sprintf("pod %d gets ready", i))
+				r = reconciler()
+				reconcile(r, emulation.Reconcile())
+				if i%2 == 1 {
+					By("waiting for the other pod of the batch")
+					Expect(client.UpdateCallCount()).To(Equal(0))
+					continue
+				}
+				Expect(client.UpdateCallCount()).To(Equal(1))
+				Expect(emulation.statefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Rollout"))
+				Expect(int(*emulation.statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)).To(Equal(0))
+			}
+
+			By("pod 0 is restarted and gets ready")
+			r = reconciler()
+			reconcile(r, emulation.Reconcile())
+			reconcile(r, emulation.Reconcile())
+			Expect(client.UpdateCallCount()).To(Equal(1))
+			Expect(emulation.statefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Done"))
+		})
+	})
+
 	Context("Failed Update", func() {
 		It("It recovers from failed update", func() {
 			for ev := emulation.Reconcile(); ev != nil; ev = emulation.Reconcile() {
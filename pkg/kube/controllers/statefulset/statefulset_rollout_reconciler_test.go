@@ -21,6 +21,8 @@ import (
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers"
 	cfakes "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/fakes"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/statefulset"
@@ -47,11 +49,12 @@ var _ = Describe("ReconcileStatefulSetRollout", func() {
 		updatedReplicas    int32
 		updatedStatefulSet appsv1.StatefulSet
 	)
-	annotations := make(map[string]string)
+	var annotations map[string]string
 	timeout := 10 * time.Second
 	timeoutTolerance := 5 * time.Second
 
 	BeforeEach(func() {
+		annotations = map[string]string{}
 		annotations[statefulset.AnnotationCanaryRollout] = "Pending"
 		annotations[statefulset.AnnotationCanaryWatchTime] = strconv.FormatInt(timeout.Milliseconds(), 10)
 		annotations[statefulset.AnnotationUpdateWatchTime] = strconv.FormatInt(timeout.Milliseconds(), 10)
@@ -270,6 +273,24 @@ var _ = Describe("ReconcileStatefulSetRollout", func() {
 					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Canary"))
 				})
 			})
+
+			Context("without canaries", func() {
+				request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo", Namespace: "default"}}
+
+				BeforeEach(func() {
+					annotations[statefulset.AnnotationCanaries] = "0"
+					replicas = 2
+					partition = 2
+				})
+
+				It("skips the canary phase and updates the first batch", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(client.UpdateCallCount()).To(Equal(1))
+					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Rollout"))
+					Expect(*updatedStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(1)))
+				})
+			})
 		})
 
 		Context("in rollout state 'Rollout'", func() {
@@ -292,6 +313,141 @@ var _ = Describe("ReconcileStatefulSetRollout", func() {
 					Expect(client.UpdateCallCount()).To(Equal(1))
 					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Done"))
 				})
+
+				When("the stateful set belongs to a QuarksStatefulSet", func() {
+					var (
+						statusWriter *cfakes.FakeStatusWriter
+						qStatefulSet *qstsv1a1.QuarksStatefulSet
+					)
+
+					JustBeforeEach(func() {
+						statefulSet.Labels = map[string]string{
+							qstsv1a1.LabelQStsName: "foo",
+							qstsv1a1.LabelAZName:   "z1",
+						}
+						statefulSet.Status.UpdateRevision = "2"
+						readyPod.Labels = map[string]string{appsv1.StatefulSetRevisionLabel: "2"}
+						qStatefulSet = &qstsv1a1.QuarksStatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+						getStub := client.GetStub
+						client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+							switch object := object.(type) {
+							case *qstsv1a1.QuarksStatefulSet:
+								qStatefulSet.DeepCopyInto(object)
+								return nil
+							}
+							return getStub(context, nn, object)
+						})
+						statusWriter = &cfakes.FakeStatusWriter{}
+						client.StatusCalls(func() k8sclient.StatusWriter { return statusWriter })
+					})
+
+					It("reports the finished rollout", func() {
+						_, err := reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+						_, object, _ := statusWriter.UpdateArgsForCall(0)
+						status := object.(*qstsv1a1.QuarksStatefulSet).Status
+						Expect(status.Rollouts).To(Equal([]qstsv1a1.StatefulSetRolloutStatus{{
+							Name:            "foo",
+							Zone:            "z1",
+							Phase:           "Done",
+							Partition:       0,
+							Replicas:        3,
+							UpdatedReplicas: 3,
+							ReadyReplicas:   3,
+							UpdateRevision:  "2",
+						}}))
+						Expect(status.RolloutHistory).To(HaveLen(1))
+						Expect(status.RolloutHistory[0].StatefulSet).To(Equal("foo"))
+						Expect(status.RolloutHistory[0].Revision).To(Equal("2"))
+						Expect(status.RolloutHistory[0].Outcome).To(Equal("Done"))
+						Expect(status.RolloutHistory[0].EndTime).ToNot(BeNil())
+					})
+
+					It("keeps a bounded history", func() {
+						for i := 0; i < qstsv1a1.MaxRolloutHistory; i++ {
+							qStatefulSet.Status.AddRolloutHistory(qstsv1a1.RolloutHistoryEntry{StatefulSet: "foo", Revision: strconv.Itoa(i), Outcome: "Done"})
+						}
+
+						_, err := reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						_, object, _ := statusWriter.UpdateArgsForCall(0)
+						history := object.(*qstsv1a1.QuarksStatefulSet).Status.RolloutHistory
+						Expect(history).To(HaveLen(qstsv1a1.MaxRolloutHistory))
+						Expect(history[0].Revision).To(Equal("1"))
+						Expect(history[qstsv1a1.MaxRolloutHistory-1].Revision).To(Equal("2"))
+					})
+
+					It("doesn't update an unchanged status", func() {
+						statefulSet.Annotations[statefulset.AnnotationCanaryRollout] = "Done"
+						qStatefulSet.Status.SetRolloutStatus(qstsv1a1.StatefulSetRolloutStatus{
+							Name:            "foo",
+							Zone:            "z1",
+							Phase:           "Done",
+							Replicas:        3,
+							UpdatedReplicas: 3,
+							ReadyReplicas:   3,
+							UpdateRevision:  "2",
+						})
+
+						_, err := reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(statusWriter.UpdateCallCount()).To(Equal(0))
+					})
+				})
+
+				When("the BOSHDeployment is paused", func() {
+					JustBeforeEach(func() {
+						statefulSet.Labels = map[string]string{bdv1.LabelDeploymentName: "foo-deployment"}
+						getStub := client.GetStub
+						client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+							switch object := object.(type) {
+							case *bdv1.BOSHDeployment:
+								object.Name = nn.Name
+								object.Spec.Paused = true
+								return nil
+							}
+							return getStub(context, nn, object)
+						})
+					})
+
+					It("records the pause time and doesn't continue the rollout", func() {
+						_, err := reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(client.UpdateCallCount()).To(Equal(1))
+						Expect(updatedStatefulSet.Annotations).To(HaveKey(statefulset.AnnotationPausedTime))
+						Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue(statefulset.AnnotationCanaryRollout, "Rollout"))
+					})
+
+					It("keeps the first pause time", func() {
+						annotations[statefulset.AnnotationPausedTime] = "1"
+						_, err := reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(client.UpdateCallCount()).To(Equal(0))
+					})
+				})
+
+				When("the BOSHDeployment was resumed", func() {
+					BeforeEach(func() {
+						pausedTime := time.Now().Add(-100 * time.Second).Unix()
+						annotations[statefulset.AnnotationUpdateStartTime] = strconv.FormatInt(pausedTime-10, 10)
+						annotations[statefulset.AnnotationPausedTime] = strconv.FormatInt(pausedTime, 10)
+						annotations[statefulset.AnnotationUpdateWatchTime] = "50000"
+					})
+
+					It("excludes the paused duration from the watch time", func() {
+						result, err := reconciler.Reconcile(request)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(result.Requeue).To(BeTrue())
+						Expect(client.UpdateCallCount()).To(Equal(1))
+						Expect(updatedStatefulSet.Annotations).ToNot(HaveKey(statefulset.AnnotationPausedTime))
+						Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue(statefulset.AnnotationCanaryRollout, "Rollout"))
+
+						startTime, err := strconv.ParseInt(updatedStatefulSet.Annotations[statefulset.AnnotationUpdateStartTime], 10, 64)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(startTime).To(BeNumerically("~", time.Now().Add(-10*time.Second).Unix(), 2))
+					})
+				})
 			})
 
 			When("NOT all replicas are ready", func() {
@@ -362,6 +518,188 @@ var _ = Describe("ReconcileStatefulSetRollout", func() {
 					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Failed"))
 				})
 			})
+
+			When("update_watch_time is exceeded and rollback is enabled", func() {
+				var statusWriter *cfakes.FakeStatusWriter
+
+				BeforeEach(func() {
+					readyReplicas = 3
+					replicas = 3
+					updatedReplicas = 1
+					partition = 2
+					annotations[statefulset.AnnotationUpdateWatchTime] = "-1"
+					annotations[statefulset.AnnotationRollbackOnFailure] = "true"
+				})
+
+				AfterEach(func() {
+					delete(annotations, statefulset.AnnotationRollbackOnFailure)
+				})
+
+				JustBeforeEach(func() {
+					statefulSet.Labels = map[string]string{qstsv1a1.LabelQStsName: "foo"}
+					statefulSet.Status.UpdateRevision = "2"
+					getStub := client.GetStub
+					client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+						switch object := object.(type) {
+						case *appsv1.ControllerRevision:
+							Expect(nn.Name).To(Equal("1"))
+							object.Name = nn.Name
+							object.Data.Raw = []byte(`{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"name":"foo","image":"previous"}]}}}}`)
+							return nil
+						case *qstsv1a1.QuarksStatefulSet:
+							object.Name = nn.Name
+							return nil
+						}
+						return getStub(context, nn, object)
+					})
+					statusWriter = &cfakes.FakeStatusWriter{}
+					client.StatusCalls(func() k8sclient.StatusWriter { return statusWriter })
+				})
+
+				It("restores the previous revision", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(client.UpdateCallCount()).To(Equal(1))
+					Expect(updatedStatefulSet.Spec.Template.Spec.Containers[0].Image).To(Equal("previous"))
+					Expect(*updatedStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(BeEquivalentTo(3))
+					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Pending"))
+				})
+
+				It("records the failed revision in the QuarksStatefulSet", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+					_, object, _ := statusWriter.UpdateArgsForCall(0)
+					Expect(object.(*qstsv1a1.QuarksStatefulSet).Status.FailedRevision).To(Equal("2"))
+				})
+
+				It("records the rollback in the rollout history", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					_, object, _ := statusWriter.UpdateArgsForCall(0)
+					status := object.(*qstsv1a1.QuarksStatefulSet).Status
+					Expect(status.RolloutHistory).To(HaveLen(1))
+					Expect(status.RolloutHistory[0].Revision).To(Equal("2"))
+					Expect(status.RolloutHistory[0].Outcome).To(Equal("RolledBack"))
+					Expect(status.RolloutHistory[0].StartTime).ToNot(BeNil())
+					Expect(status.GetRolloutStatus("foo").Phase).To(Equal("Pending"))
+				})
+
+				It("doesn't roll back a rollback", func() {
+					statefulSet.Status.UpdateRevision = "1"
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Failed"))
+					Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+					_, object, _ := statusWriter.UpdateArgsForCall(0)
+					status := object.(*qstsv1a1.QuarksStatefulSet).Status
+					Expect(status.FailedRevision).To(BeEmpty())
+					Expect(status.RolloutHistory[0].Outcome).To(Equal("Failed"))
+				})
+			})
+		})
+
+		Context("when the rollout is controlled manually", func() {
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo", Namespace: "default"}}
+
+			BeforeEach(func() {
+				replicas = 3
+				readyReplicas = 3
+				updatedReplicas = 1
+				partition = 2
+			})
+
+			AfterEach(func() {
+				delete(annotations, statefulset.AnnotationPauseAfterCanary)
+				delete(annotations, statefulset.AnnotationRolloutAction)
+			})
+
+			When("the rollout pauses after the canaries", func() {
+				BeforeEach(func() {
+					annotations[statefulset.AnnotationCanaryRollout] = "Canary"
+					annotations[statefulset.AnnotationPauseAfterCanary] = "true"
+				})
+
+				It("pauses once the canaries are ready", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Paused"))
+					Expect(*updatedStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(BeEquivalentTo(2))
+				})
+			})
+
+			When("the rollout is paused", func() {
+				BeforeEach(func() {
+					annotations[statefulset.AnnotationCanaryRollout] = "Paused"
+					annotations[statefulset.AnnotationUpdateWatchTime] = "-1"
+				})
+
+				AfterEach(func() {
+					delete(annotations, statefulset.AnnotationUpdateWatchTime)
+				})
+
+				It("doesn't time out", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(client.UpdateCallCount()).To(Equal(0))
+				})
+
+				It("resumes the rollout", func() {
+					annotations[statefulset.AnnotationRolloutAction] = "resume"
+					annotations[statefulset.AnnotationUpdateStartTime] = "0"
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(client.UpdateCallCount()).To(Equal(1))
+					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Rollout"))
+					Expect(updatedStatefulSet.Annotations).ToNot(HaveKey(statefulset.AnnotationRolloutAction))
+					Expect(updatedStatefulSet.Annotations[statefulset.AnnotationUpdateStartTime]).ToNot(Equal("0"))
+					Expect(*updatedStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(BeEquivalentTo(2))
+				})
+			})
+
+			When("the rollout is in progress", func() {
+				BeforeEach(func() {
+					annotations[statefulset.AnnotationCanaryRollout] = "Rollout"
+				})
+
+				It("pauses the rollout", func() {
+					annotations[statefulset.AnnotationRolloutAction] = "pause"
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Paused"))
+					Expect(updatedStatefulSet.Annotations).ToNot(HaveKey(statefulset.AnnotationRolloutAction))
+				})
+
+				It("promotes the update to all pods", func() {
+					annotations[statefulset.AnnotationRolloutAction] = "promote"
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Rollout"))
+					Expect(*updatedStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(BeEquivalentTo(0))
+				})
+
+				It("aborts the rollout", func() {
+					annotations[statefulset.AnnotationRolloutAction] = "abort"
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Failed"))
+					Expect(*updatedStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(BeEquivalentTo(2))
+				})
+
+				It("ignores an action, which doesn't apply to the state", func() {
+					annotations[statefulset.AnnotationRolloutAction] = "resume"
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(client.UpdateCallCount()).To(Equal(1))
+					Expect(updatedStatefulSet.Annotations).To(HaveKeyWithValue("quarks.cloudfoundry.org/canary-rollout", "Rollout"))
+					Expect(updatedStatefulSet.Annotations).ToNot(HaveKey(statefulset.AnnotationRolloutAction))
+				})
+			})
 		})
 
 		Context("in rollout state 'Done'", func() {
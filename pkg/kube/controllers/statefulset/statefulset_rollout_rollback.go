@@ -0,0 +1,96 @@
+package statefulset
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
+)
+
+// isRollbackEnabled returns true if a failed rollout of the stateful set is rolled back
+func isRollbackEnabled(statefulSet *appsv1.StatefulSet) bool {
+	enabled, ok := statefulSet.GetAnnotations()[AnnotationRollbackOnFailure]
+	return ok && enabled == "true"
+}
+
+// canRollback returns true if there is a previous revision to roll back to.
+// A failed rollback, which rolls out the previous revision, is not rolled back again.
+func canRollback(statefulSet *appsv1.StatefulSet) bool {
+	return statefulSet.Status.CurrentRevision != "" && statefulSet.Status.CurrentRevision != statefulSet.Status.UpdateRevision
+}
+
+// failRollout marks the rollout as failed, or rolls it back if a rollback is enabled
+func (r *ReconcileStatefulSetRollout) failRollout(ctx context.Context, statefulSet appsv1.StatefulSet) error {
+	if isRollbackEnabled(&statefulSet) && canRollback(&statefulSet) {
+		return r.rollback(ctx, statefulSet)
+	}
+
+	statefulSet.Annotations[AnnotationCanaryRollout] = rolloutStateFailed
+	if err := r.updateStatefulSet(ctx, &statefulSet); err != nil {
+		ctxlog.Debug(ctx, "Error updating StatefulSet ", statefulSet.Name, err)
+		return err
+	}
+	r.updateRolloutStatus(ctx, &statefulSet, finishedRollout(&statefulSet, statefulSet.Status.UpdateRevision, qstsv1a1.RolloutPhaseFailed))
+	return nil
+}
+
+// rollback restores the pod template of the current revision and starts a
+// new rollout of it, which replaces the pods of the failed revision
+func (r *ReconcileStatefulSetRollout) rollback(ctx context.Context, statefulSet appsv1.StatefulSet) error {
+	failedRevision := statefulSet.Status.UpdateRevision
+	currentRevision := statefulSet.Status.CurrentRevision
+
+	revision := &appsv1.ControllerRevision{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: statefulSet.Namespace, Name: currentRevision}, revision)
+	if err != nil {
+		return errors.Wrapf(err, "could not get controller revision '%s' of StatefulSet '%s/%s'", currentRevision, statefulSet.Namespace, statefulSet.Name)
+	}
+
+	template, err := revisionTemplate(revision)
+	if err != nil {
+		return errors.Wrapf(err, "could not read pod template of controller revision '%s'", currentRevision)
+	}
+
+	finished := finishedRollout(&statefulSet, failedRevision, qstsv1a1.RolloutPhaseRolledBack)
+	_, err = controllerutil.CreateOrUpdate(ctx, r.client, &statefulSet, func() error {
+		statefulSet.Spec.Template = template
+		ConfigureStatefulSetForRollout(&statefulSet)
+		delete(statefulSet.Annotations, AnnotationRolloutAction)
+		statefulSet.Annotations[AnnotationRolledBackRevision] = failedRevision
+		meltdown.SetLastReconcile(&statefulSet.ObjectMeta, time.Now())
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "could not roll back StatefulSet '%s/%s'", statefulSet.Namespace, statefulSet.Name)
+	}
+
+	ctxlog.WithEvent(&statefulSet, "RolloutRollback").Infof(ctx, "Rollout of revision '%s' of StatefulSet '%s/%s' failed, rolling back to revision '%s'", failedRevision, statefulSet.Namespace, statefulSet.Name, currentRevision)
+
+	r.updateRolloutStatus(ctx, &statefulSet, finished)
+	return nil
+}
+
+// revisionTemplate returns the pod template, which is stored as a patch in the controller revision of a stateful set
+func revisionTemplate(revision *appsv1.ControllerRevision) (corev1.PodTemplateSpec, error) {
+	patch := struct {
+		Spec struct {
+			Template corev1.PodTemplateSpec `json:"template"`
+		} `json:"spec"`
+	}{}
+
+	err := json.Unmarshal(revision.Data.Raw, &patch)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+	return patch.Spec.Template, nil
+}
@@ -0,0 +1,86 @@
+package statefulset
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// rolloutStatus returns the rollout state of the stateful set, as reported in the status of its QuarksStatefulSet
+func rolloutStatus(statefulSet *appsv1.StatefulSet) qstsv1a1.StatefulSetRolloutStatus {
+	status := qstsv1a1.StatefulSetRolloutStatus{
+		Name:            statefulSet.Name,
+		Zone:            statefulSet.GetLabels()[qstsv1a1.LabelAZName],
+		Phase:           statefulSet.GetAnnotations()[AnnotationCanaryRollout],
+		UpdatedReplicas: statefulSet.Status.UpdatedReplicas,
+		ReadyReplicas:   statefulSet.Status.ReadyReplicas,
+		UpdateRevision:  statefulSet.Status.UpdateRevision,
+	}
+	if statefulSet.Spec.Replicas != nil {
+		status.Replicas = *statefulSet.Spec.Replicas
+	}
+	if statefulSet.Spec.UpdateStrategy.RollingUpdate != nil && statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		status.Partition = *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+	return status
+}
+
+// finishedRollout returns the history entry for the rollout of the given revision, which ends now
+func finishedRollout(statefulSet *appsv1.StatefulSet, revision string, outcome string) *qstsv1a1.RolloutHistoryEntry {
+	entry := &qstsv1a1.RolloutHistoryEntry{
+		StatefulSet: statefulSet.Name,
+		Revision:    revision,
+		EndTime:     &metav1.Time{Time: time.Now()},
+		Outcome:     outcome,
+	}
+	startTime, err := strconv.ParseInt(statefulSet.GetAnnotations()[AnnotationUpdateStartTime], 10, 64)
+	if err == nil {
+		entry.StartTime = &metav1.Time{Time: time.Unix(startTime, 0)}
+	}
+	return entry
+}
+
+// updateRolloutStatus writes the rollout state of the stateful set into the
+// status of its QuarksStatefulSet. A finished rollout is added to the history.
+// Errors are only logged, as the rollout itself is not affected.
+func (r *ReconcileStatefulSetRollout) updateRolloutStatus(ctx context.Context, statefulSet *appsv1.StatefulSet, finished *qstsv1a1.RolloutHistoryEntry) {
+	name, ok := statefulSet.GetLabels()[qstsv1a1.LabelQStsName]
+	if !ok {
+		return
+	}
+
+	qStatefulSet := &qstsv1a1.QuarksStatefulSet{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: statefulSet.Namespace, Name: name}, qStatefulSet)
+	if err != nil {
+		err = errors.Wrapf(err, "could not get QuarksStatefulSet '%s'", name)
+		ctxlog.WithEvent(statefulSet, "UpdateStatusError").Errorf(ctx, "Failed to update rollout status of StatefulSet '%s/%s': %s", statefulSet.Namespace, statefulSet.Name, err)
+		return
+	}
+
+	old := qStatefulSet.Status.DeepCopy()
+	qStatefulSet.Status.SetRolloutStatus(rolloutStatus(statefulSet))
+	if finished != nil {
+		qStatefulSet.Status.AddRolloutHistory(*finished)
+		if finished.Outcome == qstsv1a1.RolloutPhaseRolledBack {
+			qStatefulSet.Status.FailedRevision = finished.Revision
+		}
+	}
+	if reflect.DeepEqual(old, &qStatefulSet.Status) {
+		return
+	}
+
+	err = r.client.Status().Update(ctx, qStatefulSet)
+	if err != nil {
+		ctxlog.WithEvent(statefulSet, "UpdateStatusError").Errorf(ctx, "Failed to update rollout status of StatefulSet '%s/%s': %s", statefulSet.Namespace, statefulSet.Name, err)
+	}
+}
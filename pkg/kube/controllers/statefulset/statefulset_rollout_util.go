@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
@@ -79,6 +80,18 @@ func ComputeAnnotations(ig *manifest.InstanceGroup) (map[string]string, error) {
 		statefulSetAnnotations[AnnotationUpdateWatchTime] = updateWatchTime
 	}
 
+	if ig.Update.Canaries != nil {
+		statefulSetAnnotations[AnnotationCanaries] = strconv.Itoa(*ig.Update.Canaries)
+	}
+
+	maxInFlight, err := ExtractMaxInFlight(ig.Update.MaxInFlight)
+	if err != nil {
+		return nil, err
+	}
+	if maxInFlight != "" {
+		statefulSetAnnotations[AnnotationMaxInFlight] = maxInFlight
+	}
+
 	return statefulSetAnnotations, nil
 }
 
@@ -100,6 +113,36 @@ func ExtractWatchTime(rawWatchTime string, field string) (string, error) {
 	return "", fmt.Errorf("invalid %s", field)
 }
 
+// ExtractMaxInFlight validates max_in_flight, which is an absolute value or a percentage
+func ExtractMaxInFlight(rawMaxInFlight string) (string, error) {
+	if rawMaxInFlight == "" {
+		return "", nil
+	}
+
+	maxInFlightRegex := regexp.MustCompile(`^\s*(\d+%?)\s*$`)
+	if matches := maxInFlightRegex.FindStringSubmatch(rawMaxInFlight); len(matches) > 0 {
+		return matches[1], nil
+	}
+	return "", fmt.Errorf("invalid max_in_flight")
+}
+
+// maxInFlight returns the number of pods updated at once. Percentages are
+// relative to the replicas and rounded down, but at least one pod is updated.
+func maxInFlight(value string, replicas int32) (int32, error) {
+	percentage := strings.HasSuffix(value, "%")
+	number, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+	if err != nil {
+		return 0, err
+	}
+	if percentage {
+		number = number * int(replicas) / 100
+	}
+	if number < 1 {
+		number = 1
+	}
+	return int32(number), nil
+}
+
 // CleanupNonReadyPod deletes all pods, that are not ready
 func CleanupNonReadyPod(ctx context.Context, client crc.Client, statefulSet *appsv1.StatefulSet, index int32) error {
 	ctxlog.Debug(ctx, "Cleaning up non ready pod for StatefulSet ", statefulSet.Namespace, "/", statefulSet.Name, "-", index)
@@ -44,6 +44,63 @@ var _ = Describe("FilterLabels", func() {
 
 })
 
+var _ = Describe("ComputeAnnotations", func() {
+	var ig *manifest.InstanceGroup
+
+	BeforeEach(func() {
+		ig = &manifest.InstanceGroup{
+			Update: &manifest.Update{
+				Canaries:    pointers.Int(2),
+				MaxInFlight: "25%",
+			},
+		}
+	})
+
+	It("adds canaries and max_in_flight", func() {
+		annotations, err := statefulset.ComputeAnnotations(ig)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(annotations).To(HaveKeyWithValue(statefulset.AnnotationCanaries, "2"))
+		Expect(annotations).To(HaveKeyWithValue(statefulset.AnnotationMaxInFlight, "25%"))
+	})
+
+	It("keeps zero canaries", func() {
+		ig.Update.Canaries = pointers.Int(0)
+
+		annotations, err := statefulset.ComputeAnnotations(ig)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(annotations).To(HaveKeyWithValue(statefulset.AnnotationCanaries, "0"))
+	})
+
+	It("omits unset values", func() {
+		ig.Update = &manifest.Update{}
+
+		annotations, err := statefulset.ComputeAnnotations(ig)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(annotations).ToNot(HaveKey(statefulset.AnnotationCanaries))
+		Expect(annotations).ToNot(HaveKey(statefulset.AnnotationMaxInFlight))
+	})
+
+	It("fails for an invalid max_in_flight", func() {
+		ig.Update.MaxInFlight = "half"
+
+		_, err := statefulset.ComputeAnnotations(ig)
+		Expect(err).To(MatchError("invalid max_in_flight"))
+	})
+})
+
+var _ = Describe("ExtractMaxInFlight", func() {
+	It("accepts absolute values and percentages", func() {
+		Expect(statefulset.ExtractMaxInFlight(" 3 ")).To(Equal("3"))
+		Expect(statefulset.ExtractMaxInFlight("50%")).To(Equal("50%"))
+		Expect(statefulset.ExtractMaxInFlight("")).To(Equal(""))
+	})
+
+	It("rejects other values", func() {
+		_, err := statefulset.ExtractMaxInFlight("3-5")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 var _ = Describe("CleanupNonReadyPod", func() {
 	var (
 		ctx          context.Context
@@ -2,11 +2,13 @@ package operator
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/pkg/errors"
 
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	extv1client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -29,6 +31,8 @@ type resource struct {
 	shortNames   []string
 	groupVersion schema.GroupVersion
 	validation   *extv1.CustomResourceValidation
+	// columns are shown by kubectl get, in addition to the name
+	columns []extv1.CustomResourceColumnDefinition
 }
 
 // NewManager adds schemes, controllers and starts the manager
@@ -78,6 +82,7 @@ func ApplyCRDs(config *rest.Config) error {
 			bdv1.BOSHDeploymentResourceShortNames,
 			bdv1.SchemeGroupVersion,
 			&bdv1.BOSHDeploymentValidation,
+			nil,
 		},
 		{
 			qjv1a1.QuarksJobResourceName,
@@ -86,6 +91,7 @@ func ApplyCRDs(config *rest.Config) error {
 			qjv1a1.QuarksJobResourceShortNames,
 			qjv1a1.SchemeGroupVersion,
 			&qjv1a1.QuarksJobValidation,
+			nil,
 		},
 		{
 			qsv1a1.QuarksSecretResourceName,
@@ -94,6 +100,7 @@ func ApplyCRDs(config *rest.Config) error {
 			qsv1a1.QuarksSecretResourceShortNames,
 			qsv1a1.SchemeGroupVersion,
 			&qsv1a1.QuarksSecretValidation,
+			qsv1a1.QuarksSecretPrinterColumns,
 		},
 		{
 			qstsv1a1.QuarksStatefulSetResourceName,
@@ -102,6 +109,7 @@ func ApplyCRDs(config *rest.Config) error {
 			qstsv1a1.QuarksStatefulSetResourceShortNames,
 			qstsv1a1.SchemeGroupVersion,
 			&qstsv1a1.QuarksStatefulSetValidation,
+			nil,
 		},
 	} {
 		err = crd.ApplyCRD(
@@ -116,6 +124,10 @@ func ApplyCRDs(config *rest.Config) error {
 		if err != nil {
 			return errors.Wrapf(err, "failed to apply CRD '%s'", res.name)
 		}
+		err = applyPrinterColumns(exClient, res.name, res.columns)
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply printer columns of CRD '%s'", res.name)
+		}
 		err = crd.WaitForCRDReady(exClient, res.name)
 		if err != nil {
 			return errors.Wrapf(err, "failed to wait for CRD '%s' ready", res.name)
@@ -124,3 +136,26 @@ func ApplyCRDs(config *rest.Config) error {
 
 	return nil
 }
+
+// applyPrinterColumns sets the additional printer columns of a CRD, which
+// are not supported by crd.ApplyCRD
+func applyPrinterColumns(client extv1client.ApiextensionsV1beta1Interface, crdName string, columns []extv1.CustomResourceColumnDefinition) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	exCrd, err := client.CustomResourceDefinitions().Get(crdName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "getting CRD '%s'", crdName)
+	}
+	if reflect.DeepEqual(exCrd.Spec.AdditionalPrinterColumns, columns) {
+		return nil
+	}
+
+	exCrd.Spec.AdditionalPrinterColumns = columns
+	_, err = client.CustomResourceDefinitions().Update(exCrd)
+	if err != nil {
+		return errors.Wrapf(err, "updating CRD '%s'", crdName)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Package crossnamespace allows BOSHDeployments to reference config maps and
+// secrets in other namespaces. Only namespaces, which are explicitly allowed
+// by the operator configuration, can be referenced.
+package crossnamespace
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+)
+
+var (
+	mu                sync.RWMutex
+	allowedNamespaces = []string{}
+)
+
+// SetAllowedNamespaces sets the namespaces, which can be referenced by
+// BOSHDeployments in other namespaces
+func SetAllowedNamespaces(namespaces []string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	allowedNamespaces = []string{}
+	for _, namespace := range namespaces {
+		if namespace != "" {
+			allowedNamespaces = append(allowedNamespaces, namespace)
+		}
+	}
+}
+
+// AllowedNamespaces returns the namespaces, which can be referenced
+func AllowedNamespaces() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return append([]string{}, allowedNamespaces...)
+}
+
+// IsAllowed returns true if resources in the namespace can be referenced
+// from other namespaces
+func IsAllowed(namespace string) bool {
+	for _, allowed := range AllowedNamespaces() {
+		if allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Namespace returns the namespace of the referenced resource. References
+// without a namespace point to the namespace of the BOSHDeployment.
+func Namespace(deploymentNamespace string, ref bdv1.ResourceReference) string {
+	if ref.Namespace == "" {
+		return deploymentNamespace
+	}
+	return ref.Namespace
+}
+
+// Validate returns an error if the reference points to a namespace, which
+// is not allowed
+func Validate(deploymentNamespace string, ref bdv1.ResourceReference) error {
+	namespace := Namespace(deploymentNamespace, ref)
+	if namespace == deploymentNamespace || IsAllowed(namespace) {
+		return nil
+	}
+	return errors.Errorf("%s reference '%s' to namespace '%s' is not allowed, the namespace is not shared with BOSHDeployments of other namespaces", ref.Type, ref.Name, namespace)
+}
+
+// NewCache returns a cache for the allowed namespaces and adds it to the
+// manager. It returns nil if no namespaces are allowed.
+func NewCache(mgr manager.Manager) (cache.Cache, error) {
+	namespaces := AllowedNamespaces()
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+
+	c, err := cache.MultiNamespacedCacheBuilder(namespaces)(mgr.GetConfig(), cache.Options{
+		Scheme: mgr.GetScheme(),
+		Mapper: mgr.GetRESTMapper(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cache for shared namespaces")
+	}
+
+	err = mgr.Add(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add cache for shared namespaces to manager")
+	}
+	return c, nil
+}
+
+// Client reads objects in the allowed namespaces with a separate reader, as
+// the client of the manager is restricted to the watched namespace
+type Client struct {
+	crc.Client
+	reader crc.Reader
+}
+
+var _ crc.Client = &Client{}
+
+// NewClient returns a client, which reads from reader for allowed namespaces
+// and uses c for everything else. If reader is nil, c is returned.
+func NewClient(c crc.Client, reader crc.Reader) crc.Client {
+	if reader == nil {
+		return c
+	}
+	return &Client{Client: c, reader: reader}
+}
+
+// Get retrieves an obj for the given object key
+func (c *Client) Get(ctx context.Context, key crc.ObjectKey, obj runtime.Object) error {
+	if IsAllowed(key.Namespace) {
+		return c.reader.Get(ctx, key, obj)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+// List retrieves list of objects for a given namespace and list options
+func (c *Client) List(ctx context.Context, list runtime.Object, opts ...crc.ListOption) error {
+	listOpts := (&crc.ListOptions{}).ApplyOptions(opts)
+	if IsAllowed(listOpts.Namespace) {
+		return c.reader.List(ctx, list, opts...)
+	}
+	return c.Client.List(ctx, list, opts...)
+}
@@ -0,0 +1,101 @@
+package crossnamespace_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
+)
+
+var _ = Describe("CrossNamespace", func() {
+	BeforeEach(func() {
+		crossnamespace.SetAllowedNamespaces([]string{"shared", ""})
+	})
+
+	AfterEach(func() {
+		crossnamespace.SetAllowedNamespaces(nil)
+	})
+
+	Describe("AllowedNamespaces", func() {
+		It("ignores empty namespaces", func() {
+			Expect(crossnamespace.AllowedNamespaces()).To(Equal([]string{"shared"}))
+			Expect(crossnamespace.IsAllowed("shared")).To(BeTrue())
+			Expect(crossnamespace.IsAllowed("")).To(BeFalse())
+		})
+	})
+
+	Describe("Namespace", func() {
+		It("defaults to the namespace of the deployment", func() {
+			ref := bdv1.ResourceReference{Type: bdv1.ConfigMapReference, Name: "ops"}
+			Expect(crossnamespace.Namespace("default", ref)).To(Equal("default"))
+		})
+
+		It("returns the namespace of the reference", func() {
+			ref := bdv1.ResourceReference{Type: bdv1.ConfigMapReference, Name: "ops", Namespace: "shared"}
+			Expect(crossnamespace.Namespace("default", ref)).To(Equal("shared"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts references to the namespace of the deployment", func() {
+			ref := bdv1.ResourceReference{Type: bdv1.ConfigMapReference, Name: "ops", Namespace: "default"}
+			Expect(crossnamespace.Validate("default", ref)).To(Succeed())
+		})
+
+		It("accepts references to allowed namespaces", func() {
+			ref := bdv1.ResourceReference{Type: bdv1.ConfigMapReference, Name: "ops", Namespace: "shared"}
+			Expect(crossnamespace.Validate("default", ref)).To(Succeed())
+		})
+
+		It("rejects references to other namespaces", func() {
+			ref := bdv1.ResourceReference{Type: bdv1.SecretReference, Name: "ops", Namespace: "private"}
+			err := crossnamespace.Validate("default", ref)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("secret reference 'ops' to namespace 'private' is not allowed"))
+		})
+	})
+
+	Describe("Client", func() {
+		var client crc.Client
+
+		BeforeEach(func() {
+			client = crossnamespace.NewClient(
+				fake.NewFakeClient(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "ops", Namespace: "default"}}),
+				fake.NewFakeClient(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "shared-ops", Namespace: "shared"}}),
+			)
+		})
+
+		It("reads allowed namespaces from the reader", func() {
+			configMap := &corev1.ConfigMap{}
+			Expect(client.Get(context.Background(), crc.ObjectKey{Name: "shared-ops", Namespace: "shared"}, configMap)).To(Succeed())
+
+			configMaps := &corev1.ConfigMapList{}
+			Expect(client.List(context.Background(), configMaps, crc.InNamespace("shared"))).To(Succeed())
+			Expect(configMaps.Items).To(HaveLen(1))
+			Expect(configMaps.Items[0].Name).To(Equal("shared-ops"))
+		})
+
+		It("reads other namespaces from the client", func() {
+			configMap := &corev1.ConfigMap{}
+			Expect(client.Get(context.Background(), crc.ObjectKey{Name: "ops", Namespace: "default"}, configMap)).To(Succeed())
+
+			configMaps := &corev1.ConfigMapList{}
+			Expect(client.List(context.Background(), configMaps, crc.InNamespace("default"))).To(Succeed())
+			Expect(configMaps.Items).To(HaveLen(1))
+			Expect(configMaps.Items[0].Name).To(Equal("ops"))
+		})
+
+		It("returns the client if there is no reader", func() {
+			c := fake.NewFakeClient()
+			Expect(crossnamespace.NewClient(c, nil)).To(BeIdenticalTo(c))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package crossnamespace_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCrossNamespace(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CrossNamespace Suite")
+}
@@ -0,0 +1,277 @@
+// Package gitrepo reads files from git repositories. It uses the git binary
+// and keeps a shallow bare clone per remote repository, so repeated reads
+// only fetch the new commits.
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecretKeyUsername is the key of the user name in a credentials secret
+	SecretKeyUsername = corev1.BasicAuthUsernameKey
+	// SecretKeyPassword is the key of the password or token in a credentials secret
+	SecretKeyPassword = corev1.BasicAuthPasswordKey
+	// SecretKeySSHPrivateKey is the key of the SSH private key in a credentials secret
+	SecretKeySSHPrivateKey = corev1.SSHAuthPrivateKey
+	// SecretKeyKnownHosts is the key of the SSH known hosts in a credentials secret
+	SecretKeyKnownHosts = "known_hosts"
+
+	// DefaultRef is used if a reference does not specify a ref
+	DefaultRef = "HEAD"
+
+	defaultTimeout = 2 * time.Minute
+)
+
+var commitRegexp = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// Default is the repository cache shared by all resolvers of the operator
+var Default = NewRepositories(filepath.Join(os.TempDir(), "cf-operator-git"))
+
+// Credentials are used to access a private repository. Either username and
+// password are used for HTTP(S) remotes, or an SSH private key for SSH remotes.
+type Credentials struct {
+	Username      string
+	Password      string
+	SSHPrivateKey []byte
+	KnownHosts    []byte
+}
+
+// CredentialsFromSecret reads the credentials from a secret
+func CredentialsFromSecret(secret *corev1.Secret) *Credentials {
+	return &Credentials{
+		Username:      string(secret.Data[SecretKeyUsername]),
+		Password:      string(secret.Data[SecretKeyPassword]),
+		SSHPrivateKey: secret.Data[SecretKeySSHPrivateKey],
+		KnownHosts:    secret.Data[SecretKeyKnownHosts],
+	}
+}
+
+// Repositories fetches files from remote git repositories
+type Repositories struct {
+	baseDir string
+	timeout time.Duration
+	mu      sync.Mutex
+	locks   map[string]*sync.Mutex
+}
+
+// NewRepositories returns a repository cache, which keeps its clones in baseDir
+func NewRepositories(baseDir string) *Repositories {
+	return &Repositories{
+		baseDir: baseDir,
+		timeout: defaultTimeout,
+		locks:   map[string]*sync.Mutex{},
+	}
+}
+
+// lock locks the local clone in dir, so a slow repository doesn't block
+// fetches from other repositories. It returns the unlock function.
+func (r *Repositories) lock(dir string) func() {
+	r.mu.Lock()
+	l, ok := r.locks[dir]
+	if !ok {
+		l = &sync.Mutex{}
+		r.locks[dir] = l
+	}
+	r.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// IsCommit returns true if the ref is a full commit SHA, which never moves
+func IsCommit(ref string) bool {
+	return commitRegexp.MatchString(ref)
+}
+
+// Fetch fetches the ref from the repository and returns the content of the
+// file at path, together with the commit SHA the ref resolved to.
+func (r *Repositories) Fetch(ctx context.Context, repository string, ref string, path string, creds *Credentials) (string, string, error) {
+	if ref == "" {
+		ref = DefaultRef
+	}
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	if path == "" {
+		return "", "", errors.Errorf("no path given for repository '%s'", repository)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	dir := filepath.Join(r.baseDir, fmt.Sprintf("%x", sha256.Sum256([]byte(repository))))
+	unlock := r.lock(dir)
+	defer unlock()
+
+	err := r.clone(ctx, repository, dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = r.git(ctx, dir, creds, "fetch", "--quiet", "--depth", "1", "--force", repository, ref)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to fetch '%s' from repository '%s'", ref, repository)
+	}
+
+	out, err := r.git(ctx, dir, nil, "rev-parse", "FETCH_HEAD^{commit}")
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to resolve '%s' of repository '%s'", ref, repository)
+	}
+	commit := strings.TrimSpace(string(out))
+
+	out, err = r.git(ctx, dir, nil, "show", fmt.Sprintf("%s:%s", commit, path))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to read '%s' at commit %s of repository '%s'", path, commit, repository)
+	}
+
+	return string(out), commit, nil
+}
+
+// RemoteCommit returns the commit SHA the ref currently points to in the
+// remote repository, without fetching it.
+func (r *Repositories) RemoteCommit(ctx context.Context, repository string, ref string, creds *Credentials) (string, error) {
+	if ref == "" {
+		ref = DefaultRef
+	}
+	if IsCommit(ref) {
+		return ref, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := os.MkdirAll(r.baseDir, 0700); err != nil {
+		return "", errors.Wrapf(err, "failed to create directory '%s'", r.baseDir)
+	}
+
+	out, err := r.git(ctx, r.baseDir, creds, "ls-remote", repository, ref, ref+"^{}")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list '%s' of repository '%s'", ref, repository)
+	}
+
+	// Annotated tags are listed twice, the peeled entry ending in '^{}'
+	// is the commit the tag points to
+	commit := ""
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.HasSuffix(fields[1], "^{}") {
+			return fields[0], nil
+		}
+		if commit == "" {
+			commit = fields[0]
+		}
+	}
+
+	if commit == "" {
+		return "", errors.Errorf("ref '%s' not found in repository '%s'", ref, repository)
+	}
+	return commit, nil
+}
+
+// clone initializes the bare repository for the remote in dir on first use
+func (r *Repositories) clone(ctx context.Context, repository string, dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(r.baseDir, 0700); err != nil {
+		return errors.Wrapf(err, "failed to create directory '%s'", r.baseDir)
+	}
+
+	_, err := r.git(ctx, r.baseDir, nil, "init", "--quiet", "--bare", dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return errors.Wrapf(err, "failed to initialize local repository for '%s'", repository)
+	}
+	return nil
+}
+
+// git runs a git command in dir. Credentials are passed via config and
+// environment, so they don't show up in the arguments of error messages.
+func (r *Repositories) git(ctx context.Context, dir string, creds *Credentials, args ...string) ([]byte, error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if creds != nil {
+		credsEnv, cleanup, err := credentialsEnv(creds)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		env = append(env, credsEnv...)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// credentialsEnv writes the credentials to a temporary directory and returns
+// the environment for git using them. The basic auth header is written to the
+// git config of a temporary home directory, which works with all git versions.
+// SSH remotes are only accepted with known hosts, so the host key is verified.
+func credentialsEnv(creds *Credentials) ([]string, func(), error) {
+	tmpDir, err := ioutil.TempDir("", "cf-operator-git-creds")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create directory for git credentials")
+	}
+	cleanup := func() { os.RemoveAll(tmpDir) }
+
+	env := []string{}
+	if creds.Username != "" || creds.Password != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password))
+		config := fmt.Sprintf("[http]\n\textraHeader = Authorization: Basic %s\n", auth)
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, ".gitconfig"), []byte(config), 0600); err != nil {
+			cleanup()
+			return nil, nil, errors.Wrap(err, "failed to write git config")
+		}
+		env = append(env, "HOME="+tmpDir, "XDG_CONFIG_HOME="+tmpDir)
+	}
+
+	if len(creds.SSHPrivateKey) > 0 {
+		if len(creds.KnownHosts) == 0 {
+			cleanup()
+			return nil, nil, errors.Errorf("the credentials for ssh remotes need the '%s' key, to verify the host key", SecretKeyKnownHosts)
+		}
+
+		keyFile := filepath.Join(tmpDir, "id")
+		if err := ioutil.WriteFile(keyFile, creds.SSHPrivateKey, 0600); err != nil {
+			cleanup()
+			return nil, nil, errors.Wrap(err, "failed to write ssh key")
+		}
+		knownHostsFile := filepath.Join(tmpDir, "known_hosts")
+		if err := ioutil.WriteFile(knownHostsFile, creds.KnownHosts, 0600); err != nil {
+			cleanup()
+			return nil, nil, errors.Wrap(err, "failed to write ssh known hosts")
+		}
+		env = append(env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes -o UserKnownHostsFile=%s -o StrictHostKeyChecking=yes", keyFile, knownHostsFile))
+	}
+
+	return env, cleanup, nil
+}
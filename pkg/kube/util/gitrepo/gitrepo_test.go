@@ -0,0 +1,167 @@
+package gitrepo_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/gitrepo"
+)
+
+var _ = Describe("Repositories", func() {
+	var (
+		ctx     context.Context
+		tmpDir  string
+		remote  string
+		work    string
+		repos   *gitrepo.Repositories
+		initial string
+	)
+
+	git := func(dir string, args ...string) string {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		Expect(err).ToNot(HaveOccurred(), string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	commit := func(path, content string) string {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(work, path)), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(work, path), []byte(content), 0644)).To(Succeed())
+		git(work, "add", "-A")
+		git(work, "commit", "--quiet", "-m", "update "+path)
+		git(work, "push", "--quiet", "origin", "HEAD:main")
+		return git(work, "rev-parse", "HEAD")
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "gitrepo-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		remote = filepath.Join(tmpDir, "remote.git")
+		work = filepath.Join(tmpDir, "work")
+		git(tmpDir, "init", "--quiet", "--bare", "--initial-branch=main", remote)
+		git(tmpDir, "clone", "--quiet", remote, work)
+
+		initial = commit("deployments/manifest.yml", "name: initial\n")
+
+		repos = gitrepo.NewRepositories(filepath.Join(tmpDir, "cache"))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("Fetch", func() {
+		It("returns the file content and commit of the default branch", func() {
+			data, sha, err := repos.Fetch(ctx, remote, "", "deployments/manifest.yml", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal("name: initial\n"))
+			Expect(sha).To(Equal(initial))
+		})
+
+		It("fetches new commits once the ref moved", func() {
+			_, _, err := repos.Fetch(ctx, remote, "main", "deployments/manifest.yml", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			next := commit("deployments/manifest.yml", "name: next\n")
+
+			data, sha, err := repos.Fetch(ctx, remote, "main", "/deployments/manifest.yml", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal("name: next\n"))
+			Expect(sha).To(Equal(next))
+		})
+
+		It("resolves tags and commits", func() {
+			git(work, "tag", "-a", "-m", "release", "v1")
+			git(work, "push", "--quiet", "origin", "v1")
+			commit("deployments/manifest.yml", "name: next\n")
+
+			data, sha, err := repos.Fetch(ctx, remote, "v1", "deployments/manifest.yml", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal("name: initial\n"))
+			Expect(sha).To(Equal(initial))
+
+			data, sha, err = repos.Fetch(ctx, remote, initial, "deployments/manifest.yml", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal("name: initial\n"))
+			Expect(sha).To(Equal(initial))
+		})
+
+		It("fails if the file does not exist", func() {
+			_, _, err := repos.Fetch(ctx, remote, "main", "missing.yml", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to read 'missing.yml' at commit " + initial))
+		})
+
+		It("fails if no path is given", func() {
+			_, _, err := repos.Fetch(ctx, remote, "main", "", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no path given"))
+		})
+
+		It("fails if the ref does not exist", func() {
+			_, _, err := repos.Fetch(ctx, remote, "missing", "deployments/manifest.yml", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to fetch 'missing'"))
+		})
+	})
+
+	Describe("RemoteCommit", func() {
+		It("returns the commit of a branch", func() {
+			next := commit("deployments/manifest.yml", "name: next\n")
+
+			sha, err := repos.RemoteCommit(ctx, remote, "main", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sha).To(Equal(next))
+		})
+
+		It("returns the commit an annotated tag points to", func() {
+			git(work, "tag", "-a", "-m", "release", "v1")
+			git(work, "push", "--quiet", "origin", "v1")
+
+			sha, err := repos.RemoteCommit(ctx, remote, "v1", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sha).To(Equal(initial))
+		})
+
+		It("fails for ssh credentials without known hosts", func() {
+			creds := &gitrepo.Credentials{SSHPrivateKey: []byte("key")}
+			_, err := repos.RemoteCommit(ctx, "git@example.com:org/repo.git", "main", creds)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("need the 'known_hosts' key"))
+		})
+
+		It("accepts basic auth credentials", func() {
+			creds := &gitrepo.Credentials{Username: "user", Password: "secret"}
+			sha, err := repos.RemoteCommit(ctx, remote, "main", creds)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sha).To(Equal(initial))
+		})
+
+		It("fails if the ref does not exist", func() {
+			_, err := repos.RemoteCommit(ctx, remote, "missing", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ref 'missing' not found"))
+		})
+	})
+
+	Describe("IsCommit", func() {
+		It("only accepts full commit SHAs", func() {
+			Expect(gitrepo.IsCommit(initial)).To(BeTrue())
+			Expect(gitrepo.IsCommit("main")).To(BeFalse())
+			Expect(gitrepo.IsCommit(initial[:7])).To(BeFalse())
+		})
+	})
+})
@@ -127,3 +127,16 @@ func ServiceMutateFn(svc *corev1.Service) controllerutil.MutateFn {
 		return nil
 	}
 }
+
+// ConfigMapMutateFn returns MutateFn which mutates ConfigMap including:
+// - labels, annotations
+// - data
+func ConfigMapMutateFn(cm *corev1.ConfigMap) controllerutil.MutateFn {
+	updated := cm.DeepCopy()
+	return func() error {
+		cm.Labels = updated.Labels
+		cm.Annotations = updated.Annotations
+		cm.Data = updated.Data
+		return nil
+	}
+}
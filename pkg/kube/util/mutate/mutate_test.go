@@ -453,6 +453,88 @@ var _ = Describe("Mutate", func() {
 		})
 	})
 
+	Describe("ConfigMapMutateFn", func() {
+		var (
+			cm *corev1.ConfigMap
+		)
+
+		BeforeEach(func() {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "default",
+				},
+				Data: map[string]string{
+					"dummy": "foo-value",
+				},
+			}
+		})
+
+		Context("when the config map is not found", func() {
+			It("creates the config map", func() {
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+
+				ops, err := controllerutil.CreateOrUpdate(ctx, client, cm, mutate.ConfigMapMutateFn(cm))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ops).To(Equal(controllerutil.OperationResultCreated))
+			})
+		})
+
+		Context("when the config map is found", func() {
+			It("updates the config map when data is changed", func() {
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.ConfigMap:
+						existing := &corev1.ConfigMap{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "foo",
+								Namespace: "default",
+							},
+							Data: map[string]string{
+								"dummy": "initial-value",
+							},
+						}
+						existing.DeepCopyInto(object)
+
+						return nil
+					}
+
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+				ops, err := controllerutil.CreateOrUpdate(ctx, client, cm, mutate.ConfigMapMutateFn(cm))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ops).To(Equal(controllerutil.OperationResultUpdated))
+			})
+
+			It("does not update the config map when data is not changed", func() {
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.ConfigMap:
+						existing := &corev1.ConfigMap{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "foo",
+								Namespace: "default",
+							},
+							Data: map[string]string{
+								"dummy": "foo-value",
+							},
+						}
+						existing.DeepCopyInto(object)
+
+						return nil
+					}
+
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+				ops, err := controllerutil.CreateOrUpdate(ctx, client, cm, mutate.ConfigMapMutateFn(cm))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ops).To(Equal(controllerutil.OperationResultNone))
+			})
+		})
+	})
+
 	Describe("ServiceMutateFn", func() {
 		var (
 			svc *corev1.Service
@@ -0,0 +1,49 @@
+// Package pause checks whether the reconciliation of a BOSHDeployment and
+// the resources created for it is paused.
+package pause
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+)
+
+// IsPaused returns true if the labels reference a BOSHDeployment, which is
+// paused. Resources without a deployment label are never paused.
+func IsPaused(ctx context.Context, client crc.Client, namespace string, labels map[string]string) (bool, error) {
+	deploymentName, ok := labels[bdv1.LabelDeploymentName]
+	if !ok || deploymentName == "" {
+		return false, nil
+	}
+
+	bdpl := &bdv1.BOSHDeployment{}
+	err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: deploymentName}, bdpl)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "getting BOSHDeployment '%s/%s'", namespace, deploymentName)
+	}
+
+	return bdpl.IsPaused(), nil
+}
+
+// IsResumed returns true if the update event unpauses a BOSHDeployment
+func IsResumed(e event.UpdateEvent) bool {
+	o, ok := e.ObjectOld.(*bdv1.BOSHDeployment)
+	if !ok {
+		return false
+	}
+	n, ok := e.ObjectNew.(*bdv1.BOSHDeployment)
+	if !ok {
+		return false
+	}
+	return o.IsPaused() && !n.IsPaused()
+}
@@ -0,0 +1,423 @@
+// Package plan calculates the changes a BOSHDeployment would apply to the
+// cluster, without applying them.
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/cf-operator/pkg/bosh/bpm"
+	"code.cloudfoundry.org/cf-operator/pkg/bosh/bpmconverter"
+	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
+	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+)
+
+// Action is what would happen to a resource
+type Action string
+
+// Valid values for actions
+const (
+	// ActionCreate means the resource doesn't exist yet
+	ActionCreate Action = "create"
+	// ActionUpdate means the spec of the existing resource differs
+	ActionUpdate Action = "update"
+	// ActionDelete means the resource is no longer part of the deployment
+	ActionDelete Action = "delete"
+)
+
+// Kinds of resources included in a plan, in the order they are listed
+const (
+	KindQuarksSecret      = "QuarksSecret"
+	KindQuarksStatefulSet = "QuarksStatefulSet"
+	KindService           = "Service"
+	KindQuarksJob         = "QuarksJob"
+)
+
+// FieldChange is a spec field, which differs between the live and the desired resource
+type FieldChange struct {
+	Path    string      `json:"path"`
+	Live    interface{} `json:"live,omitempty"`
+	Desired interface{} `json:"desired,omitempty"`
+}
+
+// Change is a planned change of a single resource
+type Change struct {
+	Kind   string        `json:"kind"`
+	Name   string        `json:"name"`
+	Action Action        `json:"action"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// Plan lists all changes, which would be applied for a BOSHDeployment
+type Plan struct {
+	Deployment string   `json:"deployment"`
+	Namespace  string   `json:"namespace"`
+	Changes    []Change `json:"changes"`
+}
+
+// Marshal serializes the plan to YAML
+func (p *Plan) Marshal() ([]byte, error) {
+	return yaml.Marshal(p)
+}
+
+// Count returns the number of changes with the given action
+func (p *Plan) Count(action Action) int {
+	count := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// Summary returns a short description of the plan
+func (p *Plan) Summary() string {
+	return fmt.Sprintf("%d to create, %d to update, %d to delete",
+		p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete))
+}
+
+// WithOps interpolates BOSH manifests and operations files to create the WithOps manifest
+type WithOps interface {
+	Manifest(instance *bdv1.BOSHDeployment, namespace string) (*bdm.Manifest, []string, error)
+}
+
+// VariablesConverter converts BOSH variables into QuarksSecrets
+type VariablesConverter interface {
+	Variables(manifestName string, variables []bdm.Variable) ([]qsv1a1.QuarksSecret, error)
+}
+
+// BPMConverter converts BPM information into Kubernetes resources
+type BPMConverter interface {
+	Resources(manifestName string, dns bpmconverter.DomainNameService, qStsVersion string, instanceGroup *bdm.InstanceGroup, releaseImageProvider bdm.ReleaseImageProvider, bpmConfigs bpm.Configs, igResolvedSecretVersion string) (*bpmconverter.Resources, error)
+}
+
+// Planner calculates plans for BOSHDeployments
+type Planner struct {
+	client               crc.Client
+	withops              WithOps
+	variablesConverter   VariablesConverter
+	bpmConverter         BPMConverter
+	newDNSFunc           boshdns.NewDNSFunc
+	versionedSecretStore versionedsecretstore.VersionedSecretStore
+}
+
+// NewPlanner returns a new planner
+func NewPlanner(client crc.Client, withops WithOps, variablesConverter VariablesConverter, bpmConverter BPMConverter, newDNSFunc boshdns.NewDNSFunc) *Planner {
+	return &Planner{
+		client:               client,
+		withops:              withops,
+		variablesConverter:   variablesConverter,
+		bpmConverter:         bpmConverter,
+		newDNSFunc:           newDNSFunc,
+		versionedSecretStore: versionedsecretstore.NewVersionedSecretStore(client),
+	}
+}
+
+// resource is a named resource and the spec which is compared
+type resource struct {
+	name string
+	spec interface{}
+}
+
+// Plan resolves the manifest of the BOSHDeployment and converts it into
+// Kubernetes resources, like the controllers would. The resources are
+// compared to the live resources, nothing is applied.
+//
+// The BPM information is rendered by a QuarksJob from the release images, so
+// the plan uses the BPM information of the latest deployment. Only fields
+// set by the operator are compared, fields defaulted by Kubernetes are ignored.
+func (p *Planner) Plan(ctx context.Context, bdpl *bdv1.BOSHDeployment) (*Plan, error) {
+	namespace := bdpl.Namespace
+	manifest, _, err := p.withops.Manifest(bdpl, namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving manifest of BOSHDeployment '%s/%s'", namespace, bdpl.Name)
+	}
+
+	desired := map[string][]resource{}
+
+	qsecs, err := p.variablesConverter.Variables(bdpl.Name, manifest.Variables)
+	if err != nil {
+		return nil, errors.Wrap(err, "converting variables to quarks secrets")
+	}
+	for _, qsec := range qsecs {
+		desired[KindQuarksSecret] = append(desired[KindQuarksSecret], resource{name: qsec.Name, spec: qsec.Spec})
+	}
+
+	dns, err := p.newDNSFunc(bdpl.Name, *manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading BOSH DNS for manifest")
+	}
+
+	for _, ig := range manifest.InstanceGroups {
+		resources, err := p.instanceGroupResources(ctx, bdpl, manifest, dns, ig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting instance group '%s'", ig.Name)
+		}
+
+		for _, qsts := range resources.InstanceGroups {
+			desired[KindQuarksStatefulSet] = append(desired[KindQuarksStatefulSet], resource{name: qsts.Name, spec: qsts.Spec})
+		}
+		for _, svc := range resources.Services {
+			desired[KindService] = append(desired[KindService], resource{name: svc.Name, spec: svc.Spec})
+		}
+		for _, qJob := range resources.Errands {
+			desired[KindQuarksJob] = append(desired[KindQuarksJob], resource{name: qJob.Name, spec: qJob.Spec})
+		}
+	}
+
+	live, err := p.liveResources(ctx, bdpl)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		Deployment: bdpl.Name,
+		Namespace:  namespace,
+		Changes:    []Change{},
+	}
+	for _, kind := range []string{KindQuarksSecret, KindQuarksStatefulSet, KindService, KindQuarksJob} {
+		changes, err := compare(kind, live[kind], desired[kind])
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	return plan, nil
+}
+
+// instanceGroupResources converts an instance group of the with-ops manifest
+// with the BPM information of the latest deployment
+func (p *Planner) instanceGroupResources(ctx context.Context, bdpl *bdv1.BOSHDeployment, manifest *bdm.Manifest, dns boshdns.DomainNameService, ig *bdm.InstanceGroup) (*bpmconverter.Resources, error) {
+	bpmConfigs := bpm.Configs{}
+	bpmSecret, err := p.latestSecret(ctx, bdpl, names.DeploymentSecretBpmInformation, ig.Name)
+	if err != nil {
+		return nil, err
+	}
+	if bpmSecret != nil {
+		var bpmInfo bdm.BPMInfo
+		if err := yaml.Unmarshal(bpmSecret.Data["bpm.yaml"], &bpmInfo); err != nil {
+			return nil, errors.Wrapf(err, "unmarshalling BPM information from secret '%s'", bpmSecret.Name)
+		}
+		bpmConfigs = bpmInfo.Configs
+	}
+
+	// Fill in missing BPM information for new jobs, it is only known after rendering
+	for _, job := range ig.Jobs {
+		if _, ok := bpmConfigs[job.Name]; !ok {
+			bpmConfigs[job.Name] = bpm.Config{}
+		}
+	}
+
+	igResolvedSecretVersion := ""
+	igResolvedSecret, err := p.latestSecret(ctx, bdpl, names.DeploymentSecretTypeInstanceGroupResolvedProperties, ig.Name)
+	if err != nil {
+		return nil, err
+	}
+	if igResolvedSecret != nil {
+		igResolvedSecretVersion = igResolvedSecret.GetLabels()[versionedsecretstore.LabelVersion]
+	}
+
+	qStsVersion := "1"
+	qsts := &qstsv1a1.QuarksStatefulSet{}
+	err = p.client.Get(ctx, types.NamespacedName{Namespace: bdpl.Namespace, Name: ig.QuarksStatefulSetName(bdpl.Name)}, qsts)
+	if err == nil {
+		if v, ok := qsts.GetLabels()[bdm.LabelDeploymentVersion]; ok {
+			qStsVersion = v
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "getting QuarksStatefulSet '%s'", qsts.Name)
+	}
+
+	return p.bpmConverter.Resources(bdpl.Name, dns, qStsVersion, ig, manifest, bpmConfigs, igResolvedSecretVersion)
+}
+
+// latestSecret returns the latest version of a versioned instance group secret, or nil if it doesn't exist
+func (p *Planner) latestSecret(ctx context.Context, bdpl *bdv1.BOSHDeployment, secretType names.DeploymentSecretType, igName string) (*corev1.Secret, error) {
+	secretName := names.InstanceGroupSecretName(secretType, bdpl.Name, igName, "")
+	secret, err := p.versionedSecretStore.Latest(ctx, bdpl.Namespace, secretName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "reading latest versioned secret '%s'", secretName)
+	}
+	return secret, nil
+}
+
+// liveResources lists the resources, which belong to the BOSHDeployment.
+// Except for quarks secrets, only resources of instance groups are included.
+func (p *Planner) liveResources(ctx context.Context, bdpl *bdv1.BOSHDeployment) (map[string][]resource, error) {
+	live := map[string][]resource{}
+	opts := []crc.ListOption{
+		crc.InNamespace(bdpl.Namespace),
+		crc.MatchingLabels{bdm.LabelDeploymentName: bdpl.Name},
+	}
+
+	qsecs := &qsv1a1.QuarksSecretList{}
+	if err := p.client.List(ctx, qsecs, opts...); err != nil {
+		return nil, errors.Wrap(err, "listing quarks secrets")
+	}
+	for _, qsec := range qsecs.Items {
+		live[KindQuarksSecret] = append(live[KindQuarksSecret], resource{name: qsec.Name, spec: qsec.Spec})
+	}
+
+	qstss := &qstsv1a1.QuarksStatefulSetList{}
+	if err := p.client.List(ctx, qstss, opts...); err != nil {
+		return nil, errors.Wrap(err, "listing quarks statefulsets")
+	}
+	for _, qsts := range qstss.Items {
+		if isInstanceGroupResource(qsts.GetLabels()) {
+			live[KindQuarksStatefulSet] = append(live[KindQuarksStatefulSet], resource{name: qsts.Name, spec: qsts.Spec})
+		}
+	}
+
+	svcs := &corev1.ServiceList{}
+	if err := p.client.List(ctx, svcs, opts...); err != nil {
+		return nil, errors.Wrap(err, "listing services")
+	}
+	for _, svc := range svcs.Items {
+		if isInstanceGroupResource(svc.GetLabels()) {
+			live[KindService] = append(live[KindService], resource{name: svc.Name, spec: svc.Spec})
+		}
+	}
+
+	qJobs := &qjv1a1.QuarksJobList{}
+	if err := p.client.List(ctx, qJobs, opts...); err != nil {
+		return nil, errors.Wrap(err, "listing quarks jobs")
+	}
+	for _, qJob := range qJobs.Items {
+		if isInstanceGroupResource(qJob.GetLabels()) {
+			live[KindQuarksJob] = append(live[KindQuarksJob], resource{name: qJob.Name, spec: qJob.Spec})
+		}
+	}
+
+	return live, nil
+}
+
+func isInstanceGroupResource(labels map[string]string) bool {
+	_, ok := labels[bdm.LabelInstanceGroupName]
+	return ok
+}
+
+// compare returns the changes needed to get from the live to the desired resources
+func compare(kind string, live []resource, desired []resource) ([]Change, error) {
+	changes := []Change{}
+
+	liveByName := map[string]resource{}
+	for _, r := range live {
+		liveByName[r.name] = r
+	}
+
+	desiredNames := map[string]bool{}
+	for _, d := range desired {
+		desiredNames[d.name] = true
+
+		l, ok := liveByName[d.name]
+		if !ok {
+			changes = append(changes, Change{Kind: kind, Name: d.name, Action: ActionCreate})
+			continue
+		}
+
+		fields, err := diff(l.spec, d.spec)
+		if err != nil {
+			return changes, errors.Wrapf(err, "comparing %s '%s'", kind, d.name)
+		}
+		if len(fields) > 0 {
+			changes = append(changes, Change{Kind: kind, Name: d.name, Action: ActionUpdate, Fields: fields})
+		}
+	}
+
+	for _, l := range live {
+		if !desiredNames[l.name] {
+			changes = append(changes, Change{Kind: kind, Name: l.name, Action: ActionDelete})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes, nil
+}
+
+// diff returns the fields of the desired spec, which differ from the live spec
+func diff(live interface{}, desired interface{}) ([]FieldChange, error) {
+	l, err := toUnstructured(live)
+	if err != nil {
+		return nil, err
+	}
+	d, err := toUnstructured(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []FieldChange{}
+	diffValue("spec", l, d, &fields)
+	return fields, nil
+}
+
+func diffValue(path string, live interface{}, desired interface{}, fields *[]FieldChange) {
+	switch d := desired.(type) {
+	case nil:
+		return
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			*fields = append(*fields, FieldChange{Path: path, Live: live, Desired: desired})
+			return
+		}
+
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffValue(path+"."+k, l[k], d[k], fields)
+		}
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			*fields = append(*fields, FieldChange{Path: path, Live: live, Desired: desired})
+			return
+		}
+
+		for i := range d {
+			diffValue(fmt.Sprintf("%s[%d]", path, i), l[i], d[i], fields)
+		}
+	default:
+		if !reflect.DeepEqual(live, desired) {
+			*fields = append(*fields, FieldChange{Path: path, Live: live, Desired: desired})
+		}
+	}
+}
+
+func toUnstructured(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling spec")
+	}
+
+	var u interface{}
+	err = json.Unmarshal(data, &u)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling spec")
+	}
+	return u, nil
+}
@@ -0,0 +1,187 @@
+package plan_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/cf-operator/pkg/bosh/bpmconverter"
+	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	qsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarkssecret/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/fakes"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/plan"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+)
+
+var _ = Describe("Planner", func() {
+	var (
+		ctx          context.Context
+		client       *fakes.FakeClient
+		withops      *fakes.FakeWithOps
+		variables    *fakes.FakeVariablesConverter
+		bpmConverter *fakes.FakeBPMConverter
+		planner      *plan.Planner
+		bdpl         *bdv1.BOSHDeployment
+		igLabels     map[string]string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		bdpl = &bdv1.BOSHDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		}
+		igLabels = map[string]string{
+			bdm.LabelDeploymentName:    "foo",
+			bdm.LabelInstanceGroupName: "fakepod",
+		}
+
+		withops = &fakes.FakeWithOps{}
+		withops.ManifestReturns(&bdm.Manifest{
+			InstanceGroups: []*bdm.InstanceGroup{{Name: "fakepod"}},
+		}, []string{}, nil)
+
+		variables = &fakes.FakeVariablesConverter{}
+		variables.VariablesReturns([]qsv1a1.QuarksSecret{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo.var-new"},
+				Spec:       qsv1a1.QuarksSecretSpec{Type: qsv1a1.Password, SecretName: "foo.var-new"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo.var-same"},
+				Spec:       qsv1a1.QuarksSecretSpec{Type: qsv1a1.Password, SecretName: "foo.var-same"},
+			},
+		}, nil)
+
+		bpmConverter = &fakes.FakeBPMConverter{}
+		bpmConverter.ResourcesReturns(&bpmconverter.Resources{
+			InstanceGroups: []qstsv1a1.QuarksStatefulSet{
+				{ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod"}},
+			},
+		}, nil)
+
+		client = &fakes.FakeClient{}
+		client.GetCalls(func(_ context.Context, nn types.NamespacedName, object runtime.Object) error {
+			switch object := object.(type) {
+			case *qstsv1a1.QuarksStatefulSet:
+				object.Name = nn.Name
+				object.Labels = map[string]string{bdm.LabelDeploymentVersion: "3"}
+				return nil
+			}
+			return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+		})
+		client.ListCalls(func(_ context.Context, object runtime.Object, _ ...crc.ListOption) error {
+			switch object := object.(type) {
+			case *qsv1a1.QuarksSecretList:
+				object.Items = []qsv1a1.QuarksSecret{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "foo.var-same"},
+						Spec:       qsv1a1.QuarksSecretSpec{Type: qsv1a1.Password, SecretName: "foo.var-same"},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "foo.var-old"},
+						Spec:       qsv1a1.QuarksSecretSpec{Type: qsv1a1.Password, SecretName: "foo.var-old"},
+					},
+				}
+			case *qstsv1a1.QuarksStatefulSetList:
+				qsts := qstsv1a1.QuarksStatefulSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod", Labels: igLabels},
+				}
+				qsts.Spec.Template.Spec.Replicas = pointers.Int32(2)
+				object.Items = []qstsv1a1.QuarksStatefulSet{qsts}
+			case *corev1.ServiceList:
+				object.Items = []corev1.Service{
+					{ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod-0", Labels: igLabels}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "foo-dns", Labels: map[string]string{bdm.LabelDeploymentName: "foo"}}},
+				}
+			}
+			return nil
+		})
+
+		planner = plan.NewPlanner(client, withops, variables, bpmConverter,
+			func(name string, m bdm.Manifest) (boshdns.DomainNameService, error) {
+				return boshdns.NewSimpleDomainNameService(name), nil
+			},
+		)
+	})
+
+	Describe("Plan", func() {
+		It("lists the resources which would be created, updated and deleted", func() {
+			p, err := planner.Plan(ctx, bdpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(p.Deployment).To(Equal("foo"))
+			Expect(p.Namespace).To(Equal("default"))
+			Expect(p.Changes).To(Equal([]plan.Change{
+				{Kind: plan.KindQuarksSecret, Name: "foo.var-new", Action: plan.ActionCreate},
+				{Kind: plan.KindQuarksSecret, Name: "foo.var-old", Action: plan.ActionDelete},
+				{Kind: plan.KindService, Name: "foo-fakepod-0", Action: plan.ActionDelete},
+			}))
+			Expect(p.Summary()).To(Equal("1 to create, 0 to update, 2 to delete"))
+		})
+
+		It("converts the instance groups with the live versions", func() {
+			_, err := planner.Plan(ctx, bdpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(bpmConverter.ResourcesCallCount()).To(Equal(1))
+			name, _, version, ig, _, bpmConfigs, _ := bpmConverter.ResourcesArgsForCall(0)
+			Expect(name).To(Equal("foo"))
+			Expect(version).To(Equal("3"))
+			Expect(ig.Name).To(Equal("fakepod"))
+			Expect(bpmConfigs).To(BeEmpty())
+		})
+
+		It("lists the changed fields of updated resources", func() {
+			desired := qstsv1a1.QuarksStatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod"}}
+			desired.Spec.Template.Spec.Replicas = pointers.Int32(3)
+			bpmConverter.ResourcesReturns(&bpmconverter.Resources{
+				InstanceGroups: []qstsv1a1.QuarksStatefulSet{desired},
+			}, nil)
+
+			p, err := planner.Plan(ctx, bdpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(p.Changes).To(ContainElement(plan.Change{
+				Kind:   plan.KindQuarksStatefulSet,
+				Name:   "foo-fakepod",
+				Action: plan.ActionUpdate,
+				Fields: []plan.FieldChange{
+					{Path: "spec.template.spec.replicas", Live: float64(2), Desired: float64(3)},
+				},
+			}))
+
+			out, err := p.Marshal()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).To(ContainSubstring("path: spec.template.spec.replicas"))
+		})
+
+		It("handles an error when resolving the manifest", func() {
+			withops.ManifestReturns(nil, []string{}, fmt.Errorf("fake-error"))
+
+			_, err := planner.Plan(ctx, bdpl)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("resolving manifest of BOSHDeployment 'default/foo': fake-error"))
+		})
+
+		It("handles an error when converting an instance group", func() {
+			bpmConverter.ResourcesReturns(nil, fmt.Errorf("fake-error"))
+
+			_, err := planner.Plan(ctx, bdpl)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("converting instance group 'fakepod': fake-error"))
+		})
+	})
+})
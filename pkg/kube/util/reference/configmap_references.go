@@ -26,12 +26,18 @@ func getConfMapRefFromBdpl(object bdv1.BOSHDeployment) map[string]bool {
 	result := map[string]bool{}
 
 	if object.Spec.Manifest.Type == bdv1.ConfigMapReference {
-		result[object.Spec.Manifest.Name] = true
+		result[referenceKey(object.Namespace, object.Spec.Manifest, object.Spec.Manifest.Name)] = true
 	}
 
 	for _, ops := range object.Spec.Ops {
 		if ops.Type == bdv1.ConfigMapReference {
-			result[ops.Name] = true
+			result[referenceKey(object.Namespace, ops, ops.Name)] = true
+		}
+	}
+
+	for _, vars := range object.Spec.Vars {
+		if vars.Type == bdv1.ConfigMapReference {
+			result[referenceKey(object.Namespace, vars, vars.Name)] = true
 		}
 	}
 
@@ -13,6 +13,7 @@ import (
 	"code.cloudfoundry.org/cf-operator/pkg/kube/apis"
 	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
 	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 	vss "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
 )
@@ -38,7 +39,7 @@ func (r ReconcileType) String() string {
 // GetReconciles returns reconciliation requests for the BOSHDeployments or QuarksStatefulSets
 // that reference an object. The object can be a ConfigMap or a Secret
 func GetReconciles(ctx context.Context, client crc.Client, reconcileType ReconcileType, object apis.Object, versionCheck bool) ([]reconcile.Request, error) {
-	objReferencedBy := func(parent interface{}) (bool, error) {
+	objReferencedBy := func(parent interface{}, parentNamespace string) (bool, error) {
 		var (
 			objectReferences map[string]bool
 			err              error
@@ -49,10 +50,10 @@ func GetReconciles(ctx context.Context, client crc.Client, reconcileType Reconci
 		switch object := object.(type) {
 		case *corev1.ConfigMap:
 			objectReferences, err = GetConfigMapsReferencedBy(parent)
-			name = object.Name
+			name = objectKey(parentNamespace, object.Namespace, object.Name)
 		case *corev1.Secret:
 			objectReferences, err = GetSecretsReferencedBy(ctx, client, parent)
-			name = object.Name
+			name = objectKey(parentNamespace, object.Namespace, object.Name)
 			versionedSecret = vss.IsVersionedSecret(*object)
 
 		default:
@@ -94,7 +95,7 @@ func GetReconciles(ctx context.Context, client crc.Client, reconcileType Reconci
 		}
 
 		for _, boshDeployment := range boshDeployments.Items {
-			isRef, err := objReferencedBy(boshDeployment)
+			isRef, err := objReferencedBy(boshDeployment, boshDeployment.Namespace)
 			if err != nil {
 				return nil, err
 			}
@@ -118,7 +119,7 @@ func GetReconciles(ctx context.Context, client crc.Client, reconcileType Reconci
 				continue
 			}
 
-			isRef, err := objReferencedBy(quarksStatefulSet)
+			isRef, err := objReferencedBy(quarksStatefulSet, quarksStatefulSet.Namespace)
 			if err != nil {
 				return nil, err
 			}
@@ -173,6 +174,20 @@ func SkipReconciles(ctx context.Context, client crc.Client, object apis.Object)
 	return false
 }
 
+// objectKey returns the key of an object in the references of a parent.
+// Objects in other namespaces are prefixed with their namespace.
+func objectKey(parentNamespace string, namespace string, name string) string {
+	if namespace == "" || namespace == parentNamespace {
+		return name
+	}
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// referenceKey returns the key of a BOSHDeployment reference, see objectKey
+func referenceKey(deploymentNamespace string, ref bdv1.ResourceReference, name string) string {
+	return objectKey(deploymentNamespace, crossnamespace.Namespace(deploymentNamespace, ref), name)
+}
+
 func listBOSHDeployments(ctx context.Context, client crc.Client, namespace string) (*bdv1.BOSHDeploymentList, error) {
 	result := &bdv1.BOSHDeploymentList{}
 	err := client.List(ctx, result)
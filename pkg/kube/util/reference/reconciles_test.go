@@ -6,10 +6,12 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers"
 	cfakes "code.cloudfoundry.org/cf-operator/pkg/kube/controllers/fakes"
@@ -95,4 +97,65 @@ var _ = Describe("GetReconciles", func() {
 			})
 		})
 	})
+
+	Context("when getting reconciles for BOSHDeployments", func() {
+		var (
+			bdpl   bdv1.BOSHDeployment
+			client client.Client
+		)
+
+		BeforeEach(func() {
+			controllers.AddToScheme(scheme.Scheme)
+
+			bdpl = bdv1.BOSHDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+				Spec: bdv1.BOSHDeploymentSpec{
+					Manifest: bdv1.ResourceReference{Type: bdv1.ConfigMapReference, Name: "manifest"},
+					Ops: []bdv1.ResourceReference{
+						{Type: bdv1.ConfigMapReference, Name: "ops", Namespace: "shared"},
+					},
+					Vars: []bdv1.ResourceReference{
+						{Type: bdv1.ConfigMapReference, Name: "vars"},
+					},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			client = fake.NewFakeClient(&bdpl)
+		})
+
+		configMap := func(namespace string, name string) *corev1.ConfigMap {
+			return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		}
+
+		It("triggers a reconcile when a referenced configmap in the same namespace changes", func() {
+			requests, err := reference.GetReconciles(context.Background(), client, reference.ReconcileForBOSHDeployment, configMap("default", "manifest"), false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(requests)).To(Equal(1))
+		})
+
+		It("triggers a reconcile when a referenced vars configmap changes", func() {
+			requests, err := reference.GetReconciles(context.Background(), client, reference.ReconcileForBOSHDeployment, configMap("default", "vars"), false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(requests)).To(Equal(1))
+		})
+
+		It("triggers a reconcile when a referenced configmap in another namespace changes", func() {
+			requests, err := reference.GetReconciles(context.Background(), client, reference.ReconcileForBOSHDeployment, configMap("shared", "ops"), false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(requests)).To(Equal(1))
+			Expect(requests[0].Namespace).To(Equal("default"))
+		})
+
+		It("doesn't trigger a reconcile for a configmap with the same name in another namespace", func() {
+			requests, err := reference.GetReconciles(context.Background(), client, reference.ReconcileForBOSHDeployment, configMap("default", "ops"), false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(requests)).To(Equal(0))
+
+			requests, err = reference.GetReconciles(context.Background(), client, reference.ReconcileForBOSHDeployment, configMap("shared", "manifest"), false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(requests)).To(Equal(0))
+		})
+	})
 })
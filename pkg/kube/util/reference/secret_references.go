@@ -32,12 +32,25 @@ func getSecretRefFromBdpl(ctx context.Context, client crc.Client, object bdv1.BO
 	result := map[string]bool{}
 
 	if object.Spec.Manifest.Type == bdv1.SecretReference {
-		result[object.Spec.Manifest.Name] = true
+		result[referenceKey(object.Namespace, object.Spec.Manifest, object.Spec.Manifest.Name)] = true
 	}
 
 	for _, ops := range object.Spec.Ops {
 		if ops.Type == bdv1.SecretReference {
-			result[ops.Name] = true
+			result[referenceKey(object.Namespace, ops, ops.Name)] = true
+		}
+	}
+
+	for _, vars := range object.Spec.Vars {
+		if vars.Type == bdv1.SecretReference {
+			result[referenceKey(object.Namespace, vars, vars.Name)] = true
+		}
+	}
+
+	// Include credentials of git and url references
+	for _, ref := range object.Spec.References() {
+		if (ref.Type == bdv1.GitReference || ref.Type == bdv1.URLReference) && ref.SecretName != "" {
+			result[referenceKey(object.Namespace, ref, ref.SecretName)] = true
 		}
 	}
 
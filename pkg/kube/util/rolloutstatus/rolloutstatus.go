@@ -0,0 +1,63 @@
+// Package rolloutstatus summarizes the rollout of a QuarksStatefulSet, like
+// `kubectl rollout status` does for a StatefulSet
+package rolloutstatus
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+)
+
+// Status returns a message about the progress of the rollout and whether
+// the rollout of all StatefulSets is done. An error is returned if the
+// rollout of a StatefulSet failed or was rolled back.
+func Status(qStatefulSet *qstsv1a1.QuarksStatefulSet) (string, bool, error) {
+	rollouts := make([]qstsv1a1.StatefulSetRolloutStatus, len(qStatefulSet.Status.Rollouts))
+	copy(rollouts, qStatefulSet.Status.Rollouts)
+	if len(rollouts) == 0 {
+		return fmt.Sprintf("Waiting for rollout of QuarksStatefulSet '%s' to start...\n", qStatefulSet.Name), false, nil
+	}
+	sort.Slice(rollouts, func(i, j int) bool { return rollouts[i].Name < rollouts[j].Name })
+
+	for _, rollout := range rollouts {
+		switch rollout.Phase {
+		case qstsv1a1.RolloutPhaseFailed:
+			return "", false, errors.Errorf("rollout of StatefulSet '%s' failed", rollout.Name)
+		case qstsv1a1.RolloutPhaseDone:
+			continue
+		case qstsv1a1.RolloutPhasePaused:
+			return fmt.Sprintf("Rollout of StatefulSet '%s'%s is paused: %d of %d updated, %d ready, resume it with the 'quarks.cloudfoundry.org/rollout-action: resume' annotation...\n",
+				rollout.Name, zone(rollout), rollout.UpdatedReplicas, rollout.Replicas, rollout.ReadyReplicas), false, nil
+		}
+
+		if entry := lastRollout(qStatefulSet, rollout.Name); entry != nil && entry.Outcome == qstsv1a1.RolloutPhaseRolledBack {
+			return "", false, errors.Errorf("rollout of revision '%s' of StatefulSet '%s' failed and is rolled back", entry.Revision, rollout.Name)
+		}
+
+		return fmt.Sprintf("Waiting for StatefulSet '%s'%s rollout to finish: phase %s, partition %d, %d of %d updated, %d ready...\n",
+			rollout.Name, zone(rollout), rollout.Phase, rollout.Partition, rollout.UpdatedReplicas, rollout.Replicas, rollout.ReadyReplicas), false, nil
+	}
+
+	return fmt.Sprintf("QuarksStatefulSet '%s' successfully rolled out\n", qStatefulSet.Name), true, nil
+}
+
+// lastRollout returns the latest history entry of the named StatefulSet
+func lastRollout(qStatefulSet *qstsv1a1.QuarksStatefulSet, name string) *qstsv1a1.RolloutHistoryEntry {
+	history := qStatefulSet.Status.RolloutHistory
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].StatefulSet == name {
+			return &history[i]
+		}
+	}
+	return nil
+}
+
+func zone(rollout qstsv1a1.StatefulSetRolloutStatus) string {
+	if rollout.Zone == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (zone %s)", rollout.Zone)
+}
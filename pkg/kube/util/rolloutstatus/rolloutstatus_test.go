@@ -0,0 +1,78 @@
+package rolloutstatus_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	qstsv1a1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/rolloutstatus"
+)
+
+var _ = Describe("Status", func() {
+	var qStatefulSet *qstsv1a1.QuarksStatefulSet
+
+	BeforeEach(func() {
+		qStatefulSet = &qstsv1a1.QuarksStatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Status: qstsv1a1.QuarksStatefulSetStatus{
+				Rollouts: []qstsv1a1.StatefulSetRolloutStatus{
+					{Name: "foo-z1", Zone: "z1", Phase: "Done", Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3},
+					{Name: "foo-z0", Zone: "z0", Phase: "Canary", Partition: 2, Replicas: 3, UpdatedReplicas: 1, ReadyReplicas: 2},
+				},
+			},
+		}
+	})
+
+	It("waits for a rollout to start", func() {
+		qStatefulSet.Status.Rollouts = nil
+
+		message, done, err := rolloutstatus.Status(qStatefulSet)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(done).To(BeFalse())
+		Expect(message).To(ContainSubstring("to start"))
+	})
+
+	It("reports the progress of an unfinished StatefulSet", func() {
+		message, done, err := rolloutstatus.Status(qStatefulSet)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(done).To(BeFalse())
+		Expect(message).To(Equal("Waiting for StatefulSet 'foo-z0' (zone z0) rollout to finish: phase Canary, partition 2, 1 of 3 updated, 2 ready...\n"))
+	})
+
+	It("reports a paused rollout", func() {
+		qStatefulSet.Status.Rollouts[1].Phase = "Paused"
+
+		message, done, err := rolloutstatus.Status(qStatefulSet)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(done).To(BeFalse())
+		Expect(message).To(HavePrefix("Rollout of StatefulSet 'foo-z0' (zone z0) is paused: 1 of 3 updated, 2 ready"))
+	})
+
+	It("is done when all StatefulSets are done", func() {
+		qStatefulSet.Status.Rollouts[1].Phase = "Done"
+
+		message, done, err := rolloutstatus.Status(qStatefulSet)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(done).To(BeTrue())
+		Expect(message).To(Equal("QuarksStatefulSet 'foo' successfully rolled out\n"))
+	})
+
+	It("fails when a rollout failed", func() {
+		qStatefulSet.Status.Rollouts[1].Phase = "Failed"
+
+		_, _, err := rolloutstatus.Status(qStatefulSet)
+		Expect(err).To(MatchError("rollout of StatefulSet 'foo-z0' failed"))
+	})
+
+	It("fails while a failed rollout is rolled back", func() {
+		qStatefulSet.Status.Rollouts[1].Phase = "Pending"
+		qStatefulSet.Status.RolloutHistory = []qstsv1a1.RolloutHistoryEntry{
+			{StatefulSet: "foo-z0", Revision: "2", Outcome: "RolledBack"},
+		}
+
+		_, _, err := rolloutstatus.Status(qStatefulSet)
+		Expect(err).To(MatchError("rollout of revision '2' of StatefulSet 'foo-z0' failed and is rolled back"))
+	})
+})
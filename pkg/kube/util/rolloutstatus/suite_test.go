@@ -0,0 +1,13 @@
+package rolloutstatus_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRolloutStatus(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RolloutStatus Suite")
+}
@@ -0,0 +1,88 @@
+package secretbackend
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// File stores each secret as a JSON file in a directory. It is a local
+// stand-in for external stores, e.g. in tests.
+type File struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFile returns a backend, which stores secrets in the directory
+func NewFile(dir string) *File {
+	return &File{dir: dir}
+}
+
+// Get returns the data of the secret file at the path
+func (f *File) Get(ctx context.Context, path string) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := f.file(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &NotFoundError{Path: path}
+		}
+		return nil, errors.Wrapf(err, "could not read secret file '%s'", file)
+	}
+
+	data := map[string]string{}
+	err = json.Unmarshal(content, &data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse secret file '%s'", file)
+	}
+	return data, nil
+}
+
+// Put writes the data to the secret file at the path
+func (f *File) Put(ctx context.Context, path string, data map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := f.file(path)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrapf(err, "could not marshal secret '%s'", path)
+	}
+
+	err = os.MkdirAll(filepath.Dir(file), 0700)
+	if err != nil {
+		return errors.Wrapf(err, "could not create directory for secret '%s'", path)
+	}
+
+	err = ioutil.WriteFile(file, content, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "could not write secret file '%s'", file)
+	}
+	return nil
+}
+
+// file returns the file of the path, which has to be inside of the directory
+func (f *File) file(path string) (string, error) {
+	file := filepath.Join(f.dir, filepath.FromSlash(path)+".json")
+	rel, err := filepath.Rel(f.dir, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", errors.Errorf("path '%s' is outside of the secret directory", path)
+	}
+	return file, nil
+}
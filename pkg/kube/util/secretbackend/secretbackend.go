@@ -0,0 +1,73 @@
+// Package secretbackend stores the material of QuarksSecrets in external
+// stores, so it can be fetched from or kept outside of the cluster. The
+// backends are configured by the operator and selected by name in the
+// QuarksSecret.
+package secretbackend
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Names of the backends configured by the operator
+const (
+	// VaultBackend is the name of the Vault KV backend
+	VaultBackend = "vault"
+	// FileBackend is the name of the file backend
+	FileBackend = "file"
+)
+
+// Backend reads and writes the data of secrets in an external store
+type Backend interface {
+	// Get returns the data stored at the path. It returns a NotFoundError
+	// if there is no data at the path.
+	Get(ctx context.Context, path string) (map[string]string, error)
+	// Put stores the data at the path, replacing existing data
+	Put(ctx context.Context, path string, data map[string]string) error
+}
+
+// NotFoundError is returned if a backend has no data at a path
+type NotFoundError struct {
+	Path string
+}
+
+// Error returns the error message
+func (e *NotFoundError) Error() string {
+	return "no secret found at path '" + e.Path + "'"
+}
+
+// IsNotFound returns true if the error is a NotFoundError
+func IsNotFound(err error) bool {
+	_, ok := errors.Cause(err).(*NotFoundError)
+	return ok
+}
+
+var (
+	mu       sync.RWMutex
+	backends = map[string]Backend{}
+)
+
+// SetBackends sets the backends, which can be selected by QuarksSecrets
+func SetBackends(b map[string]Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	backends = map[string]Backend{}
+	for name, backend := range b {
+		backends[name] = backend
+	}
+}
+
+// Lookup returns the backend with the name
+func Lookup(name string) (Backend, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	backend, ok := backends[name]
+	if !ok {
+		return nil, errors.Errorf("secret backend '%s' is not configured", name)
+	}
+	return backend, nil
+}
@@ -0,0 +1,130 @@
+package secretbackend_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/secretbackend"
+)
+
+var _ = Describe("SecretBackend", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	Describe("Lookup", func() {
+		AfterEach(func() {
+			secretbackend.SetBackends(nil)
+		})
+
+		It("returns configured backends", func() {
+			file := secretbackend.NewFile("/tmp")
+			secretbackend.SetBackends(map[string]secretbackend.Backend{secretbackend.FileBackend: file})
+
+			backend, err := secretbackend.Lookup(secretbackend.FileBackend)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(backend).To(Equal(file))
+
+			_, err = secretbackend.Lookup(secretbackend.VaultBackend)
+			Expect(err).To(MatchError("secret backend 'vault' is not configured"))
+		})
+	})
+
+	Describe("File", func() {
+		var (
+			dir     string
+			backend *secretbackend.File
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "secretbackend")
+			Expect(err).ToNot(HaveOccurred())
+			backend = secretbackend.NewFile(dir)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("stores secrets", func() {
+			err := backend.Put(ctx, "default/foo", map[string]string{"password": "securepassword"})
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := backend.Get(ctx, "default/foo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(map[string]string{"password": "securepassword"}))
+		})
+
+		It("returns a not found error for missing secrets", func() {
+			_, err := backend.Get(ctx, "default/foo")
+			Expect(secretbackend.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("does not write outside of the directory", func() {
+			err := backend.Put(ctx, "../foo", map[string]string{"password": "securepassword"})
+			Expect(err).To(MatchError("path '../foo' is outside of the secret directory"))
+		})
+	})
+
+	Describe("Vault", func() {
+		var (
+			server  *ghttp.Server
+			backend *secretbackend.Vault
+		)
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			backend = secretbackend.NewVault(server.URL(), "", "token")
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("reads the latest version of a secret", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v1/secret/data/default/foo"),
+				ghttp.VerifyHeaderKV("X-Vault-Token", "token"),
+				ghttp.RespondWith(http.StatusOK, `{"data":{"data":{"password":"securepassword"},"metadata":{"version":2}}}`),
+			))
+
+			data, err := backend.Get(ctx, "default/foo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(map[string]string{"password": "securepassword"}))
+		})
+
+		It("returns a not found error for missing secrets", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, `{"errors":[]}`))
+
+			_, err := backend.Get(ctx, "default/foo")
+			Expect(secretbackend.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("writes secrets", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/v1/secret/data/default/foo"),
+				ghttp.VerifyJSON(`{"data":{"password":"securepassword"}}`),
+				ghttp.RespondWith(http.StatusOK, `{"data":{"version":1}}`),
+			))
+
+			err := backend.Put(ctx, "default/foo", map[string]string{"password": "securepassword"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns the error of vault", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, `{"errors":["permission denied"]}`))
+
+			err := backend.Put(ctx, "default/foo", map[string]string{"password": "securepassword"})
+			Expect(err).To(MatchError(`vault request for secret 'default/foo' failed with status 403: {"errors":["permission denied"]}`))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package secretbackend_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSecretBackend(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SecretBackend Suite")
+}
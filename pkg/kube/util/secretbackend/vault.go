@@ -0,0 +1,122 @@
+package secretbackend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultVaultMount is the mount path of the KV secrets engine
+	DefaultVaultMount = "secret"
+
+	vaultTimeout = 30 * time.Second
+	// maxErrorBody limits how much of an error response is included in the error
+	maxErrorBody = 256
+)
+
+// Vault stores secrets in a Vault-compatible KV version 2 secrets engine
+type Vault struct {
+	address string
+	mount   string
+	token   string
+	client  *http.Client
+}
+
+// NewVault returns a backend for the KV secrets engine mounted at mount
+func NewVault(address string, mount string, token string) *Vault {
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+	return &Vault{
+		address: strings.TrimSuffix(address, "/"),
+		mount:   strings.Trim(mount, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: vaultTimeout},
+	}
+}
+
+type vaultData struct {
+	Data map[string]string `json:"data"`
+}
+
+type vaultResponse struct {
+	Data vaultData `json:"data"`
+}
+
+// Get reads the latest version of the secret at the path
+func (v *Vault) Get(ctx context.Context, path string) (map[string]string, error) {
+	resp, err := v.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &NotFoundError{Path: path}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, vaultError(resp, path)
+	}
+
+	body := vaultResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not decode secret '%s' from vault", path)
+	}
+	if body.Data.Data == nil {
+		return nil, &NotFoundError{Path: path}
+	}
+	return body.Data.Data, nil
+}
+
+// Put writes a new version of the secret at the path
+func (v *Vault) Put(ctx context.Context, path string, data map[string]string) error {
+	content, err := json.Marshal(vaultData{Data: data})
+	if err != nil {
+		return errors.Wrapf(err, "could not marshal secret '%s'", path)
+	}
+
+	resp, err := v.do(ctx, http.MethodPost, path, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return vaultError(resp, path)
+	}
+	return nil
+}
+
+func (v *Vault) do(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
+	url := v.address + "/v1/" + v.mount + "/data/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create vault request for secret '%s'", path)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", v.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "vault request for secret '%s' failed", path)
+	}
+	return resp, nil
+}
+
+// vaultError returns an error with the beginning of the response body
+func vaultError(resp *http.Response, path string) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return errors.Errorf("vault request for secret '%s' failed with status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,13 @@
+package urlfetch_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestURLFetch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "URLFetch Suite")
+}
@@ -0,0 +1,277 @@
+// Package urlfetch downloads manifests and ops files via HTTP(S). Responses
+// are cached and revalidated with ETag and Last-Modified, so unchanged files
+// are not downloaded again on each reconcile.
+package urlfetch
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecretKeyUsername is the key of the user name for basic auth in a credentials secret
+	SecretKeyUsername = corev1.BasicAuthUsernameKey
+	// SecretKeyPassword is the key of the password for basic auth in a credentials secret
+	SecretKeyPassword = corev1.BasicAuthPasswordKey
+	// SecretKeyToken is the key of the bearer token in a credentials secret
+	SecretKeyToken = "token"
+	// SecretKeyCA is the key of the PEM encoded CA bundle in a credentials secret
+	SecretKeyCA = "ca.crt"
+
+	defaultTimeout = 30 * time.Second
+	// maxErrorBody limits how much of an error response is included in the error
+	maxErrorBody = 256
+	// maxCachedResponses limits the number of cached responses, the least
+	// recently used response is dropped first
+	maxCachedResponses = 128
+	// maxTransports limits the number of transports kept for CA bundles
+	maxTransports = 16
+)
+
+// Default is the response cache shared by all resolvers of the operator
+var Default = NewFetcher()
+
+// Credentials are used to access a protected URL. Basic auth is used if a
+// username or password is set, otherwise the bearer token. The CA bundle is
+// trusted in addition to the system CAs.
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+	CA       []byte
+}
+
+// CredentialsFromSecret reads the credentials from a secret
+func CredentialsFromSecret(secret *corev1.Secret) *Credentials {
+	return &Credentials{
+		Username: string(secret.Data[SecretKeyUsername]),
+		Password: string(secret.Data[SecretKeyPassword]),
+		Token:    string(secret.Data[SecretKeyToken]),
+		CA:       secret.Data[SecretKeyCA],
+	}
+}
+
+// authorization returns the value of the authorization header
+func (c *Credentials) authorization() string {
+	if c == nil {
+		return ""
+	}
+	if c.Username != "" || c.Password != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.Password))
+	}
+	if c.Token != "" {
+		return "Bearer " + c.Token
+	}
+	return ""
+}
+
+type entry struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// lru is a map, which drops the least recently used item once it holds
+// more than max items
+type lru struct {
+	max     int
+	ll      *list.List
+	items   map[string]*list.Element
+	onEvict func(value interface{})
+}
+
+type lruItem struct {
+	key   string
+	value interface{}
+}
+
+func newLRU(max int, onEvict func(value interface{})) *lru {
+	return &lru{
+		max:     max,
+		ll:      list.New(),
+		items:   map[string]*list.Element{},
+		onEvict: onEvict,
+	}
+}
+
+func (c *lru) get(key string) (interface{}, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*lruItem).value, true
+}
+
+func (c *lru) add(key string, value interface{}) {
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*lruItem).value = value
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruItem{key: key, value: value})
+	if c.ll.Len() > c.max {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+func (c *lru) remove(key string) {
+	if e, ok := c.items[key]; ok {
+		c.removeElement(e)
+	}
+}
+
+func (c *lru) removeElement(e *list.Element) {
+	item := c.ll.Remove(e).(*lruItem)
+	delete(c.items, item.key)
+	if c.onEvict != nil {
+		c.onEvict(item.value)
+	}
+}
+
+// Fetcher downloads files and caches the responses. Transports are shared
+// by all requests trusting the same CA bundle, so connections are reused.
+type Fetcher struct {
+	timeout    time.Duration
+	mu         sync.Mutex
+	cache      *lru
+	transports *lru
+}
+
+// NewFetcher returns a fetcher with an empty cache
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		timeout: defaultTimeout,
+		cache:   newLRU(maxCachedResponses, nil),
+		transports: newLRU(maxTransports, func(value interface{}) {
+			value.(*http.Transport).CloseIdleConnections()
+		}),
+	}
+}
+
+// Get downloads the file at url. A cached response is revalidated with the
+// server and used, if the file did not change. If expectedSHA256 is set, the
+// content has to match the checksum.
+func (f *Fetcher) Get(ctx context.Context, url string, creds *Credentials, expectedSHA256 string) ([]byte, error) {
+	client, err := f.client(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid url '%s'", url)
+	}
+	req = req.WithContext(ctx)
+
+	auth := creds.authorization()
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	key := cacheKey(url, auth)
+	f.mu.Lock()
+	value, ok := f.cache.get(key)
+	f.mu.Unlock()
+	var cached entry
+	if ok {
+		cached = value.(entry)
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get url '%s'", url)
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		body = cached.body
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		body, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read response body of url '%s'", url)
+		}
+
+		f.mu.Lock()
+		if resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "" {
+			f.cache.add(key, entry{
+				etag:         resp.Header.Get("ETag"),
+				lastModified: resp.Header.Get("Last-Modified"),
+				body:         body,
+			})
+		} else {
+			f.cache.remove(key)
+		}
+		f.mu.Unlock()
+	default:
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, errors.Errorf("unexpected response '%s' from url '%s': %s", resp.Status, url, strings.TrimSpace(string(msg)))
+	}
+
+	if expectedSHA256 != "" {
+		sum := fmt.Sprintf("%x", sha256.Sum256(body))
+		expected := strings.ToLower(strings.TrimPrefix(expectedSHA256, "sha256:"))
+		if sum != expected {
+			return nil, errors.Errorf("checksum mismatch for url '%s': expected sha256 %s, got %s", url, expected, sum)
+		}
+	}
+
+	return body, nil
+}
+
+// client returns a HTTP client, which trusts the CA of the credentials
+func (f *Fetcher) client(creds *Credentials) (*http.Client, error) {
+	client := &http.Client{Timeout: f.timeout}
+	if creds == nil || len(creds.CA) == 0 {
+		return client, nil
+	}
+
+	key := fmt.Sprintf("%x", sha256.Sum256(creds.CA))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if transport, ok := f.transports.get(key); ok {
+		client.Transport = transport.(*http.Transport)
+		return client, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(creds.CA) {
+		return nil, errors.New("failed to parse CA bundle, no PEM encoded certificates found")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	f.transports.add(key, transport)
+	client.Transport = transport
+	return client, nil
+}
+
+// cacheKey separates the responses for different credentials
+func cacheKey(url string, auth string) string {
+	return fmt.Sprintf("%s#%x", url, sha256.Sum256([]byte(auth)))
+}
@@ -0,0 +1,215 @@
+package urlfetch_test
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/urlfetch"
+)
+
+var _ = Describe("Fetcher", func() {
+	var (
+		ctx     context.Context
+		server  *ghttp.Server
+		fetcher *urlfetch.Fetcher
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server = ghttp.NewServer()
+		fetcher = urlfetch.NewFetcher()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Get", func() {
+		It("returns the response body", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "name: foo"))
+
+			body, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", nil, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("name: foo"))
+		})
+
+		It("revalidates cached responses with the etag", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, "name: foo", http.Header{"ETag": []string{`"v1"`}}),
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("If-None-Match", `"v1"`),
+					ghttp.RespondWith(http.StatusNotModified, nil),
+				),
+			)
+
+			_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", nil, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			body, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", nil, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("name: foo"))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("revalidates cached responses with the modification time", func() {
+			modified := "Wed, 21 Oct 2015 07:28:00 GMT"
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, "name: foo", http.Header{"Last-Modified": []string{modified}}),
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("If-Modified-Since", modified),
+					ghttp.RespondWith(http.StatusNotModified, nil),
+				),
+			)
+
+			_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", nil, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			body, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", nil, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("name: foo"))
+		})
+
+		It("drops the least recently used responses", func() {
+			server.RouteToHandler(http.MethodGet, regexp.MustCompile(`/manifest-\d+\.yml`), func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				_, _ = w.Write([]byte("name: foo"))
+			})
+
+			for i := 0; i <= 128; i++ {
+				_, err := fetcher.Get(ctx, fmt.Sprintf("%s/manifest-%d.yml", server.URL(), i), nil, "")
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := fetcher.Get(ctx, server.URL()+"/manifest-128.yml", nil, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()[129].Header.Get("If-None-Match")).To(Equal(`"v1"`))
+
+			_, err = fetcher.Get(ctx, server.URL()+"/manifest-0.yml", nil, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()[130].Header.Get("If-None-Match")).To(BeEmpty())
+		})
+
+		It("does not share cached responses between credentials", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, "name: foo", http.Header{"ETag": []string{`"v1"`}}),
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeader(http.Header{"If-None-Match": nil}),
+					ghttp.RespondWith(http.StatusUnauthorized, "denied"),
+				),
+			)
+
+			_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", &urlfetch.Credentials{Token: "valid"}, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = fetcher.Get(ctx, server.URL()+"/manifest.yml", &urlfetch.Credentials{Token: "invalid"}, "")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("sends basic auth credentials", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyBasicAuth("admin", "secret"),
+				ghttp.RespondWith(http.StatusOK, "name: foo"),
+			))
+
+			_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", &urlfetch.Credentials{Username: "admin", Password: "secret"}, "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("sends a bearer token", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "Bearer foo-token"),
+				ghttp.RespondWith(http.StatusOK, "name: foo"),
+			))
+
+			_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", &urlfetch.Credentials{Token: "foo-token"}, "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error including the status for unsuccessful responses", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, "access denied"))
+
+			_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", nil, "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unexpected response '403 Forbidden'"))
+			Expect(err.Error()).To(ContainSubstring("access denied"))
+		})
+
+		It("verifies the checksum", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, "---\n"),
+				ghttp.RespondWith(http.StatusOK, "---\n"),
+			)
+
+			_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", nil, "F52D711103D50A437830C6FBCD04FB4BAB49A0F82F6D26D1C791C6E8488DD090")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = fetcher.Get(ctx, server.URL()+"/manifest.yml", nil, "sha256:0000")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("checksum mismatch"))
+		})
+
+		Context("when using TLS", func() {
+			BeforeEach(func() {
+				server.Close()
+				server = ghttp.NewTLSServer()
+				server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "name: foo"))
+			})
+
+			It("trusts the CA bundle", func() {
+				ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.HTTPTestServer.Certificate().Raw})
+
+				body, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", &urlfetch.Credentials{CA: ca}, "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("name: foo"))
+			})
+
+			It("reuses connections for the same CA bundle", func() {
+				server.Close()
+				server = ghttp.NewUnstartedServer()
+				var connections int32
+				server.HTTPTestServer.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+					if state == http.StateNew {
+						atomic.AddInt32(&connections, 1)
+					}
+				}
+				server.HTTPTestServer.StartTLS()
+				server.AppendHandlers(
+					ghttp.RespondWith(http.StatusOK, "name: foo"),
+					ghttp.RespondWith(http.StatusOK, "name: foo"),
+				)
+				ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.HTTPTestServer.Certificate().Raw})
+
+				for i := 0; i < 2; i++ {
+					_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", &urlfetch.Credentials{CA: ca}, "")
+					Expect(err).ToNot(HaveOccurred())
+				}
+				Expect(atomic.LoadInt32(&connections)).To(Equal(int32(1)))
+			})
+
+			It("fails for unknown certificates", func() {
+				_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", nil, "")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("certificate"))
+			})
+
+			It("fails for an invalid CA bundle", func() {
+				_, err := fetcher.Get(ctx, server.URL()+"/manifest.yml", &urlfetch.Credentials{CA: []byte("invalid")}, "")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to parse CA bundle"))
+			})
+		})
+	})
+})
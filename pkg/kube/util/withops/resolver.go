@@ -3,8 +3,6 @@ package withops
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"reflect"
 	"strings"
 
@@ -13,9 +11,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 
 	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/gitrepo"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/urlfetch"
 	"code.cloudfoundry.org/quarks-utils/pkg/names"
 	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
 )
@@ -32,6 +34,8 @@ type Resolver struct {
 	versionedSecretStore versionedsecretstore.VersionedSecretStore
 	newInterpolatorFunc  NewInterpolatorFunc
 	newDNSFunc           NewDNSFunc
+	git                  *gitrepo.Repositories
+	url                  *urlfetch.Fetcher
 }
 
 // NewInterpolatorFunc returns a fresh Interpolator
@@ -46,6 +50,8 @@ func NewResolver(client client.Client, f NewInterpolatorFunc, dns NewDNSFunc) *R
 		client:               client,
 		newInterpolatorFunc:  f,
 		newDNSFunc:           dns,
+		git:                  gitrepo.Default,
+		url:                  urlfetch.Default,
 		versionedSecretStore: versionedsecretstore.NewVersionedSecretStore(client),
 	}
 }
@@ -54,6 +60,20 @@ func NewResolver(client client.Client, f NewInterpolatorFunc, dns NewDNSFunc) *R
 // The resulting manifest has variables interpolated and ops files applied.
 // It is the 'with-ops' manifest.
 func (r *Resolver) Manifest(bdpl *bdv1.BOSHDeployment, namespace string) (*bdm.Manifest, []string, error) {
+	manifest, varSecrets, _, err := r.resolve(bdpl, namespace)
+	return manifest, varSecrets, err
+}
+
+// ManifestWithGitReferences returns the 'with-ops' manifest like Manifest and
+// the commits of the git references it was resolved from
+func (r *Resolver) ManifestWithGitReferences(bdpl *bdv1.BOSHDeployment, namespace string) (*bdm.Manifest, []bdv1.GitReferenceStatus, error) {
+	manifest, _, gitReferences, err := r.resolve(bdpl, namespace)
+	return manifest, gitReferences, err
+}
+
+// resolve returns the 'with-ops' manifest, the implicit variables and the
+// commits of the git references
+func (r *Resolver) resolve(bdpl *bdv1.BOSHDeployment, namespace string) (*bdm.Manifest, []string, []bdv1.GitReferenceStatus, error) {
 	interpolator := r.newInterpolatorFunc()
 	spec := bdpl.Spec
 	var (
@@ -61,22 +81,23 @@ func (r *Resolver) Manifest(bdpl *bdv1.BOSHDeployment, namespace string) (*bdm.M
 		err error
 	)
 
-	m, err = r.resourceData(namespace, spec.Manifest.Type, spec.Manifest.Name, bdv1.ManifestSpecName)
+	gitReferences := []bdv1.GitReferenceStatus{}
+	m, err = r.referenceData(namespace, spec.Manifest, bdv1.ManifestSpecName, &gitReferences)
 	if err != nil {
-		return nil, []string{}, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", bdpl.GetName())
+		return nil, []string{}, nil, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", bdpl.GetName())
 	}
 
 	// Interpolate manifest with ops
 	ops := spec.Ops
 
 	for _, op := range ops {
-		opsData, err := r.resourceData(namespace, op.Type, op.Name, bdv1.OpsSpecName)
+		opsData, err := r.referenceData(namespace, op, bdv1.OpsSpecName, &gitReferences)
 		if err != nil {
-			return nil, []string{}, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", bdpl.GetName())
+			return nil, []string{}, nil, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", bdpl.GetName())
 		}
 		err = interpolator.BuildOps([]byte(opsData))
 		if err != nil {
-			return nil, []string{}, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", bdpl.GetName())
+			return nil, []string{}, nil, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", bdpl.GetName())
 		}
 	}
 
@@ -84,61 +105,45 @@ func (r *Resolver) Manifest(bdpl *bdv1.BOSHDeployment, namespace string) (*bdm.M
 	if len(ops) != 0 {
 		bytes, err = interpolator.Interpolate([]byte(m))
 		if err != nil {
-			return nil, []string{}, errors.Wrapf(err, "Failed to interpolate %#v in interpolation task", m)
+			return nil, []string{}, nil, errors.Wrapf(err, "Failed to interpolate %#v in interpolation task", m)
 		}
 	}
 
 	// Reload the manifest after interpolation, and apply implicit variables
 	manifest, err := bdm.LoadYAML(bytes)
 	if err != nil {
-		return nil, []string{}, errors.Wrapf(err, "Loading yaml failed in interpolation task after applying ops %#v", m)
+		return nil, []string{}, nil, errors.Wrapf(err, "Loading yaml failed in interpolation task after applying ops %#v", m)
 	}
 
-	// Interpolate implicit variables
-	vars, err := manifest.ImplicitVariables()
+	// Interpolate variables of the vars references, before implicit variables are looked up
+	manifest, err = r.applyVars(manifest, namespace, spec.Vars, &gitReferences)
 	if err != nil {
-		return nil, []string{}, errors.Wrapf(err, "failed to list implicit variables")
+		return nil, []string{}, nil, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", bdpl.GetName())
 	}
 
-	varSecrets := make([]string, len(vars))
-	for i, v := range vars {
-		varKeyName := ""
-		varSecretName := ""
-		if strings.Contains(v, "/") {
-			parts := strings.Split(v, "/")
-			if len(parts) != 2 {
-				return nil, []string{}, fmt.Errorf("expected one / separator for implicit variable/key name, have %d", len(parts))
-			}
-
-			varSecretName = names.DeploymentSecretName(names.DeploymentSecretTypeVariable, bdpl.GetName(), parts[0])
-			varKeyName = parts[1]
-		} else {
-			varKeyName = bdv1.ImplicitVariableKeyName
-			varSecretName = names.DeploymentSecretName(names.DeploymentSecretTypeVariable, bdpl.GetName(), v)
-		}
-
-		varData, err := r.resourceData(namespace, bdv1.SecretReference, varSecretName, varKeyName)
-		if err != nil {
-			return nil, varSecrets, errors.Wrapf(err, "failed to load secret for variable '%s'", v)
-		}
-
-		varSecrets[i] = varSecretName
-		manifest = r.replaceVar(manifest, v, varData)
+	// Interpolate implicit variables
+	manifest, varSecrets, err := r.applyImplicitVars(bdpl, namespace, manifest)
+	if err != nil {
+		return nil, varSecrets, nil, err
 	}
 
 	// Apply addons
 	err = manifest.ApplyAddons()
 	if err != nil {
-		return nil, varSecrets, errors.Wrapf(err, "failed to apply addons")
+		return nil, varSecrets, nil, errors.Wrapf(err, "failed to apply addons")
 	}
 
 	dns, err := r.newDNSFunc(bdpl.Name, *manifest)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	manifest.ApplyUpdateBlock(dns)
 
-	return manifest, varSecrets, err
+	if len(gitReferences) == 0 {
+		gitReferences = nil
+	}
+
+	return manifest, varSecrets, gitReferences, err
 }
 
 // ManifestDetailed returns manifest and a list of implicit variables referenced by our bdpl CRD
@@ -151,7 +156,8 @@ func (r *Resolver) ManifestDetailed(bdpl *bdv1.BOSHDeployment, namespace string)
 		err error
 	)
 
-	m, err = r.resourceData(namespace, spec.Manifest.Type, spec.Manifest.Name, bdv1.ManifestSpecName)
+	gitReferences := []bdv1.GitReferenceStatus{}
+	m, err = r.referenceData(namespace, spec.Manifest, bdv1.ManifestSpecName, &gitReferences)
 	if err != nil {
 		return nil, []string{}, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", bdpl.GetName())
 	}
@@ -163,7 +169,7 @@ func (r *Resolver) ManifestDetailed(bdpl *bdv1.BOSHDeployment, namespace string)
 	for _, op := range ops {
 		interpolator := r.newInterpolatorFunc()
 
-		opsData, err := r.resourceData(namespace, op.Type, op.Name, bdv1.OpsSpecName)
+		opsData, err := r.referenceData(namespace, op, bdv1.OpsSpecName, &gitReferences)
 		if err != nil {
 			return nil, []string{}, errors.Wrapf(err, "Failed to get resource data for interpolation of bosh deployment '%s' and ops '%s'", bdpl.GetName(), op.Name)
 		}
@@ -184,12 +190,75 @@ func (r *Resolver) ManifestDetailed(bdpl *bdv1.BOSHDeployment, namespace string)
 		return nil, []string{}, errors.Wrapf(err, "Loading yaml failed in interpolation task after applying ops %#v", m)
 	}
 
+	// Interpolate variables of the vars references, before implicit variables are looked up
+	manifest, err = r.applyVars(manifest, namespace, spec.Vars, &gitReferences)
+	if err != nil {
+		return nil, []string{}, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", bdpl.GetName())
+	}
+
 	// Interpolate implicit variables
+	manifest, varSecrets, err := r.applyImplicitVars(bdpl, namespace, manifest)
+	if err != nil {
+		return nil, varSecrets, err
+	}
+
+	// Apply addons
+	err = manifest.ApplyAddons()
+	if err != nil {
+		return nil, varSecrets, errors.Wrapf(err, "failed to apply addons")
+	}
+
+	dns, err := r.newDNSFunc(bdpl.Name, *manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest.ApplyUpdateBlock(dns)
+
+	return manifest, varSecrets, err
+}
+
+// applyVars loads the YAML maps of the vars references and replaces the
+// placeholders of their variables in the manifest. Variables of later
+// references override those of earlier ones.
+func (r *Resolver) applyVars(manifest *bdm.Manifest, namespace string, refs []bdv1.ResourceReference, gitReferences *[]bdv1.GitReferenceStatus) (*bdm.Manifest, error) {
+	if len(refs) == 0 {
+		return manifest, nil
+	}
+
+	vars := map[string]interface{}{}
+	for _, ref := range refs {
+		data, err := r.referenceData(namespace, ref, bdv1.VarsSpecName, gitReferences)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load vars '%s'", ref.Name)
+		}
+
+		refVars := map[string]interface{}{}
+		err = yaml.Unmarshal([]byte(data), &refVars)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal vars '%s'", ref.Name)
+		}
+		for name, value := range refVars {
+			vars[name] = value
+		}
+	}
+
+	err := replaceVars(manifest, bdm.StaticVariables(vars))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to interpolate vars")
+	}
+	return manifest, nil
+}
+
+// applyImplicitVars loads the values of the implicit variables from their
+// secrets and replaces them in a single pass over the manifest. It returns
+// the names of the secrets.
+func (r *Resolver) applyImplicitVars(bdpl *bdv1.BOSHDeployment, namespace string, manifest *bdm.Manifest) (*bdm.Manifest, []string, error) {
 	vars, err := manifest.ImplicitVariables()
 	if err != nil {
 		return nil, []string{}, errors.Wrapf(err, "failed to list implicit variables")
 	}
 
+	values := make(map[string]interface{}, len(vars))
 	varSecrets := make([]string, len(vars))
 	for i, v := range vars {
 		varKeyName := ""
@@ -207,135 +276,318 @@ func (r *Resolver) ManifestDetailed(bdpl *bdv1.BOSHDeployment, namespace string)
 			varSecretName = names.DeploymentSecretName(names.DeploymentSecretTypeVariable, bdpl.GetName(), v)
 		}
 
-		varData, err := r.resourceData(namespace, bdv1.SecretReference, varSecretName, varKeyName)
+		varData, _, err := r.resourceData(namespace, bdv1.ResourceReference{Type: bdv1.SecretReference, Name: varSecretName}, varKeyName)
 		if err != nil {
 			return nil, varSecrets, errors.Wrapf(err, "failed to load secret for variable '%s'", v)
 		}
 
 		varSecrets[i] = varSecretName
-		manifest = r.replaceVar(manifest, v, varData)
+		values[v] = implicitVarValue(varData)
 	}
 
-	// Apply addons
-	err = manifest.ApplyAddons()
-	if err != nil {
-		return nil, varSecrets, errors.Wrapf(err, "failed to apply addons")
+	if len(values) == 0 {
+		return manifest, varSecrets, nil
 	}
 
-	dns, err := r.newDNSFunc(bdpl.Name, *manifest)
+	err = replaceVars(manifest, bdm.StaticVariables(values))
 	if err != nil {
-		return nil, nil, err
+		return nil, varSecrets, errors.Wrapf(err, "failed to interpolate implicit variables")
 	}
-	manifest.ApplyUpdateBlock(dns)
-
-	return manifest, varSecrets, err
+	return manifest, varSecrets, nil
 }
 
-func (r *Resolver) replaceVar(manifest *bdm.Manifest, name, value string) *bdm.Manifest {
-	original := reflect.ValueOf(manifest)
-	replaced := reflect.New(original.Type()).Elem()
+// implicitVarValue returns the value of an implicit variable secret.
+// Implicit variables are strings, only YAML maps and lists are parsed, so
+// they can be used as whole values. Scalars are never reinterpreted, e.g. a
+// password 'yes' or '0123' is kept as is.
+func implicitVarValue(data string) interface{} {
+	var value interface{}
+	err := yaml.Unmarshal([]byte(data), &value)
+	if err != nil {
+		return data
+	}
 
-	r.replaceVarRecursive(replaced, original, name, value)
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return value
+	}
+	return data
+}
 
-	return replaced.Interface().(*bdm.Manifest)
+// replaceVars replaces the placeholders of the variables in a single pass
+// over the manifest. The manifest is modified in place and only strings
+// containing placeholders are touched, so large manifests are not copied.
+// Placeholders, which are the entire value of an untyped property, are
+// replaced by the typed value of the variable.
+func replaceVars(manifest *bdm.Manifest, lookup bdm.VariableLookup) error {
+	return replaceVarsRecursive(reflect.ValueOf(manifest), lookup)
 }
-func (r *Resolver) replaceVarRecursive(copy, v reflect.Value, varName, varValue string) {
+
+func replaceVarsRecursive(v reflect.Value, lookup bdm.VariableLookup) error {
 	switch v.Kind() {
 	case reflect.Ptr:
-		if !v.Elem().IsValid() {
-			return
+		if v.IsNil() {
+			return nil
 		}
-		copy.Set(reflect.New(v.Elem().Type()))
-		r.replaceVarRecursive(copy.Elem(), reflect.Indirect(v), varName, varValue)
+		return replaceVarsRecursive(v.Elem(), lookup)
 
 	case reflect.Interface:
-		originalValue := v.Elem()
-		if !originalValue.IsValid() {
-			return
+		if v.IsNil() {
+			return nil
+		}
+		elem := v.Elem()
+		if elem.Kind() != reflect.String {
+			return replaceVarsRecursive(elem, lookup)
+		}
+		if !v.CanSet() {
+			return nil
 		}
-		copyValue := reflect.New(originalValue.Type()).Elem()
-		r.replaceVarRecursive(copyValue, originalValue, varName, varValue)
-		copy.Set(copyValue)
 
-	case reflect.Struct:
-		deepCopy := v.MethodByName("DeepCopy")
-		if (deepCopy != reflect.Value{}) {
-			values := deepCopy.Call([]reflect.Value{})
-			copy.Set(values[0])
+		value, changed, err := interpolate(elem.String(), lookup)
+		if err != nil || !changed {
+			return err
 		}
+		v.Set(untypedValue(value, v.Type()))
+
+	case reflect.Struct:
+		t := v.Type()
 		for i := 0; i < v.NumField(); i++ {
-			r.replaceVarRecursive(copy.Field(i), v.Field(i), varName, varValue)
+			// Unexported fields can't be set
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			err := replaceVarsRecursive(v.Field(i), lookup)
+			if err != nil {
+				return err
+			}
 		}
 
-	case reflect.Slice:
-		copy.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Cap()))
+	case reflect.Slice, reflect.Array:
 		for i := 0; i < v.Len(); i++ {
-			r.replaceVarRecursive(copy.Index(i), v.Index(i), varName, varValue)
+			err := replaceVarsRecursive(v.Index(i), lookup)
+			if err != nil {
+				return err
+			}
 		}
 
 	case reflect.Map:
-		copy.Set(reflect.MakeMap(v.Type()))
-		for _, key := range v.MapKeys() {
-			originalValue := v.MapIndex(key)
-			copyValue := reflect.New(originalValue.Type()).Elem()
-			r.replaceVarRecursive(copyValue, originalValue, varName, varValue)
-			copy.SetMapIndex(key, copyValue)
+		// Map values are not addressable, changed values are set again
+		iter := v.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			elem := value
+			if elem.Kind() == reflect.Interface {
+				if elem.IsNil() {
+					continue
+				}
+				elem = elem.Elem()
+			}
+
+			switch elem.Kind() {
+			case reflect.String:
+				replaced, changed, err := interpolate(elem.String(), lookup)
+				if err != nil {
+					return err
+				}
+				if !changed {
+					continue
+				}
+				if value.Kind() == reflect.Interface {
+					v.SetMapIndex(iter.Key(), untypedValue(replaced, value.Type()))
+					continue
+				}
+				str, err := stringValue(replaced)
+				if err != nil {
+					return err
+				}
+				v.SetMapIndex(iter.Key(), reflect.ValueOf(str).Convert(value.Type()))
+
+			case reflect.Struct, reflect.Array:
+				copy := reflect.New(elem.Type()).Elem()
+				copy.Set(elem)
+				err := replaceVarsRecursive(copy, lookup)
+				if err != nil {
+					return err
+				}
+				v.SetMapIndex(iter.Key(), copy)
+
+			default:
+				err := replaceVarsRecursive(elem, lookup)
+				if err != nil {
+					return err
+				}
+			}
 		}
 
 	case reflect.String:
-		if copy.CanSet() {
-			replaced := strings.Replace(v.String(), fmt.Sprintf("((%s))", varName), varValue, -1)
-			copy.SetString(replaced)
+		if !v.CanSet() {
+			return nil
 		}
-	default:
-		if copy.CanSet() {
-			copy.Set(v)
+		value, changed, err := interpolate(v.String(), lookup)
+		if err != nil || !changed {
+			return err
 		}
+		// Typed string fields receive the string representation
+		str, err := stringValue(value)
+		if err != nil {
+			return err
+		}
+		v.SetString(str)
 	}
+	return nil
 }
 
-// resourceData resolves different manifest reference types and returns the resource's data
-func (r *Resolver) resourceData(namespace string, resType bdv1.ReferenceType, name string, key string) (string, error) {
+// interpolate replaces the placeholders in s and reports whether s contained
+// any. Strings without placeholders are returned without further processing.
+func interpolate(s string, lookup bdm.VariableLookup) (interface{}, bool, error) {
+	if !strings.Contains(s, "((") {
+		return s, false, nil
+	}
+	value, err := bdm.InterpolateString(s, lookup)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// untypedValue returns the value for an interface of type t
+func untypedValue(value interface{}, t reflect.Type) reflect.Value {
+	if value == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(value)
+}
+
+// stringValue returns the string representation of a variable value
+func stringValue(value interface{}) (string, error) {
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	bytes, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bytes)), nil
+}
+
+// referenceData returns the data of a manifest or ops reference and records
+// the commit of git references
+func (r *Resolver) referenceData(namespace string, ref bdv1.ResourceReference, key string, gitReferences *[]bdv1.GitReferenceStatus) (string, error) {
+	data, commit, err := r.resourceData(namespace, ref, key)
+	if err != nil {
+		return data, err
+	}
+
+	if ref.Type == bdv1.GitReference {
+		*gitReferences = append(*gitReferences, bdv1.GitReferenceStatus{
+			Repository: ref.Name,
+			Ref:        ref.Ref,
+			Path:       ref.Path,
+			Commit:     commit,
+		})
+	}
+	return data, nil
+}
+
+// resourceData resolves different manifest reference types and returns the
+// resource's data. For git references the resolved commit is returned, too.
+func (r *Resolver) resourceData(namespace string, ref bdv1.ResourceReference, key string) (string, string, error) {
 	var (
 		data string
 		ok   bool
 	)
+	name := ref.Name
 
-	switch resType {
+	err := crossnamespace.Validate(namespace, ref)
+	if err != nil {
+		return data, "", err
+	}
+	namespace = crossnamespace.Namespace(namespace, ref)
+
+	switch ref.Type {
 	case bdv1.ConfigMapReference:
 		opsConfig := &corev1.ConfigMap{}
 		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, opsConfig)
 		if err != nil {
-			return data, errors.Wrapf(err, "failed to retrieve %s from configmap '%s/%s' via client.Get", key, namespace, name)
+			return data, "", errors.Wrapf(err, "failed to retrieve %s from configmap '%s/%s' via client.Get", key, namespace, name)
 		}
 		data, ok = opsConfig.Data[key]
 		if !ok {
-			return data, fmt.Errorf("configMap '%s/%s' doesn't contain key %s", namespace, name, key)
+			return data, "", fmt.Errorf("configMap '%s/%s' doesn't contain key %s", namespace, name, key)
 		}
 	case bdv1.SecretReference:
 		opsSecret := &corev1.Secret{}
 		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, opsSecret)
 		if err != nil {
-			return data, errors.Wrapf(err, "failed to retrieve %s from secret '%s/%s' via client.Get", key, namespace, name)
+			return data, "", errors.Wrapf(err, "failed to retrieve %s from secret '%s/%s' via client.Get", key, namespace, name)
 		}
 		encodedData, ok := opsSecret.Data[key]
 		if !ok {
-			return data, fmt.Errorf("secret '%s/%s' doesn't contain key %s", namespace, name, key)
+			return data, "", fmt.Errorf("secret '%s/%s' doesn't contain key %s", namespace, name, key)
 		}
 		data = string(encodedData)
 	case bdv1.URLReference:
-		httpResponse, err := http.Get(name)
+		creds, err := urlCredentials(context.TODO(), r.client, namespace, ref)
 		if err != nil {
-			return data, errors.Wrapf(err, "failed to resolve %s from url '%s' via http.Get", key, name)
+			return data, "", err
 		}
-		body, err := ioutil.ReadAll(httpResponse.Body)
+		body, err := r.url.Get(context.TODO(), name, creds, ref.SHA256)
 		if err != nil {
-			return data, errors.Wrapf(err, "failed to read %s response body '%s' via ioutil", key, name)
+			return data, "", errors.Wrapf(err, "failed to resolve %s from url", key)
 		}
 		data = string(body)
+	case bdv1.GitReference:
+		creds, err := GitCredentials(context.TODO(), r.client, namespace, ref)
+		if err != nil {
+			return data, "", err
+		}
+		data, commit, err := r.git.Fetch(context.TODO(), name, ref.Ref, ref.Path, creds)
+		if err != nil {
+			return data, "", errors.Wrapf(err, "failed to resolve %s from git repository '%s'", key, name)
+		}
+		return data, commit, nil
 	default:
-		return data, fmt.Errorf("unrecognized %s ref type %s", key, name)
+		return data, "", fmt.Errorf("unrecognized %s ref type %s", key, name)
 	}
 
-	return data, nil
+	return data, "", nil
+}
+
+// GitCredentials reads the credentials secret of a git reference, it returns
+// nil if the reference has no secret
+func GitCredentials(ctx context.Context, client client.Client, namespace string, ref bdv1.ResourceReference) (*gitrepo.Credentials, error) {
+	secret, err := credentialsSecret(ctx, client, namespace, ref)
+	if secret == nil || err != nil {
+		return nil, err
+	}
+	return gitrepo.CredentialsFromSecret(secret), nil
+}
+
+// urlCredentials reads the credentials secret of an url reference, it returns
+// nil if the reference has no secret
+func urlCredentials(ctx context.Context, client client.Client, namespace string, ref bdv1.ResourceReference) (*urlfetch.Credentials, error) {
+	secret, err := credentialsSecret(ctx, client, namespace, ref)
+	if secret == nil || err != nil {
+		return nil, err
+	}
+	return urlfetch.CredentialsFromSecret(secret), nil
+}
+
+func credentialsSecret(ctx context.Context, client client.Client, namespace string, ref bdv1.ResourceReference) (*corev1.Secret, error) {
+	if ref.SecretName == "" {
+		return nil, nil
+	}
+
+	err := crossnamespace.Validate(namespace, ref)
+	if err != nil {
+		return nil, err
+	}
+	namespace = crossnamespace.Namespace(namespace, ref)
+
+	secret := &corev1.Secret{}
+	err = client.Get(ctx, types.NamespacedName{Name: ref.SecretName, Namespace: namespace}, secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve credentials for %s reference '%s' from secret '%s/%s'", ref.Type, ref.Name, namespace, ref.SecretName)
+	}
+	return secret, nil
 }
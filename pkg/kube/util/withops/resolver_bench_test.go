@@ -0,0 +1,137 @@
+package withops_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bdm "code.cloudfoundry.org/cf-operator/pkg/bosh/manifest"
+	bdc "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/withops"
+	"code.cloudfoundry.org/cf-operator/testing/boshmanifest"
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+const benchmarkDeployment = "bench"
+
+// BenchmarkResolver measures resolving the manifests used in the tests,
+// including the synchronous ManifestDetailed call of the validating webhook
+func BenchmarkResolver(b *testing.B) {
+	manifests := map[string]string{
+		"Default":                 boshmanifest.Default,
+		"WithAddons":              boshmanifest.WithAddons,
+		"Elaborated":              boshmanifest.Elaborated,
+		"CFRouting":               boshmanifest.CFRouting,
+		"Diego":                   boshmanifest.Diego,
+		"ManifestWithLargeValues": boshmanifest.ManifestWithLargeValues,
+		"ManyImplicitVariables":   manyImplicitVariables(b, boshmanifest.ManifestWithLargeValues, 500),
+	}
+
+	for name, manifest := range manifests {
+		resolver, deployment := benchmarkResolver(b, manifest)
+
+		b.Run(name+"/Manifest", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _, err := resolver.Manifest(deployment, "default")
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(name+"/ManifestDetailed", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _, err := resolver.ManifestDetailed(deployment, "default")
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// benchmarkResolver returns a resolver with a client containing the manifest
+// and a secret for each of its implicit variables
+func benchmarkResolver(b *testing.B, manifest string) (*withops.Resolver, *bdc.BOSHDeployment) {
+	m, err := bdm.LoadYAML([]byte(manifest))
+	if err != nil {
+		b.Fatal(err)
+	}
+	vars, err := m.ImplicitVariables()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	objects := []runtime.Object{
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "manifest", Namespace: "default"},
+			Data:       map[string]string{bdc.ManifestSpecName: manifest},
+		},
+	}
+	secrets := map[string]*corev1.Secret{}
+	for _, v := range vars {
+		name, key := v, bdc.ImplicitVariableKeyName
+		if parts := strings.Split(v, "/"); len(parts) == 2 {
+			name, key = parts[0], parts[1]
+		}
+		secretName := names.DeploymentSecretName(names.DeploymentSecretTypeVariable, benchmarkDeployment, name)
+
+		secret, ok := secrets[secretName]
+		if !ok {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+				Data:       map[string][]byte{},
+			}
+			secrets[secretName] = secret
+			objects = append(objects, secret)
+		}
+		secret.Data[key] = []byte("value-of-" + v)
+	}
+
+	resolver := withops.NewResolver(
+		fakeClient.NewFakeClient(objects...),
+		func() withops.Interpolator { return withops.NewInterpolator() },
+		func(deploymentName string, m bdm.Manifest) (withops.DomainNameService, error) {
+			return boshdns.NewSimpleDomainNameService(""), nil
+		},
+	)
+	deployment := &bdc.BOSHDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: benchmarkDeployment, Namespace: "default"},
+		Spec: bdc.BOSHDeploymentSpec{
+			Manifest: bdc.ResourceReference{Type: bdc.ConfigMapReference, Name: "manifest"},
+		},
+	}
+	return resolver, deployment
+}
+
+// manyImplicitVariables adds n properties with implicit variables to the
+// first instance group of the manifest
+func manyImplicitVariables(b *testing.B, manifest string, n int) string {
+	m, err := bdm.LoadYAML([]byte(manifest))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	properties := map[string]interface{}{}
+	for i := 0; i < n; i++ {
+		properties[fmt.Sprintf("property-%d", i)] = fmt.Sprintf("((var-%d))", i)
+	}
+	if m.InstanceGroups[0].Properties.Properties == nil {
+		m.InstanceGroups[0].Properties.Properties = map[string]interface{}{}
+	}
+	m.InstanceGroups[0].Properties.Properties["benchmark"] = properties
+
+	bytes, err := m.Marshal()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return string(bytes)
+}
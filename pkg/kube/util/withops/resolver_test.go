@@ -1,7 +1,13 @@
 package withops_test
 
 import (
+	"context"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -10,6 +16,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -17,6 +24,7 @@ import (
 	bdc "code.cloudfoundry.org/cf-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/controllers/fakes"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/cf-operator/pkg/kube/util/crossnamespace"
 	"code.cloudfoundry.org/cf-operator/pkg/kube/util/withops"
 )
 
@@ -919,5 +927,459 @@ instance_groups:
 			Expect(sslProps["cert"]).To(Equal("the-cert"))
 			Expect(sslProps["key"]).To(Equal("the-key"))
 		})
+
+		Context("when using an url", func() {
+			var deployment *bdc.BOSHDeployment
+
+			BeforeEach(func() {
+				deployment = &bdc.BOSHDeployment{
+					Spec: bdc.BOSHDeploymentSpec{
+						Manifest: bdc.ResourceReference{
+							Type: bdc.URLReference,
+							Name: remoteFileServer.URL() + validManifestPath,
+						},
+					},
+				}
+			})
+
+			It("uses the credentials from the secret", func() {
+				err := client.Create(context.Background(), &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "url-credentials", Namespace: "default"},
+					Data: map[string][]byte{
+						"username": []byte("admin"),
+						"password": []byte("secret"),
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				remoteFileServer.RouteToHandler("GET", "/protected-manifest.yml", ghttp.CombineHandlers(
+					ghttp.VerifyBasicAuth("admin", "secret"),
+					ghttp.RespondWith(http.StatusOK, `---
+instance_groups:
+  - name: component8
+    instances: 1`),
+				))
+				deployment.Spec.Manifest.Name = remoteFileServer.URL() + "/protected-manifest.yml"
+				deployment.Spec.Manifest.SecretName = "url-credentials"
+
+				manifest, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(manifest.InstanceGroups[0].Name).To(Equal("component8"))
+			})
+
+			It("accepts content matching the checksum", func() {
+				remoteFileServer.RouteToHandler("GET", "/pinned-manifest.yml", ghttp.RespondWith(http.StatusOK, "---\n"))
+				deployment.Spec.Manifest.Name = remoteFileServer.URL() + "/pinned-manifest.yml"
+				deployment.Spec.Manifest.SHA256 = "sha256:f52d711103d50a437830c6fbcd04fb4bab49a0f82f6d26d1c791c6e8488dd090"
+
+				_, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("throws an error if the content does not match the checksum", func() {
+				deployment.Spec.Manifest.SHA256 = strings.Repeat("0", 64)
+
+				_, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("checksum mismatch for url"))
+			})
+
+			It("throws an error if the server does not respond with success", func() {
+				remoteFileServer.UnhandledRequestStatusCode = http.StatusNotFound
+				deployment.Spec.Manifest.Name = remoteFileServer.URL() + "/not-found-manifest.yml"
+
+				_, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("unexpected response '404 Not Found'"))
+			})
+		})
+
+		Context("when variables are not strings", func() {
+			var deployment *bdc.BOSHDeployment
+
+			BeforeEach(func() {
+				for _, obj := range []runtime.Object{
+					&corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{Name: "manifest-with-typed-vars", Namespace: "default"},
+						Data: map[string]string{bdc.ManifestSpecName: `---
+name: foo
+instance_groups:
+  - name: component1
+    instances: 1
+    properties:
+      port: ((port))
+      url: https://((system_domain)):((port))
+      enabled: ((enabled))
+      password: ((password))
+      pin: ((pin))
+      token: ((token))
+      login: admin:((password))
+      ca: ((ca))
+      ca_cert: ((ca.certificate))
+      hosts:
+      - ((system_domain))
+      - ((port))
+`},
+					},
+					&corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{Name: "typed-vars", Namespace: "default"},
+						Data: map[string]string{bdc.VarsSpecName: `---
+port: 8443
+ca:
+  certificate: the-cert
+  private_key: the-key
+`},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "foo-deployment.var-enabled", Namespace: "default"},
+						Data:       map[string][]byte{"value": []byte("true")},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "foo-deployment.var-password", Namespace: "default"},
+						Data:       map[string][]byte{"value": []byte("yes")},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "foo-deployment.var-pin", Namespace: "default"},
+						Data:       map[string][]byte{"value": []byte("0123")},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "foo-deployment.var-token", Namespace: "default"},
+						Data:       map[string][]byte{"value": []byte("12345678901234567890")},
+					},
+				} {
+					Expect(client.Create(context.Background(), obj)).To(Succeed())
+				}
+
+				deployment = &bdc.BOSHDeployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "foo-deployment",
+					},
+					Spec: bdc.BOSHDeploymentSpec{
+						Manifest: bdc.ResourceReference{
+							Type: bdc.ConfigMapReference,
+							Name: "manifest-with-typed-vars",
+						},
+						Vars: []bdc.ResourceReference{
+							{
+								Type: bdc.ConfigMapReference,
+								Name: "typed-vars",
+							},
+						},
+					},
+				}
+			})
+
+			It("replaces whole value placeholders by typed values", func() {
+				m, implicitVars, err := resolver.Manifest(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(implicitVars).To(ConsistOf(
+					"foo-deployment.var-system-domain",
+					"foo-deployment.var-enabled",
+					"foo-deployment.var-password",
+					"foo-deployment.var-pin",
+					"foo-deployment.var-token",
+				))
+
+				properties := m.InstanceGroups[0].Properties.Properties
+				Expect(properties["port"]).To(Equal(float64(8443)))
+				Expect(properties["enabled"]).To(Equal("true"))
+				Expect(properties["ca"]).To(Equal(map[string]interface{}{
+					"certificate": "the-cert",
+					"private_key": "the-key",
+				}))
+				Expect(properties["ca_cert"]).To(Equal("the-cert"))
+				Expect(properties["hosts"]).To(Equal([]interface{}{"example.com", float64(8443)}))
+			})
+
+			It("keeps implicit variables as strings", func() {
+				m, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+
+				properties := m.InstanceGroups[0].Properties.Properties
+				Expect(properties["password"]).To(Equal("yes"))
+				Expect(properties["pin"]).To(Equal("0123"))
+				Expect(properties["token"]).To(Equal("12345678901234567890"))
+				Expect(properties["login"]).To(Equal("admin:yes"))
+			})
+
+			It("concatenates embedded placeholders", func() {
+				m, _, err := resolver.ManifestDetailed(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(m.InstanceGroups[0].Properties.Properties["url"]).To(Equal("https://example.com:8443"))
+			})
+
+			It("throws an error if a map is embedded in a string", func() {
+				Expect(client.Create(context.Background(), &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "manifest-with-embedded-map", Namespace: "default"},
+					Data: map[string]string{bdc.ManifestSpecName: `---
+instance_groups:
+  - name: component1
+    properties:
+      ca: 'ca: ((ca))'
+`},
+				})).To(Succeed())
+				deployment.Spec.Manifest.Name = "manifest-with-embedded-map"
+
+				_, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid type"))
+			})
+		})
+
+		Context("when using vars", func() {
+			var deployment *bdc.BOSHDeployment
+
+			BeforeEach(func() {
+				for _, obj := range []runtime.Object{
+					&corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{Name: "domain-vars", Namespace: "default"},
+						Data: map[string]string{bdc.VarsSpecName: `---
+system_domain: vars.example.com
+foo-pass:
+  password: the-password
+`},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "override-vars", Namespace: "default"},
+						Data: map[string][]byte{bdc.VarsSpecName: []byte(`---
+system_domain: override.example.com
+`)},
+					},
+				} {
+					Expect(client.Create(context.Background(), obj)).To(Succeed())
+				}
+
+				deployment = &bdc.BOSHDeployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "foo-deployment",
+					},
+					Spec: bdc.BOSHDeploymentSpec{
+						Manifest: bdc.ResourceReference{
+							Type: bdc.ConfigMapReference,
+							Name: "manifest-with-vars",
+						},
+						Vars: []bdc.ResourceReference{
+							{
+								Type: bdc.ConfigMapReference,
+								Name: "domain-vars",
+							},
+						},
+					},
+				}
+			})
+
+			It("replaces variables before implicit variables are looked up", func() {
+				m, implicitVars, err := resolver.Manifest(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(m.Variables[1].Options.CommonName).To(Equal("vars.example.com"))
+				Expect(implicitVars).To(BeEmpty())
+			})
+
+			It("replaces fields of map variables", func() {
+				m, _, err := resolver.ManifestDetailed(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(m.InstanceGroups[1].Properties.Properties["password"]).To(Equal("the-password"))
+			})
+
+			It("gives precedence to later vars references", func() {
+				deployment.Spec.Vars = append(deployment.Spec.Vars, bdc.ResourceReference{
+					Type: bdc.SecretReference,
+					Name: "override-vars",
+				})
+
+				m, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(m.Variables[1].Options.CommonName).To(Equal("override.example.com"))
+			})
+
+			It("throws an error if the vars can not be found", func() {
+				deployment.Spec.Vars[0].Name = "missing-vars"
+
+				_, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to load vars 'missing-vars'"))
+			})
+
+			It("throws an error if the vars are not a YAML map", func() {
+				Expect(client.Create(context.Background(), &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "invalid-vars", Namespace: "default"},
+					Data:       map[string]string{bdc.VarsSpecName: "- foo"},
+				})).To(Succeed())
+				deployment.Spec.Vars[0].Name = "invalid-vars"
+
+				_, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to unmarshal vars 'invalid-vars'"))
+			})
+		})
+
+		Context("when referencing another namespace", func() {
+			var deployment *bdc.BOSHDeployment
+
+			BeforeEach(func() {
+				err := client.Create(context.Background(), &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "shared-ops", Namespace: "shared"},
+					Data:       map[string]string{bdc.OpsSpecName: replaceOpsStr},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				deployment = &bdc.BOSHDeployment{
+					Spec: bdc.BOSHDeploymentSpec{
+						Manifest: bdc.ResourceReference{
+							Type: bdc.ConfigMapReference,
+							Name: "base-manifest",
+						},
+						Ops: []bdc.ResourceReference{
+							{
+								Type:      bdc.ConfigMapReference,
+								Name:      "shared-ops",
+								Namespace: "shared",
+							},
+						},
+					},
+				}
+			})
+
+			AfterEach(func() {
+				crossnamespace.SetAllowedNamespaces(nil)
+			})
+
+			It("applies ops from an allowed namespace", func() {
+				crossnamespace.SetAllowedNamespaces([]string{"shared"})
+				interpolator.InterpolateReturns([]byte(`---
+instance_groups:
+  - name: component1
+    instances: 2
+`), nil)
+
+				manifest, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(manifest.InstanceGroups[0].Instances).To(Equal(2))
+
+				Expect(interpolator.BuildOpsCallCount()).To(Equal(1))
+				Expect(string(interpolator.BuildOpsArgsForCall(0))).To(Equal(replaceOpsStr))
+			})
+
+			It("throws an error if the namespace is not allowed", func() {
+				_, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("configmap reference 'shared-ops' to namespace 'shared' is not allowed"))
+			})
+		})
+
+		Context("when using a git repository", func() {
+			var (
+				tmpDir     string
+				repository string
+				work       string
+				deployment *bdc.BOSHDeployment
+			)
+
+			git := func(dir string, args ...string) string {
+				args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+				cmd := exec.Command("git", args...)
+				cmd.Dir = dir
+				out, err := cmd.CombinedOutput()
+				Expect(err).ToNot(HaveOccurred(), string(out))
+				return strings.TrimSpace(string(out))
+			}
+
+			commit := func(path, content string) string {
+				Expect(ioutil.WriteFile(filepath.Join(work, path), []byte(content), 0644)).To(Succeed())
+				git(work, "add", "-A")
+				git(work, "commit", "--quiet", "-m", "update "+path)
+				git(work, "push", "--quiet", "origin", "HEAD:main")
+				return git(work, "rev-parse", "HEAD")
+			}
+
+			BeforeEach(func() {
+				var err error
+				tmpDir, err = ioutil.TempDir("", "withops-git")
+				Expect(err).ToNot(HaveOccurred())
+
+				repository = filepath.Join(tmpDir, "remote.git")
+				work = filepath.Join(tmpDir, "work")
+				git(tmpDir, "init", "--quiet", "--bare", "--initial-branch=main", repository)
+				git(tmpDir, "clone", "--quiet", repository, work)
+
+				deployment = &bdc.BOSHDeployment{
+					Spec: bdc.BOSHDeploymentSpec{
+						Manifest: bdc.ResourceReference{
+							Type: bdc.GitReference,
+							Name: repository,
+							Ref:  "main",
+							Path: "manifest.yml",
+						},
+					},
+				}
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(tmpDir)).To(Succeed())
+			})
+
+			It("resolves the manifest and records the commit", func() {
+				sha := commit("manifest.yml", `---
+instance_groups:
+  - name: component6
+    instances: 1`)
+
+				manifest, gitReferences, err := resolver.ManifestWithGitReferences(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(manifest.InstanceGroups).To(HaveLen(1))
+				Expect(manifest.InstanceGroups[0].Name).To(Equal("component6"))
+				Expect(gitReferences).To(Equal([]bdc.GitReferenceStatus{
+					{Repository: repository, Ref: "main", Path: "manifest.yml", Commit: sha},
+				}))
+				Expect(deployment.Status.GitReferences).To(BeEmpty())
+			})
+
+			It("resolves the new commit once the ref moved", func() {
+				commit("manifest.yml", `---
+instance_groups:
+  - name: component6
+    instances: 1`)
+				_, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+
+				sha := commit("manifest.yml", `---
+instance_groups:
+  - name: component7
+    instances: 1`)
+				manifest, gitReferences, err := resolver.ManifestWithGitReferences(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(manifest.InstanceGroups[0].Name).To(Equal("component7"))
+				Expect(gitReferences[0].Commit).To(Equal(sha))
+			})
+
+			It("doesn't change the status of the BOSHDeployment", func() {
+				commit("manifest.yml", `---
+instance_groups:
+  - name: component6
+    instances: 1`)
+
+				_, _, err := resolver.ManifestDetailed(deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deployment.Status.GitReferences).To(BeEmpty())
+			})
+
+			It("throws an error if the credentials secret can not be found", func() {
+				commit("manifest.yml", "---\n")
+				deployment.Spec.Manifest.SecretName = "missing-credentials"
+
+				_, _, err := resolver.Manifest(deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to retrieve credentials for git reference"))
+			})
+
+			It("throws an error if the path does not exist", func() {
+				commit("manifest.yml", "---\n")
+				deployment.Spec.Manifest.Path = "missing.yml"
+
+				_, gitReferences, err := resolver.ManifestWithGitReferences(deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to resolve manifest from git repository"))
+				Expect(gitReferences).To(BeEmpty())
+			})
+		})
 	})
 })